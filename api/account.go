@@ -3,16 +3,36 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	db "gobank/db/sqlc"
+	"gobank/util"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
+// An account as returned by the account endpoints, with the part of its overdraft limit still available
+type accountResponse struct {
+	db.Account
+	AvailableCredit int64 `json:"available_credit"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	// The credit used is the overdrawn amount. Lowering the limit below it leaves nothing available, not a negative
+	credit := account.OverdraftLimit
+	if account.Balance < 0 {
+		credit = max(0, credit+account.Balance)
+	}
+	return accountResponse{Account: account, AvailableCredit: credit}
+}
+
 type createAccountRequest struct {
-	Owner    string `json:"owner" validate:"required"`
-	Currency string `json:"currency" validate:"required,oneof=USD VND EUR"`
+	Owner       string            `json:"owner" validate:"required"`
+	Currency    string            `json:"currency" validate:"required,oneof=USD VND EUR"`
+	AccountType string            `json:"account_type" validate:"omitempty,oneof=checking savings business"`
+	Nickname    string            `json:"nickname" validate:"max=50"`
+	Labels      map[string]string `json:"labels" validate:"max=20,dive,keys,min=1,max=63,endkeys,max=255"`
 }
 
 func (server *Server) createAccount(w http.ResponseWriter, r *http.Request) {
@@ -29,21 +49,42 @@ func (server *Server) createAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Customers open accounts for themselves, co-owners are invited afterwards
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+	if req.Owner != username {
+		server.WriteError(w, http.StatusForbidden, "accounts can only be opened for yourself")
+		return
+	}
+
+	// Accounts are checking accounts unless told otherwise
+	if req.AccountType == "" {
+		req.AccountType = db.AccountTypeChecking
+	}
+
 	// Create new account into database
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
-		Balance:  0, // Default balance when creating account
-		Currency: req.Currency,
+		Owner:       req.Owner,
+		Balance:     0, // Default balance when creating account
+		Currency:    req.Currency,
+		AccountType: req.AccountType,
+		Nickname:    req.Nickname,
 	}
-	account, err := server.store.CreateAccount(r.Context(), arg)
+	if req.Labels != nil {
+		arg.Labels, _ = json.Marshal(req.Labels)
+	}
+	account, err := server.store.CreateAccountTx(r.Context(), arg)
 	if err != nil {
-		server.logger.Error("POST /account: failed to create new account", "error", err)
+		server.logger.ErrorContext(r.Context(), "POST /account: failed to create new account", "error", err)
 		server.WriteError(w, http.StatusInternalServerError, "Failed to create new account")
 		return
 	}
 
 	// Return the newly created account back to client
-	server.WriteJSON(w, http.StatusCreated, account)
+	w.Header().Set("ETag", accountETag(account))
+	server.WriteJSON(w, http.StatusCreated, newAccountResponse(account))
 }
 
 func (server *Server) getAccount(w http.ResponseWriter, r *http.Request) {
@@ -57,24 +98,68 @@ func (server *Server) getAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := server.authorizeAccount(w, r, id, db.AccountPermissionView); !ok {
+		return
+	}
+
 	// Get account by id
 	account, err := server.store.GetAccount(r.Context(), id)
 	if err != nil {
 		// If ID not match any record in database
-		if err == sql.ErrNoRows {
-			server.logger.Warn("GET /account/{id}: account not found", "account_id", id)
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.logger.WarnContext(r.Context(), "GET /account/{id}: account not found", "account_id", id)
 			server.WriteError(w, http.StatusNotFound, "account not found")
 			return
 		}
 
 		// Other database errors
-		server.logger.Error("GET /account/{id}: failed to get account", "account_id", id)
+		server.logger.ErrorContext(r.Context(), "GET /account/{id}: failed to get account", "account_id", id)
 		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get account with ID: %d", id))
 		return
 	}
 
-	// Return the fetched account to client
-	server.WriteJSON(w, http.StatusFound, account)
+	// Return the fetched account to client, with its version so the client can update it with If-Match
+	w.Header().Set("ETag", accountETag(account))
+	server.WriteJSON(w, http.StatusFound, newAccountResponse(account))
+}
+
+// Get an account by its account number, the only ID customers see. Spaces and hyphens grouping the digits are allowed
+func (server *Server) getAccountByNumber(w http.ResponseWriter, r *http.Request) {
+	account, ok := server.accountByNumber(w, r, r.PathValue("number"))
+	if !ok {
+		return
+	}
+
+	if _, ok := server.authorizeAccount(w, r, account.AccountID, db.AccountPermissionView); !ok {
+		return
+	}
+
+	w.Header().Set("ETag", accountETag(account))
+	server.WriteJSON(w, http.StatusFound, newAccountResponse(account))
+}
+
+// Helper method: check the check digits of an account number and get its account. A wrong number is reported as
+// invalid rather than not found, so the customer knows to check for a typo. On failure, it writes the error response
+func (server *Server) accountByNumber(w http.ResponseWriter, r *http.Request, raw string) (db.Account, bool) {
+	number, ok := util.NormalizeAccountNumber(raw)
+	if !ok {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid account number: %s", raw))
+		return db.Account{}, false
+	}
+
+	account, err := server.store.GetAccountByNumber(r.Context(), number)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "account not found")
+			return account, false
+		}
+
+		server.logger.ErrorContext(r.Context(), "failed to get account by number", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get account")
+		return account, false
+	}
+
+	return account, true
 }
 
 func (server *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
@@ -95,16 +180,183 @@ func (server *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get list of accounts
-	accounts, err := server.store.ListAccount(r.Context(), db.ListAccountParams{
+	// Only the accounts the caller owns or co-owns are listed
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	// Optional filters
+	arg := db.ListAccountParams{
+		Member: sql.NullString{String: username, Valid: true},
 		Limit:  int32(pageSize),
 		Offset: int32((pageId - 1) * pageSize),
-	})
+	}
+	if !server.parseAccountFilters(w, r, &arg) {
+		return
+	}
+
+	// Get list of accounts
+	accounts, err := server.store.ListAccount(r.Context(), arg)
 	if err != nil {
-		server.logger.Error("GET /accounts: failed to get list of accounts", "error", err)
+		server.logger.ErrorContext(r.Context(), "GET /accounts: failed to get list of accounts", "error", err)
 		server.WriteError(w, http.StatusInternalServerError, "failed to get list of accounts")
 		return
 	}
 
-	server.WriteJSON(w, http.StatusFound, accounts)
+	response := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		response[i] = newAccountResponse(account)
+	}
+	server.WriteJSON(w, http.StatusFound, response)
+}
+
+// Helper method: parse the account_type, currency and label query parameters of listAccounts. A label is either
+// key:value, matching accounts holding that label, or key, matching accounts holding the key with any value. The
+// label parameter can be repeated. On failure, it writes the error response
+func (server *Server) parseAccountFilters(w http.ResponseWriter, r *http.Request, arg *db.ListAccountParams) bool {
+	params := r.URL.Query()
+
+	if accountType := params.Get("account_type"); accountType != "" {
+		if server.validate.Var(accountType, "oneof=checking savings business") != nil {
+			server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter account_type: %s", accountType))
+			return false
+		}
+		arg.AccountType = sql.NullString{String: accountType, Valid: true}
+	}
+
+	if currency := params.Get("currency"); currency != "" {
+		if server.validate.Var(currency, "oneof=USD VND EUR") != nil {
+			server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter currency: %s", currency))
+			return false
+		}
+		arg.Currency = sql.NullString{String: currency, Valid: true}
+	}
+
+	labels := make(map[string]string)
+	for _, label := range params["label"] {
+		key, value, hasValue := strings.Cut(label, ":")
+		if key == "" {
+			server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter label: %s", label))
+			return false
+		}
+
+		if hasValue {
+			labels[key] = value
+		} else {
+			arg.LabelKeys = append(arg.LabelKeys, key)
+		}
+	}
+	if len(labels) > 0 {
+		arg.Labels, _ = json.Marshal(labels)
+	}
+
+	return true
+}
+
+type updateAccountRequest struct {
+	AccountType *string            `json:"account_type" validate:"omitnil,oneof=checking savings business"`
+	Nickname    *string            `json:"nickname" validate:"omitnil,max=50"`
+	Labels      *map[string]string `json:"labels" validate:"omitnil,max=20,dive,keys,min=1,max=63,endkeys,max=255"`
+}
+
+// Update the type, nickname or labels of an account. Only the fields in the request are changed, labels are
+// replaced as a whole ({} removes them all). With If-Match, the update only applies to the given version of the account
+func (server *Server) updateAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req updateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := server.validate.Struct(req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	if req.AccountType == nil && req.Nickname == nil && req.Labels == nil {
+		server.WriteError(w, http.StatusBadRequest, "nothing to update")
+		return
+	}
+
+	if _, ok := server.authorizeAccount(w, r, id, db.AccountPermissionManage); !ok {
+		return
+	}
+
+	version, ok := server.parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	arg := db.UpdateAccountDetailsTxParams{
+		UpdateAccountDetailsParams: db.UpdateAccountDetailsParams{AccountID: id},
+		ExpectedVersion:            version,
+	}
+	if req.AccountType != nil {
+		arg.AccountType = sql.NullString{String: *req.AccountType, Valid: true}
+	}
+	if req.Nickname != nil {
+		arg.Nickname = sql.NullString{String: *req.Nickname, Valid: true}
+	}
+	if req.Labels != nil {
+		arg.Labels, _ = json.Marshal(*req.Labels)
+	}
+
+	account, err := server.store.UpdateAccountDetailsTx(r.Context(), arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "account not found")
+			return
+		}
+		if errors.Is(err, db.ErrVersionConflict) {
+			server.WriteError(w, http.StatusPreconditionFailed, "account was modified, fetch it again")
+			return
+		}
+		if db.ErrorCode(err) == db.CheckViolation {
+			server.WriteError(w, http.StatusUnprocessableEntity, "an account earning interest must stay a savings account")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "PATCH /accounts/{id}: failed to update account", "account_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to update account")
+		return
+	}
+
+	w.Header().Set("ETag", accountETag(account))
+	server.WriteJSON(w, http.StatusOK, newAccountResponse(account))
+}
+
+// Helper method: get the ETag of an account, its version as a strong entity tag
+func accountETag(account db.Account) string {
+	return strconv.Quote(strconv.FormatInt(account.Version, 10))
+}
+
+// Helper method: parse the If-Match header into the version the account must be at. 0 means any version, when the
+// header is missing or "*". An entity tag that can't be an account version never matches, so it returns 412 like a
+// stale one. On failure, it writes the error response
+func (server *Server) parseIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	if strings.Contains(header, ",") {
+		server.WriteError(w, http.StatusBadRequest, "If-Match must hold a single ETag")
+		return 0, false
+	}
+
+	// Weak entity tags never match with If-Match, which uses the strong comparison
+	raw, err := strconv.Unquote(header)
+	version, parseErr := strconv.ParseInt(raw, 10, 64)
+	if err != nil || parseErr != nil || version <= 0 {
+		server.WriteError(w, http.StatusPreconditionFailed, "account was modified, fetch it again")
+		return 0, false
+	}
+
+	return version, true
 }
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	db "gobank/db/sqlc"
+	"net/http"
+	"strings"
+)
+
+type accountOwnersResponse struct {
+	PrimaryOwner string            `json:"primary_owner"`
+	CoOwners     []db.AccountOwner `json:"co_owners"`
+}
+
+type inviteAccountOwnerRequest struct {
+	Owner      string `json:"owner" validate:"required"`
+	Permission string `json:"permission" validate:"required,oneof=view transact manage"`
+}
+
+// Helper method: get an account the caller holds the required permission on. On failure, it writes the error response
+func (server *Server) authorizedAccount(w http.ResponseWriter, r *http.Request, required string) (db.Account, string, bool) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return db.Account{}, "", false
+	}
+
+	username, ok := server.authorizeAccount(w, r, id, required)
+	if !ok {
+		return db.Account{}, "", false
+	}
+
+	account, err := server.store.GetAccount(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "account not found")
+			return account, "", false
+		}
+
+		server.logger.ErrorContext(r.Context(), "failed to get account", "account_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get account")
+		return account, "", false
+	}
+
+	return account, username, true
+}
+
+func (server *Server) listAccountOwners(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := server.authorizedAccount(w, r, db.AccountPermissionView)
+	if !ok {
+		return
+	}
+
+	owners, err := server.store.ListAccountOwners(r.Context(), account.AccountID)
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "GET /accounts/{id}/owners: failed to get co-owners", "account_id", account.AccountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get co-owners")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, accountOwnersResponse{PrimaryOwner: account.Owner, CoOwners: owners})
+}
+
+// Invite a user as co-owner of an account. The invitation of the primary owner is effective immediately, the one
+// of a co-owner with the manage permission is pending until the primary owner approves it
+func (server *Server) inviteAccountOwner(w http.ResponseWriter, r *http.Request) {
+	var req inviteAccountOwnerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := server.validate.Struct(req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	account, username, ok := server.authorizedAccount(w, r, db.AccountPermissionManage)
+	if !ok {
+		return
+	}
+
+	if req.Owner == account.Owner {
+		server.WriteError(w, http.StatusConflict, "user is already the primary owner")
+		return
+	}
+
+	status := db.AccountOwnerStatusPending
+	if username == account.Owner {
+		status = db.AccountOwnerStatusActive
+	}
+
+	owner, err := server.store.CreateAccountOwner(r.Context(), db.CreateAccountOwnerParams{
+		AccountID:  account.AccountID,
+		Owner:      req.Owner,
+		Permission: req.Permission,
+		Status:     status,
+		InvitedBy:  username,
+	})
+	if err != nil {
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation:
+			server.WriteError(w, http.StatusNotFound, "user not found")
+			return
+		case db.UniqueViolation:
+			server.WriteError(w, http.StatusConflict, "user is already a co-owner")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "POST /accounts/{id}/owners: failed to invite co-owner", "account_id", account.AccountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to invite co-owner")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusCreated, owner)
+}
+
+// Approve a pending co-owner. Only the primary owner can
+func (server *Server) approveAccountOwner(w http.ResponseWriter, r *http.Request) {
+	account, username, ok := server.authorizedAccount(w, r, db.AccountPermissionView)
+	if !ok {
+		return
+	}
+
+	if username != account.Owner {
+		server.WriteError(w, http.StatusForbidden, "only the primary owner can approve co-owners")
+		return
+	}
+
+	owner, err := server.store.ApproveAccountOwner(r.Context(), db.ApproveAccountOwnerParams{
+		AccountID: account.AccountID,
+		Owner:     strings.TrimSpace(r.PathValue("owner")),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "no pending invitation for this user")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "POST /accounts/{id}/owners/{owner}/approve: failed to approve co-owner", "account_id", account.AccountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to approve co-owner")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, owner)
+}
+
+// Remove a co-owner, or decline a pending invitation. The primary owner can remove anyone, a co-owner can only
+// leave the account
+func (server *Server) removeAccountOwner(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	// Users leaving the account only touch their own row, so no permission is needed: pending co-owners have none
+	// and must still be able to decline
+	owner := strings.TrimSpace(r.PathValue("owner"))
+	if owner == username {
+		server.deleteAccountOwner(w, r, id, owner)
+		return
+	}
+
+	account, _, ok := server.authorizedAccount(w, r, db.AccountPermissionView)
+	if !ok {
+		return
+	}
+
+	if username != account.Owner {
+		server.WriteError(w, http.StatusForbidden, "only the primary owner can remove co-owners")
+		return
+	}
+
+	server.deleteAccountOwner(w, r, account.AccountID, owner)
+}
+
+// Helper method: delete a co-owner row and write the response
+func (server *Server) deleteAccountOwner(w http.ResponseWriter, r *http.Request, accountID int64, owner string) {
+	if _, err := server.store.DeleteAccountOwner(r.Context(), db.DeleteAccountOwnerParams{
+		AccountID: accountID,
+		Owner:     owner,
+	}); err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "user is not a co-owner")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "DELETE /accounts/{id}/owners/{owner}: failed to remove co-owner", "account_id", accountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to remove co-owner")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Store of account 1, owned by alice, with its co-owners in memory. Every other method panics
+type accountOwnerStore struct {
+	db.Store
+	coOwners map[string]db.AccountOwner
+}
+
+func (store accountOwnerStore) GetAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	if accountID != 1 {
+		return db.Account{}, db.ErrRecordNotFound
+	}
+	return db.Account{AccountID: 1, Owner: "alice"}, nil
+}
+
+func (store accountOwnerStore) GetAccountPermission(ctx context.Context, arg db.GetAccountPermissionParams) (string, error) {
+	if arg.AccountID == 1 && arg.Owner == "alice" {
+		return db.AccountPermissionManage, nil
+	}
+	coOwner, ok := store.coOwners[arg.Owner]
+	if arg.AccountID != 1 || !ok || coOwner.Status != db.AccountOwnerStatusActive {
+		return "", db.ErrRecordNotFound
+	}
+	return coOwner.Permission, nil
+}
+
+func (store accountOwnerStore) DeleteAccountOwner(ctx context.Context, arg db.DeleteAccountOwnerParams) (db.AccountOwner, error) {
+	coOwner, ok := store.coOwners[arg.Owner]
+	if arg.AccountID != 1 || !ok {
+		return db.AccountOwner{}, db.ErrRecordNotFound
+	}
+	delete(store.coOwners, arg.Owner)
+	return coOwner, nil
+}
+
+func TestRemoveAccountOwner(t *testing.T) {
+	store := accountOwnerStore{coOwners: map[string]db.AccountOwner{
+		"bob":   {AccountID: 1, Owner: "bob", Permission: db.AccountPermissionView, Status: db.AccountOwnerStatusPending},
+		"carol": {AccountID: 1, Owner: "carol", Permission: db.AccountPermissionManage, Status: db.AccountOwnerStatusActive},
+		"dave":  {AccountID: 1, Owner: "dave", Permission: db.AccountPermissionView, Status: db.AccountOwnerStatusActive},
+	}}
+	server := &Server{
+		store:  store,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	testCases := []struct {
+		name     string
+		username string
+		owner    string
+		status   int
+	}{
+		{name: "Anonymous", owner: "bob", status: http.StatusUnauthorized},
+		{name: "StrangerRemovesInvitee", username: "mallory", owner: "bob", status: http.StatusNotFound},
+		{name: "StrangerLeaves", username: "mallory", owner: "mallory", status: http.StatusNotFound},
+		{name: "InviteeDeclines", username: "bob", owner: "bob", status: http.StatusNoContent},
+		{name: "CoOwnerRemovesCoOwner", username: "carol", owner: "dave", status: http.StatusForbidden},
+		{name: "CoOwnerLeaves", username: "carol", owner: "carol", status: http.StatusNoContent},
+		{name: "PrimaryOwnerRemovesCoOwner", username: "alice", owner: "dave", status: http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/accounts/1/owners/"+tc.owner, nil)
+			r.SetPathValue("id", "1")
+			r.SetPathValue("owner", tc.owner)
+			if tc.username != "" {
+				r.Header.Set(headerUsername, tc.username)
+			}
+			w := httptest.NewRecorder()
+
+			server.removeAccountOwner(w, r)
+			require.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+
+	require.Empty(t, store.coOwners)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	db "gobank/db/sqlc"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountETag(t *testing.T) {
+	require.Equal(t, `"3"`, accountETag(db.Account{Version: 3}))
+}
+
+func TestAccountResponseAvailableCredit(t *testing.T) {
+	testCases := []struct {
+		name    string
+		balance int64
+		limit   int64
+		credit  int64
+	}{
+		{name: "NoOverdraft", balance: 100, limit: 0, credit: 0},
+		{name: "Positive", balance: 100, limit: 500, credit: 500},
+		{name: "Overdrawn", balance: -200, limit: 500, credit: 300},
+		{name: "AtLimit", balance: -500, limit: 500, credit: 0},
+		{name: "LimitLowered", balance: -500, limit: 100, credit: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := newAccountResponse(db.Account{Balance: tc.balance, OverdraftLimit: tc.limit})
+			require.Equal(t, tc.credit, response.AvailableCredit)
+
+			// The account fields stay at the top level of the JSON, next to the available credit
+			data, err := json.Marshal(response)
+			require.NoError(t, err)
+			var fields map[string]any
+			require.NoError(t, json.Unmarshal(data, &fields))
+			require.Equal(t, float64(tc.credit), fields["available_credit"])
+			require.Equal(t, float64(tc.limit), fields["overdraft_limit"])
+			require.Equal(t, float64(tc.balance), fields["balance"])
+		})
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	server := &Server{}
+
+	testCases := []struct {
+		name    string
+		header  string
+		version int64
+		status  int
+	}{
+		{name: "Missing", header: "", version: 0},
+		{name: "Any", header: "*", version: 0},
+		{name: "Version", header: `"7"`, version: 7},
+		{name: "Weak", header: `W/"7"`, status: http.StatusPreconditionFailed},
+		{name: "Unquoted", header: "7", status: http.StatusPreconditionFailed},
+		{name: "NotAVersion", header: `"abc"`, status: http.StatusPreconditionFailed},
+		{name: "List", header: `"7", "8"`, status: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/accounts/1/deposit", nil)
+			if tc.header != "" {
+				r.Header.Set("If-Match", tc.header)
+			}
+			w := httptest.NewRecorder()
+
+			version, ok := server.parseIfMatch(w, r)
+			if tc.status != 0 {
+				require.False(t, ok)
+				require.Equal(t, tc.status, w.Code)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tc.version, version)
+		})
+	}
+}
+
+func TestParseAccountFilters(t *testing.T) {
+	server := &Server{validate: validator.New(validator.WithRequiredStructEnabled())}
+
+	r := httptest.NewRequest(http.MethodGet, "/accounts?account_type=savings&currency=EUR&label=team:ops&label=rent", nil)
+	w := httptest.NewRecorder()
+
+	var arg db.ListAccountParams
+	require.True(t, server.parseAccountFilters(w, r, &arg))
+	require.Equal(t, sql.NullString{String: db.AccountTypeSavings, Valid: true}, arg.AccountType)
+	require.Equal(t, sql.NullString{String: "EUR", Valid: true}, arg.Currency)
+	require.JSONEq(t, `{"team": "ops"}`, string(arg.Labels))
+	require.Equal(t, []string{"rent"}, arg.LabelKeys)
+
+	// No filter leaves every parameter null, matching all accounts
+	arg = db.ListAccountParams{}
+	require.True(t, server.parseAccountFilters(w, httptest.NewRequest(http.MethodGet, "/accounts", nil), &arg))
+	require.Equal(t, db.ListAccountParams{}, arg)
+
+	for _, query := range []string{"account_type=gold", "currency=GBP", "label=:ops"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/accounts?"+query, nil)
+		require.False(t, server.parseAccountFilters(w, r, &db.ListAccountParams{}), query)
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestUpdateAccountRequestValidation(t *testing.T) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	testCases := []struct {
+		body  string
+		valid bool
+	}{
+		{body: `{}`, valid: true},
+		{body: `{"account_type": "business", "nickname": "Rent", "labels": {"purpose": "rent"}}`, valid: true},
+		{body: `{"labels": {}}`, valid: true},
+		{body: `{"account_type": "gold"}`, valid: false},
+		{body: `{"nickname": "` + strings.Repeat("a", 51) + `"}`, valid: false},
+		{body: `{"labels": {"": "empty key"}}`, valid: false},
+		{body: `{"labels": {"key": "` + strings.Repeat("a", 256) + `"}}`, valid: false},
+	}
+
+	for _, tc := range testCases {
+		var req updateAccountRequest
+		require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+
+		err := validate.Struct(req)
+		if tc.valid {
+			require.NoError(t, err, tc.body)
+		} else {
+			require.Error(t, err, tc.body)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"net/http"
+	"strings"
+)
+
+// Header carrying the username of the caller. The server doesn't authenticate users itself: it must run behind a
+// gateway that authenticates them and sets this header, overwriting any value sent by the client
+const headerUsername = "X-Username"
+
+// Helper method: get the username of the caller. On failure, it writes the error response
+func (server *Server) caller(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username := strings.TrimSpace(r.Header.Get(headerUsername))
+	if username == "" {
+		server.WriteError(w, http.StatusUnauthorized, fmt.Sprintf("missing %s header", headerUsername))
+		return "", false
+	}
+	if username == db.BankOwner {
+		server.WriteError(w, http.StatusUnauthorized, fmt.Sprintf("reserved username: %s", username))
+		return "", false
+	}
+	return username, true
+}
+
+// Helper method: check that the caller holds the required permission on an account, as its primary owner or an
+// active co-owner. An account the caller can't access at all is reported as not found, so its existence isn't
+// revealed, nor its ID when the caller only gave its account number. On failure, it writes the error response
+func (server *Server) authorizeAccount(w http.ResponseWriter, r *http.Request, accountID int64, required string) (string, bool) {
+	username, ok := server.caller(w, r)
+	if !ok {
+		return "", false
+	}
+
+	permission, err := server.store.GetAccountPermission(r.Context(), db.GetAccountPermissionParams{
+		AccountID: accountID,
+		Owner:     username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "account not found")
+			return "", false
+		}
+
+		server.logger.ErrorContext(r.Context(), "failed to get account permission", "account_id", accountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get account with ID: %d", accountID))
+		return "", false
+	}
+
+	if !db.HasPermission(permission, required) {
+		server.WriteError(w, http.StatusForbidden, fmt.Sprintf("%s permission required on this account", required))
+		return "", false
+	}
+
+	return username, true
+}
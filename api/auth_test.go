@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"errors"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Store answering GetAccountPermission from a map keyed by username, every other method panics
+type permissionStore struct {
+	db.Store
+	permissions map[string]string
+}
+
+func (store permissionStore) GetAccountPermission(ctx context.Context, arg db.GetAccountPermissionParams) (string, error) {
+	if arg.Owner == "broken" {
+		return "", errors.New("connection lost")
+	}
+	permission, ok := store.permissions[arg.Owner]
+	if !ok {
+		return "", db.ErrRecordNotFound
+	}
+	return permission, nil
+}
+
+func TestAuthorizeAccount(t *testing.T) {
+	server := &Server{
+		store: permissionStore{permissions: map[string]string{
+			"alice": db.AccountPermissionManage,
+			"bob":   db.AccountPermissionView,
+			"carol": db.AccountPermissionTransact,
+		}},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	testCases := []struct {
+		name     string
+		username string
+		required string
+		status   int
+	}{
+		{name: "Manager", username: "alice", required: db.AccountPermissionManage},
+		{name: "ManagerCanView", username: "alice", required: db.AccountPermissionView},
+		{name: "Viewer", username: "bob", required: db.AccountPermissionView},
+		{name: "ViewerCantTransact", username: "bob", required: db.AccountPermissionTransact, status: http.StatusForbidden},
+		{name: "TransactorCantManage", username: "carol", required: db.AccountPermissionManage, status: http.StatusForbidden},
+		{name: "Stranger", username: "mallory", required: db.AccountPermissionView, status: http.StatusNotFound},
+		{name: "Anonymous", username: "", required: db.AccountPermissionView, status: http.StatusUnauthorized},
+		{name: "Bank", username: db.BankOwner, required: db.AccountPermissionView, status: http.StatusUnauthorized},
+		{name: "StoreError", username: "broken", required: db.AccountPermissionView, status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/account/1", nil)
+			if tc.username != "" {
+				r.Header.Set(headerUsername, tc.username)
+			}
+			rec := httptest.NewRecorder()
+
+			username, ok := server.authorizeAccount(rec, r, 1, tc.required)
+			if tc.status == 0 {
+				require.True(t, ok)
+				require.Equal(t, tc.username, username)
+				return
+			}
+			require.False(t, ok)
+			require.Equal(t, tc.status, rec.Code)
+		})
+	}
+}
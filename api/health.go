@@ -0,0 +1,24 @@
+package api
+
+import (
+	"gobank/health"
+	"net/http"
+)
+
+// Liveness probe: the process is up and serving requests. It doesn't check dependencies, so a database outage
+// doesn't get the process restarted
+func (server *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	server.WriteJSON(w, http.StatusOK, health.Result{Status: health.StatusOK, Checks: map[string]string{}})
+}
+
+// Readiness probe: the dependencies are reachable, so the instance can receive traffic
+func (server *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	result := server.health.Run(r.Context())
+	if result.Status != health.StatusOK {
+		server.logger.WarnContext(r.Context(), "Readiness check failed", "checks", result.Checks)
+		server.WriteJSON(w, http.StatusServiceUnavailable, result)
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, result)
+}
@@ -1 +1,246 @@
 package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header carrying the request ID, both on requests (set by a proxy or the client) and on responses
+const headerRequestID = "X-Request-ID"
+
+// Middleware wraps a handler with extra behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Apply the middlewares to a handler. The first middleware is the outermost, so it sees the request first
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// Get the ID of the request the context belongs to, or "" outside of a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// Helper method: check that an incoming request ID is safe to log and echo back
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper method: generate a random request ID
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Middleware to propagate the X-Request-ID header, or generate one, into the request context and the response
+func (server *Server) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(headerRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(headerRequestID, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// ResponseWriter recording the status code and the number of bytes written, for the access log
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(p)
+	rec.bytes += n
+	return n, err
+}
+
+// Let http.ResponseController reach the underlying writer, e.g. to flush streamed statements
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Middleware to write one access log line per request
+func (server *Server) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		// The mux sets the matched pattern on the request, so it is known once the handler has run
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		server.logger.InfoContext(r.Context(), "HTTP request",
+			"method", r.Method,
+			"route", route,
+			"path", r.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+			"bytes", rec.bytes,
+		)
+	})
+}
+
+// Middleware to create one span per request. An incoming W3C trace context is continued
+func (server *Server) trace(next http.Handler) http.Handler {
+	tracer := otel.Tracer("gobank/api")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		rec := &responseRecorder{ResponseWriter: w}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+
+		// The route is only known once the mux has matched the request, so the span is renamed afterwards
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		span.SetName(route)
+		span.SetAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", r.URL.Path),
+			attribute.Int("http.response.status_code", status),
+			attribute.String("request_id", RequestIDFromContext(ctx)),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// Middleware to record the request count and duration metrics
+func (server *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		// Unmatched requests are grouped together, so random paths can't create new series
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		server.metrics.ObserveHTTPRequest(route, r.Method, status, time.Since(start))
+	})
+}
+
+// Middleware to turn a panicking handler into a JSON 500 response instead of a dropped connection
+func (server *Server) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w}
+
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+
+			// Deliberate aborts are handled by net/http itself
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			server.logger.ErrorContext(r.Context(), "Handler panicked",
+				"error", fmt.Sprint(err), "stack", string(debug.Stack()))
+
+			// Once the headers are sent, the status can't be changed anymore
+			if rec.status == 0 {
+				server.WriteError(rec, http.StatusInternalServerError, "internal server error")
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// slog handler adding the request ID from the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsSampled() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
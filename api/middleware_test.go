@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"gobank/health"
+	"gobank/metrics"
+	"gobank/util"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Helper method: create a server without database, logging JSON lines into buf
+func newTestServer(buf *bytes.Buffer) *Server {
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	return NewServer(util.Config{}, nil, nil, metrics.New(), health.NewChecker(time.Second), logger)
+}
+
+// Helper method: decode the JSON log lines
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	middleware := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), middleware("first"), middleware("second"))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, []string{"first", "second", "handler"}, order)
+}
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	server := newTestServer(&buf)
+
+	// A generated request ID is returned and logged
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/account/abc", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	id := rec.Header().Get(headerRequestID)
+	require.Len(t, id, 32)
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1)
+	access := lines[0]
+	require.Equal(t, "HTTP request", access["msg"])
+	require.Equal(t, id, access["request_id"])
+	require.Equal(t, "GET", access["method"])
+	require.Equal(t, "GET /account/{id}", access["route"])
+	require.Equal(t, float64(http.StatusBadRequest), access["status"])
+	require.Equal(t, float64(rec.Body.Len()), access["bytes"])
+	require.Contains(t, access, "latency")
+
+	// An incoming request ID is propagated
+	buf.Reset()
+	req := httptest.NewRequest(http.MethodGet, "/account/abc", nil)
+	req.Header.Set(headerRequestID, "upstream-id-1")
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+
+	require.Equal(t, "upstream-id-1", rec.Header().Get(headerRequestID))
+	require.Equal(t, "upstream-id-1", logLines(t, &buf)[0]["request_id"])
+
+	// Unknown routes are logged without a pattern
+	buf.Reset()
+	server.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	require.Equal(t, "unmatched", logLines(t, &buf)[0]["route"])
+}
+
+func TestRecoverPanic(t *testing.T) {
+	var buf bytes.Buffer
+	server := newTestServer(&buf)
+	server.mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	})
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	// The client gets a JSON error instead of a dropped connection
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"message":"internal server error"}`, rec.Body.String())
+
+	// The panic is logged with the request ID, then the access log records the 500
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	require.Equal(t, "Handler panicked", lines[0]["msg"])
+	require.Equal(t, "something went wrong", lines[0]["error"])
+	require.Equal(t, rec.Header().Get(headerRequestID), lines[0]["request_id"])
+	require.Equal(t, float64(http.StatusInternalServerError), lines[1]["status"])
+}
+
+func TestValidRequestID(t *testing.T) {
+	require.True(t, validRequestID("abc-123"))
+	require.False(t, validRequestID(""))
+	require.False(t, validRequestID("has space"))
+	require.False(t, validRequestID("line\nbreak"))
+	require.False(t, validRequestID(strings.Repeat("a", 129)))
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	server := newTestServer(&buf)
+
+	server.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/account/abc", nil))
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(),
+		`gobank_http_requests_total{method="GET",route="GET /account/{id}",status="400"} 1`)
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	// Record the spans in memory, restoring the global providers afterwards
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	var buf bytes.Buffer
+	server := newTestServer(&buf)
+
+	// The incoming trace context is continued, and the span is named after the route
+	req := httptest.NewRequest(http.MethodGet, "/account/abc", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	server.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /account/{id}", spans[0].Name())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", http.StatusBadRequest))
+
+	// The trace ID is added to the log lines
+	lines := logLines(t, &buf)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", lines[len(lines)-1]["trace_id"])
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	db "gobank/db/sqlc"
+	"net/http"
+	"time"
+)
+
+// Notification preferences of the caller. The owner is always the caller, so nobody can read or redirect the
+// alerts of another user
+type notificationPreferenceRequest struct {
+	Email               string `json:"email" validate:"omitempty,email"`
+	Phone               string `json:"phone" validate:"omitempty,e164"`
+	EmailEnabled        bool   `json:"email_enabled"`
+	SmsEnabled          bool   `json:"sms_enabled"`
+	LowBalanceThreshold *int64 `json:"low_balance_threshold" validate:"omitempty,min=0"`
+	LargeDebitThreshold *int64 `json:"large_debit_threshold" validate:"omitempty,min=0"`
+
+	// Currency of the thresholds, required with either of them. Only accounts in this currency are alerted
+	ThresholdCurrency string `json:"threshold_currency" validate:"required_with=LowBalanceThreshold LargeDebitThreshold,omitempty,oneof=USD VND EUR"`
+}
+
+type notificationPreferenceResponse struct {
+	Owner               string    `json:"owner"`
+	Email               string    `json:"email,omitempty"`
+	Phone               string    `json:"phone,omitempty"`
+	EmailEnabled        bool      `json:"email_enabled"`
+	SmsEnabled          bool      `json:"sms_enabled"`
+	LowBalanceThreshold *int64    `json:"low_balance_threshold"`
+	LargeDebitThreshold *int64    `json:"large_debit_threshold"`
+	ThresholdCurrency   string    `json:"threshold_currency,omitempty"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// Helper method: convert a nullable column to a pointer, so it is encoded as null in JSON
+func nullInt64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// Helper method: convert a pointer to a nullable column
+func ptrNullInt64(p *int64) sql.NullInt64 {
+	if p == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *p, Valid: true}
+}
+
+func newNotificationPreferenceResponse(pref db.NotificationPreference) notificationPreferenceResponse {
+	return notificationPreferenceResponse{
+		Owner:               pref.Owner,
+		Email:               pref.Email.String,
+		Phone:               pref.Phone.String,
+		EmailEnabled:        pref.EmailEnabled,
+		SmsEnabled:          pref.SmsEnabled,
+		LowBalanceThreshold: nullInt64Ptr(pref.LowBalanceThreshold),
+		LargeDebitThreshold: nullInt64Ptr(pref.LargeDebitThreshold),
+		ThresholdCurrency:   pref.ThresholdCurrency.String,
+		UpdatedAt:           pref.UpdatedAt,
+	}
+}
+
+func (server *Server) updateNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	// Get the JSON data
+	var req notificationPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	// Validate data. A channel can only be enabled with an address to send to
+	if err := server.validate.Struct(req); err != nil ||
+		(req.EmailEnabled && req.Email == "") ||
+		(req.SmsEnabled && req.Phone == "") {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	pref, err := server.store.UpsertNotificationPreference(r.Context(), db.UpsertNotificationPreferenceParams{
+		Owner:               username,
+		Email:               sql.NullString{String: req.Email, Valid: req.Email != ""},
+		Phone:               sql.NullString{String: req.Phone, Valid: req.Phone != ""},
+		EmailEnabled:        req.EmailEnabled,
+		SmsEnabled:          req.SmsEnabled,
+		LowBalanceThreshold: ptrNullInt64(req.LowBalanceThreshold),
+		LargeDebitThreshold: ptrNullInt64(req.LargeDebitThreshold),
+		ThresholdCurrency:   sql.NullString{String: req.ThresholdCurrency, Valid: req.ThresholdCurrency != ""},
+	})
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "PUT /notification-preferences: failed to save preferences", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to save notification preferences")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, newNotificationPreferenceResponse(pref))
+}
+
+func (server *Server) getNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	pref, err := server.store.GetNotificationPreference(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "notification preferences not found")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "GET /notification-preferences: failed to get preferences", "owner", username, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get notification preferences")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, newNotificationPreferenceResponse(pref))
+}
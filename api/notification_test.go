@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Store keeping notification preferences in memory, every other method panics
+type notificationStore struct {
+	db.Store
+	preferences map[string]db.NotificationPreference
+}
+
+func (store notificationStore) UpsertNotificationPreference(ctx context.Context, arg db.UpsertNotificationPreferenceParams) (db.NotificationPreference, error) {
+	pref := db.NotificationPreference{
+		Owner:        arg.Owner,
+		Email:        arg.Email,
+		Phone:        arg.Phone,
+		EmailEnabled: arg.EmailEnabled,
+		SmsEnabled:   arg.SmsEnabled,
+	}
+	store.preferences[arg.Owner] = pref
+	return pref, nil
+}
+
+func (store notificationStore) GetNotificationPreference(ctx context.Context, owner string) (db.NotificationPreference, error) {
+	pref, ok := store.preferences[owner]
+	if !ok {
+		return pref, db.ErrRecordNotFound
+	}
+	return pref, nil
+}
+
+func TestNotificationPreferenceOwner(t *testing.T) {
+	store := notificationStore{preferences: map[string]db.NotificationPreference{}}
+	server := &Server{
+		store:    store,
+		validate: newValidator(),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	do := func(handler http.HandlerFunc, target, username, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, strings.NewReader(body))
+		if username != "" {
+			r.Header.Set(headerUsername, username)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	body := `{"owner": "bob", "email": "alice@example.com", "email_enabled": true}`
+	require.Equal(t, http.StatusUnauthorized, do(server.updateNotificationPreference, "/notification-preferences", "", body).Code)
+	require.Equal(t, http.StatusUnauthorized, do(server.getNotificationPreference, "/notification-preferences?owner=bob", "", "").Code)
+
+	// The preferences are saved for the caller, whatever owner the body names
+	require.Equal(t, http.StatusOK, do(server.updateNotificationPreference, "/notification-preferences", "alice", body).Code)
+	require.Contains(t, store.preferences, "alice")
+	require.NotContains(t, store.preferences, "bob")
+
+	w := do(server.getNotificationPreference, "/notification-preferences", "alice", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "alice@example.com")
+
+	// Other users only get their own preferences
+	w = do(server.getNotificationPreference, "/notification-preferences?owner=alice", "mallory", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.NotContains(t, w.Body.String(), "alice@example.com")
+
+	// Thresholds need their currency
+	body = `{"large_debit_threshold": 50000}`
+	require.Equal(t, http.StatusBadRequest, do(server.updateNotificationPreference, "/notification-preferences", "alice", body).Code)
+	body = `{"large_debit_threshold": 50000, "threshold_currency": "EUR"}`
+	require.Equal(t, http.StatusOK, do(server.updateNotificationPreference, "/notification-preferences", "alice", body).Code)
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/util"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A payee as returned by the payee endpoints, with the end of its cooling-off period
+type payeeResponse struct {
+	db.Payee
+	CoolingOffEndsAt time.Time `json:"cooling_off_ends_at"`
+}
+
+func (server *Server) newPayeeResponse(payee db.Payee) payeeResponse {
+	return payeeResponse{Payee: payee, CoolingOffEndsAt: payee.CreatedAt.Add(server.config.Payee.CoolingOff)}
+}
+
+// Helper method: check whether a transfer of the amount to the payee is held back by its cooling-off period, and
+// when the period ends. Transfers below the large transfer amount are always allowed
+func (server *Server) payeeCoolingOff(payee db.Payee, amount int64, now time.Time) (time.Time, bool) {
+	endsAt := payee.CreatedAt.Add(server.config.Payee.CoolingOff)
+	return endsAt, amount >= server.config.Payee.LargeTransferAmount && now.Before(endsAt)
+}
+
+// Helper method: check that a transfer of the amount to the account is allowed. Large transfers to an account the
+// caller has no access to must go to a payee of the caller, and are held back until its cooling-off period ends, so
+// a stolen session can't send money straight to a new account. On failure, it writes the error response
+func (server *Server) allowLargeTransfer(w http.ResponseWriter, r *http.Request, username string, toAccountID, amount int64) bool {
+	if amount < server.config.Payee.LargeTransferAmount {
+		return true
+	}
+
+	// Moving money between the accounts of the caller is always allowed
+	_, err := server.store.GetAccountPermission(r.Context(), db.GetAccountPermissionParams{
+		AccountID: toAccountID,
+		Owner:     username,
+	})
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, db.ErrRecordNotFound) {
+		server.logger.ErrorContext(r.Context(), "failed to get account permission", "account_id", toAccountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to check the recipient")
+		return false
+	}
+
+	payee, err := server.store.GetPayeeByAccount(r.Context(), db.GetPayeeByAccountParams{
+		Owner:     username,
+		AccountID: toAccountID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusForbidden, fmt.Sprintf(
+				"transfers of %d or more to another account must go to a payee", server.config.Payee.LargeTransferAmount))
+			return false
+		}
+
+		server.logger.ErrorContext(r.Context(), "failed to get payee", "account_id", toAccountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to check the recipient")
+		return false
+	}
+
+	if endsAt, held := server.payeeCoolingOff(payee, amount, time.Now()); held {
+		server.WriteError(w, http.StatusForbidden, fmt.Sprintf(
+			"payee %d was added recently, transfers of %d or more are allowed from %s",
+			payee.PayeeID, server.config.Payee.LargeTransferAmount, endsAt.UTC().Format(time.RFC3339)))
+		return false
+	}
+
+	return true
+}
+
+type createPayeeRequest struct {
+	Nickname      string `json:"nickname" validate:"required,max=50"`
+	AccountNumber string `json:"account_number" validate:"required,account_number"`
+	OwnerName     string `json:"owner_name" validate:"required,max=100"`
+}
+
+type updatePayeeRequest struct {
+	Nickname string `json:"nickname" validate:"required,max=50"`
+}
+
+// Helper method: get the payee of the caller with the ID in the path. On failure, it writes the error response
+func (server *Server) getCallerPayee(w http.ResponseWriter, r *http.Request, payeeID int64, username string) (db.Payee, bool) {
+	payee, err := server.store.GetPayee(r.Context(), db.GetPayeeParams{PayeeID: payeeID, Owner: username})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, fmt.Sprintf("payee %d not found", payeeID))
+			return payee, false
+		}
+
+		server.logger.ErrorContext(r.Context(), "failed to get payee", "payee_id", payeeID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get payee with ID: %d", payeeID))
+		return payee, false
+	}
+
+	return payee, true
+}
+
+// Save an account as a payee of the caller. The caller gives the account number and the name of its holder, and the
+// account is only saved when both match, so account numbers can't be used to find out who holds them
+func (server *Server) createPayee(w http.ResponseWriter, r *http.Request) {
+	var req createPayeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := server.validate.Struct(req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	// Every attempt counts, matched or not
+	if resetAt, ok := server.payeeLimiter.allow(username, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(resetAt).Seconds())+1))
+		server.WriteError(w, http.StatusTooManyRequests, "too many payees added, try again later")
+		return
+	}
+
+	number, _ := util.NormalizeAccountNumber(req.AccountNumber)
+	payee, err := server.store.CreatePayee(r.Context(), db.CreatePayeeParams{
+		Owner:         username,
+		Nickname:      req.Nickname,
+		AccountNumber: number,
+		OwnerName:     req.OwnerName,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "no account with this number is held by this name")
+			return
+		}
+		if db.ErrorCode(err) == db.UniqueViolation {
+			server.WriteError(w, http.StatusConflict, "this account is already a payee")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "POST /payees: failed to create payee", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to create payee")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusCreated, server.newPayeeResponse(payee))
+}
+
+func (server *Server) listPayees(w http.ResponseWriter, r *http.Request) {
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	payees, err := server.store.ListPayees(r.Context(), username)
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "GET /payees: failed to get list of payees", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get list of payees")
+		return
+	}
+
+	response := make([]payeeResponse, len(payees))
+	for i, payee := range payees {
+		response[i] = server.newPayeeResponse(payee)
+	}
+	server.WriteJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) getPayee(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	payee, ok := server.getCallerPayee(w, r, id, username)
+	if !ok {
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, server.newPayeeResponse(payee))
+}
+
+// Rename a payee. The account can't change, a new payee must be added instead
+func (server *Server) updatePayee(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req updatePayeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := server.validate.Struct(req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	payee, err := server.store.UpdatePayeeNickname(r.Context(), db.UpdatePayeeNicknameParams{
+		PayeeID:  id,
+		Owner:    username,
+		Nickname: req.Nickname,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, fmt.Sprintf("payee %d not found", id))
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "PATCH /payees/{id}: failed to update payee", "payee_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to update payee")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, server.newPayeeResponse(payee))
+}
+
+func (server *Server) deletePayee(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := server.store.DeletePayee(r.Context(), db.DeletePayeeParams{PayeeID: id, Owner: username}); err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, fmt.Sprintf("payee %d not found", id))
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "DELETE /payees/{id}: failed to delete payee", "payee_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete payee with ID: %d", id))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
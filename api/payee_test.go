@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+	"gobank/util"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayeeCoolingOff(t *testing.T) {
+	server := &Server{config: util.Config{Payee: util.PayeeConfig{
+		CoolingOff:          24 * time.Hour,
+		LargeTransferAmount: 100_000,
+	}}}
+
+	added := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	payee := db.Payee{PayeeID: 1, CreatedAt: added}
+
+	// Small transfers are never held back
+	endsAt, held := server.payeeCoolingOff(payee, 99_999, added)
+	require.False(t, held)
+	require.Equal(t, added.Add(24*time.Hour), endsAt)
+
+	// Large transfers wait for the end of the period
+	_, held = server.payeeCoolingOff(payee, 100_000, added.Add(23*time.Hour))
+	require.True(t, held)
+	_, held = server.payeeCoolingOff(payee, 100_000, added.Add(24*time.Hour))
+	require.False(t, held)
+
+	// The response tells when the period ends
+	require.Equal(t, added.Add(24*time.Hour), server.newPayeeResponse(payee).CoolingOffEndsAt)
+}
+
+// Store answering the permission and payee lookups of a transfer, every other method panics. Accounts are not found
+type transferStore struct {
+	db.Store
+	permissions map[int64]string // Accounts of the caller
+	payees      []db.Payee
+}
+
+func (store transferStore) GetAccountPermission(ctx context.Context, arg db.GetAccountPermissionParams) (string, error) {
+	if permission, ok := store.permissions[arg.AccountID]; ok && arg.Owner == "alice" {
+		return permission, nil
+	}
+	return "", db.ErrRecordNotFound
+}
+
+func (store transferStore) GetPayee(ctx context.Context, arg db.GetPayeeParams) (db.Payee, error) {
+	for _, payee := range store.payees {
+		if payee.PayeeID == arg.PayeeID && payee.Owner == arg.Owner {
+			return payee, nil
+		}
+	}
+	return db.Payee{}, db.ErrRecordNotFound
+}
+
+func (store transferStore) GetPayeeByAccount(ctx context.Context, arg db.GetPayeeByAccountParams) (db.Payee, error) {
+	for _, payee := range store.payees {
+		if payee.AccountID == arg.AccountID && payee.Owner == arg.Owner {
+			return payee, nil
+		}
+	}
+	return db.Payee{}, db.ErrRecordNotFound
+}
+
+func (store transferStore) GetAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	return db.Account{}, db.ErrRecordNotFound
+}
+
+func TestLargeTransferCoolingOff(t *testing.T) {
+	server := &Server{
+		store: transferStore{
+			permissions: map[int64]string{1: db.AccountPermissionManage, 2: db.AccountPermissionView},
+			payees: []db.Payee{
+				{PayeeID: 7, Owner: "alice", AccountID: 10, CreatedAt: time.Now().Add(-time.Hour)},
+				{PayeeID: 8, Owner: "alice", AccountID: 11, CreatedAt: time.Now().Add(-48 * time.Hour)},
+			},
+		},
+		config: util.Config{Payee: util.PayeeConfig{
+			CoolingOff:          24 * time.Hour,
+			LargeTransferAmount: 100_000,
+		}},
+		validate: newValidator(),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "NewPayee", body: `"payee_id": 7, "amount": 100000`, status: http.StatusForbidden},
+		{name: "NewPayeeByAccountID", body: `"to_account_id": 10, "amount": 100000`, status: http.StatusForbidden},
+		{name: "NewPayeeSmallAmount", body: `"to_account_id": 10, "amount": 99999`, status: http.StatusNotFound},
+		{name: "OldPayee", body: `"to_account_id": 11, "amount": 100000`, status: http.StatusNotFound},
+		{name: "NotAPayee", body: `"to_account_id": 12, "amount": 100000`, status: http.StatusForbidden},
+		{name: "OwnAccount", body: `"to_account_id": 2, "amount": 100000`, status: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := `{"from_account_id": 1, "currency": "USD", ` + tc.body + `}`
+			r := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(body))
+			r.Header.Set(headerUsername, "alice")
+			w := httptest.NewRecorder()
+
+			// The allowed transfers go on to look up the accounts, which don't exist here
+			server.createTransfer(w, r)
+			require.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+// Store holding account 1234567890123428 of Alice Smith, every other method panics
+type payeeStore struct {
+	db.Store
+}
+
+func (store payeeStore) CreatePayee(ctx context.Context, arg db.CreatePayeeParams) (db.Payee, error) {
+	if arg.AccountNumber != "1234567890123428" || !strings.EqualFold(strings.TrimSpace(arg.OwnerName), "Alice Smith") {
+		return db.Payee{}, db.ErrRecordNotFound
+	}
+	return db.Payee{PayeeID: 1, Owner: arg.Owner, Nickname: arg.Nickname, AccountID: 5, OwnerName: "Alice Smith"}, nil
+}
+
+func TestCreatePayee(t *testing.T) {
+	server := &Server{
+		store:        payeeStore{},
+		validate:     newValidator(),
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		payeeLimiter: newRateLimiter(3, time.Hour),
+	}
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "Match", body: `{"nickname": "Alice", "account_number": "1234 5678 9012 3428", "owner_name": "alice smith"}`, status: http.StatusCreated},
+		{name: "ByAccountID", body: `{"nickname": "Alice", "account_id": 5, "owner_name": "Alice Smith"}`, status: http.StatusBadRequest},
+		{name: "WrongName", body: `{"nickname": "Alice", "account_number": "1234567890123428", "owner_name": "Bob"}`, status: http.StatusNotFound},
+		{name: "UnknownAccount", body: `{"nickname": "Bob", "account_number": "9999999999999939", "owner_name": "Alice Smith"}`, status: http.StatusNotFound},
+		{name: "Limited", body: `{"nickname": "Alice", "account_number": "1234567890123428", "owner_name": "Alice Smith"}`, status: http.StatusTooManyRequests},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/payees", strings.NewReader(tc.body))
+			r.Header.Set(headerUsername, "bob")
+			w := httptest.NewRecorder()
+
+			server.createPayee(w, r)
+			require.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+}
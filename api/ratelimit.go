@@ -0,0 +1,62 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter allows a number of attempts per key in a fixed window. It is kept in memory, so every server instance
+// counts on its own
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]rateWindow
+	pruned  time.Time
+}
+
+// Attempts of one key in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// Constructor method for rateLimiter
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]rateWindow),
+	}
+}
+
+// Method to record an attempt for the key. When the limit is reached, the attempt is refused and the time the
+// window ends is returned
+func (limiter *rateLimiter) allow(key string, now time.Time) (time.Time, bool) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	// Forget the windows that ended, at most once per window so each attempt stays cheap
+	if now.Sub(limiter.pruned) >= limiter.window {
+		for k, w := range limiter.windows {
+			if now.Sub(w.start) >= limiter.window {
+				delete(limiter.windows, k)
+			}
+		}
+		limiter.pruned = now
+	}
+
+	w, ok := limiter.windows[key]
+	if !ok || now.Sub(w.start) >= limiter.window {
+		w = rateWindow{start: now}
+	}
+
+	resetAt := w.start.Add(limiter.window)
+	if w.count >= limiter.limit {
+		return resetAt, false
+	}
+
+	w.count++
+	limiter.windows[key] = w
+	return resetAt, true
+}
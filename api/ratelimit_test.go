@@ -0,0 +1,34 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(2, time.Minute)
+	start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	// Each key has its own window
+	_, ok := limiter.allow("alice", start)
+	require.True(t, ok)
+	_, ok = limiter.allow("alice", start.Add(time.Second))
+	require.True(t, ok)
+	resetAt, ok := limiter.allow("alice", start.Add(2*time.Second))
+	require.False(t, ok)
+	require.Equal(t, start.Add(time.Minute), resetAt)
+
+	_, ok = limiter.allow("bob", start.Add(2*time.Second))
+	require.True(t, ok)
+
+	// The attempts are forgotten once the window ends
+	_, ok = limiter.allow("alice", start.Add(time.Minute))
+	require.True(t, ok)
+	require.Contains(t, limiter.windows, "bob")
+
+	_, ok = limiter.allow("alice", start.Add(2*time.Minute))
+	require.True(t, ok)
+	require.NotContains(t, limiter.windows, "bob")
+}
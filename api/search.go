@@ -0,0 +1,170 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	db "gobank/db/sqlc"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Markers put around the matched terms by the search query, replaced by HTML tags in the response
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+type searchResult struct {
+	EntryID          int64         `json:"entry_id"`
+	AccountID        int64         `json:"account_id"`
+	Amount           int64         `json:"amount"`
+	TransferID       sql.NullInt64 `json:"transfer_id"`
+	CreatedAt        sql.NullTime  `json:"created_at"`
+	Description      string        `json:"description"`
+	CounterpartyName string        `json:"counterparty_name"`
+	Reference        string        `json:"reference"`
+	Rank             float32       `json:"rank"`
+	Highlights       highlights    `json:"highlights"`
+}
+
+type highlights struct {
+	Description      string `json:"description"`
+	CounterpartyName string `json:"counterparty_name"`
+	Reference        string `json:"reference"`
+}
+
+// Utility method: escape a highlight as HTML and wrap the matched terms in <mark> tags
+func highlight(raw string) string {
+	escaped := html.EscapeString(raw)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
+
+// Constructor method for searchResult
+func newSearchResult(row db.SearchEntriesRow) searchResult {
+	return searchResult{
+		EntryID:          row.EntryID,
+		AccountID:        row.AccountID,
+		Amount:           row.Amount,
+		TransferID:       row.TransferID,
+		CreatedAt:        row.CreatedAt,
+		Description:      row.Description,
+		CounterpartyName: row.CounterpartyName,
+		Reference:        row.Reference,
+		Rank:             row.Rank,
+		Highlights: highlights{
+			Description:      highlight(row.DescriptionHighlight),
+			CounterpartyName: highlight(row.CounterpartyNameHighlight),
+			Reference:        highlight(row.ReferenceHighlight),
+		},
+	}
+}
+
+// Helper method: parse an optional non-negative amount query parameter. On failure, it writes the error response
+func (server *Server) parseAmountFilter(w http.ResponseWriter, r *http.Request, name string) (sql.NullInt64, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return sql.NullInt64{}, true
+	}
+
+	amount, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || amount < 0 {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter %s: %s", name, raw))
+		return sql.NullInt64{}, false
+	}
+
+	return sql.NullInt64{Int64: amount, Valid: true}, true
+}
+
+// Helper method: parse an optional from/to query parameter. On failure, it writes the error response
+func (server *Server) parseTimeFilter(w http.ResponseWriter, r *http.Request, name string, endOfDay bool) (sql.NullTime, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return sql.NullTime{}, true
+	}
+
+	t, err := parsePeriodBound(raw, endOfDay)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter %s: %s", name, raw))
+		return sql.NullTime{}, false
+	}
+
+	return sql.NullTime{Time: t, Valid: true}, true
+}
+
+func (server *Server) searchTransactions(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		server.WriteError(w, http.StatusBadRequest, "missing request parameter q")
+		return
+	}
+
+	limit, offset, ok := server.parsePagination(w, r)
+	if !ok {
+		return
+	}
+
+	arg := db.SearchEntriesParams{
+		Query:  query,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if arg.MinAmount, ok = server.parseAmountFilter(w, r, "min_amount"); !ok {
+		return
+	}
+	if arg.MaxAmount, ok = server.parseAmountFilter(w, r, "max_amount"); !ok {
+		return
+	}
+	if arg.MinAmount.Valid && arg.MaxAmount.Valid && arg.MinAmount.Int64 > arg.MaxAmount.Int64 {
+		server.WriteError(w, http.StatusBadRequest, "min_amount must not be greater than max_amount")
+		return
+	}
+
+	if arg.FromTime, ok = server.parseTimeFilter(w, r, "from", false); !ok {
+		return
+	}
+	if arg.ToTime, ok = server.parseTimeFilter(w, r, "to", true); !ok {
+		return
+	}
+	if arg.FromTime.Valid && arg.ToTime.Valid && !arg.FromTime.Time.Before(arg.ToTime.Time) {
+		server.WriteError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	// Narrow the search to one account, which the caller must be able to view
+	if accountIDRaw := r.URL.Query().Get("account_id"); accountIDRaw != "" {
+		accountID, err := strconv.ParseInt(accountIDRaw, 10, 64)
+		if err != nil || accountID <= 0 {
+			server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter account_id: %s", accountIDRaw))
+			return
+		}
+
+		if _, ok := server.authorizeAccount(w, r, accountID, db.AccountPermissionView); !ok {
+			return
+		}
+		arg.AccountID = sql.NullInt64{Int64: accountID, Valid: true}
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+	arg.Member = username
+
+	rows, err := server.store.SearchEntries(r.Context(), arg)
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "GET /transactions/search: failed to search transactions", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to search transactions")
+		return
+	}
+
+	results := make([]searchResult, len(rows))
+	for i, row := range rows {
+		results[i] = newSearchResult(row)
+	}
+
+	server.WriteJSON(w, http.StatusOK, results)
+}
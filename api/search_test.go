@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlight(t *testing.T) {
+	require.Equal(t, "", highlight(""))
+	require.Equal(t, "Rent <mark>March</mark>", highlight("Rent \x02March\x03"))
+	require.Equal(t, "<mark>Tom</mark> &amp; Jerry &lt;b&gt;", highlight("\x02Tom\x03 & Jerry <b>"))
+}
+
+func TestSearchTransactionsValidation(t *testing.T) {
+	server := &Server{}
+
+	for _, query := range []string{
+		"page_id=1&page_size=10",
+		"q=%20&page_id=1&page_size=10",
+		"q=rent",
+		"q=rent&page_id=1&page_size=10&min_amount=-1",
+		"q=rent&page_id=1&page_size=10&max_amount=abc",
+		"q=rent&page_id=1&page_size=10&min_amount=500&max_amount=100",
+		"q=rent&page_id=1&page_size=10&from=yesterday",
+		"q=rent&page_id=1&page_size=10&from=2026-03-02&to=2026-03-01",
+		"q=rent&page_id=1&page_size=10&account_id=0",
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/transactions/search?"+query, nil)
+		server.searchTransactions(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -1,45 +1,174 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	db "gobank/db/sqlc"
+	"gobank/health"
+	"gobank/metrics"
+	"gobank/util"
+	"gobank/worker"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
 type Server struct {
-	store    db.Store
-	mux      *http.ServeMux
-	logger   *slog.Logger
-	validate *validator.Validate
+	config      util.Config
+	store       db.Store
+	distributor worker.TaskDistributor
+	metrics     *metrics.Metrics
+	health      *health.Checker
+	mux         *http.ServeMux
+	httpServer  *http.Server
+	logger      *slog.Logger
+	validate    *validator.Validate
+
+	// Attempts to add a payee, per caller
+	payeeLimiter *rateLimiter
+
+	// Slots of the statement exports running, see acquireStatement
+	statements chan struct{}
 }
 
-func NewServer(store db.Store, logger *slog.Logger) *Server {
+func NewServer(
+	config util.Config,
+	store db.Store,
+	distributor worker.TaskDistributor,
+	metrics *metrics.Metrics,
+	health *health.Checker,
+	logger *slog.Logger,
+) *Server {
 	server := &Server{
-		store:    store,
-		mux:      http.NewServeMux(),
-		logger:   logger,
-		validate: validator.New(validator.WithRequiredStructEnabled()),
+		config:      config,
+		store:       store,
+		distributor: distributor,
+		metrics:     metrics,
+		health:      health,
+		mux:         http.NewServeMux(),
+		logger:      slog.New(contextHandler{logger.Handler()}),
+		validate:    newValidator(),
+
+		payeeLimiter: newRateLimiter(config.Payee.CreateLimit, config.Payee.CreateWindow),
+		statements:   make(chan struct{}, config.Server.StatementConcurrency),
 	}
 
 	server.RegisterHandler()
 
+	server.httpServer = &http.Server{
+		Addr:              net.JoinHostPort(config.Server.Domain, config.Server.Port),
+		Handler:           server.Handler(),
+		ReadTimeout:       config.Server.ReadTimeout,
+		ReadHeaderTimeout: config.Server.ReadTimeout,
+		WriteTimeout:      config.Server.WriteTimeout,
+		IdleTimeout:       config.Server.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(server.logger.Handler(), slog.LevelError),
+	}
+
 	return server
 }
 
+// Helper method: create the validator of the requests, with the custom account_number tag checking the check
+// digits of an account number
+func newValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterValidation("account_number", func(fl validator.FieldLevel) bool {
+		_, ok := util.NormalizeAccountNumber(fl.Field().String())
+		return ok
+	})
+	return validate
+}
+
 func (server *Server) RegisterHandler() {
+	// Metrics route
+	server.mux.Handle("GET /metrics", server.metrics.Handler())
+
+	// Health route
+	server.mux.HandleFunc("GET /healthz", server.healthz)
+	server.mux.HandleFunc("GET /readyz", server.readyz)
+
 	// Account route
 	server.mux.HandleFunc("POST /account", server.createAccount)
 	server.mux.HandleFunc("GET /account/{id}", server.getAccount)
+	server.mux.HandleFunc("GET /account-numbers/{number}", server.getAccountByNumber)
 	server.mux.HandleFunc("GET /accounts", server.listAccounts)
+	server.mux.HandleFunc("PATCH /accounts/{id}", server.updateAccount)
+	server.mux.HandleFunc("GET /accounts/{id}/statement", server.getStatement)
+	server.mux.HandleFunc("POST /accounts/{id}/withdraw", server.withdraw)
+
+	// Account owner route
+	server.mux.HandleFunc("GET /accounts/{id}/owners", server.listAccountOwners)
+	server.mux.HandleFunc("POST /accounts/{id}/owners", server.inviteAccountOwner)
+	server.mux.HandleFunc("POST /accounts/{id}/owners/{owner}/approve", server.approveAccountOwner)
+	server.mux.HandleFunc("DELETE /accounts/{id}/owners/{owner}", server.removeAccountOwner)
+
+	// Transfer route
+	server.mux.HandleFunc("POST /transfers", server.createTransfer)
+	server.mux.HandleFunc("GET /transfers", server.listTransfers)
+	server.mux.HandleFunc("GET /transactions/search", server.searchTransactions)
+
+	// Payee route
+	server.mux.HandleFunc("POST /payees", server.createPayee)
+	server.mux.HandleFunc("GET /payees", server.listPayees)
+	server.mux.HandleFunc("GET /payees/{id}", server.getPayee)
+	server.mux.HandleFunc("PATCH /payees/{id}", server.updatePayee)
+	server.mux.HandleFunc("DELETE /payees/{id}", server.deletePayee)
+
+	// Webhook route
+	server.mux.HandleFunc("POST /webhooks", server.createWebhook)
+	server.mux.HandleFunc("GET /webhooks", server.listWebhooks)
+	server.mux.HandleFunc("DELETE /webhooks/{id}", server.deleteWebhook)
+	server.mux.HandleFunc("GET /webhooks/{id}/deliveries", server.listWebhookDeliveries)
+	server.mux.HandleFunc("POST /webhooks/{id}/deliveries/{delivery_id}/replay", server.replayWebhookDelivery)
+
+	// Notification route
+	server.mux.HandleFunc("GET /notification-preferences", server.getNotificationPreference)
+	server.mux.HandleFunc("PUT /notification-preferences", server.updateNotificationPreference)
 }
 
-func (server *Server) Start(domain, port string) error {
-	server.logger.Info(fmt.Sprintf("Server start at %s:%s", domain, port))
-	return http.ListenAndServe(fmt.Sprintf(":%s", port), server.mux)
+// Get the root handler: the routes wrapped with the middleware chain
+func (server *Server) Handler() http.Handler {
+	return Chain(server.mux,
+		server.requestID,
+		server.trace,
+		server.accessLog,
+		server.instrument,
+		server.recoverPanic,
+	)
+}
+
+// Method to listen on the configured domain and port and serve requests. It blocks until the server is shut down,
+// in which case it returns http.ErrServerClosed
+func (server *Server) Start() error {
+	listener, err := net.Listen("tcp", server.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	return server.Serve(listener)
+}
+
+// Method to serve requests on an existing listener
+func (server *Server) Serve(listener net.Listener) error {
+	server.logger.Info(fmt.Sprintf("Server start at %s", listener.Addr()))
+	return server.httpServer.Serve(listener)
+}
+
+// Method to stop the server gracefully: stop accepting connections, then wait for in-flight requests to finish.
+// If ctx expires first, the remaining connections are closed and the context error is returned
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.logger.Info("Server shutting down")
+
+	err := server.httpServer.Shutdown(ctx)
+	if err != nil {
+		server.httpServer.Close()
+	}
+	return err
 }
 
 func (server *Server) WriteError(w http.ResponseWriter, status int, message string) {
@@ -57,3 +186,37 @@ func (server *Server) WriteJSON(w http.ResponseWriter, status int, data any) {
 		"data": data,
 	})
 }
+
+// Helper method: parse a positive ID from the request path. On failure, it writes the error response
+func (server *Server) parsePathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	idRaw := strings.TrimSpace(r.PathValue(name))
+
+	id, err := strconv.ParseInt(idRaw, 10, 64)
+	if err != nil || id <= 0 {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %s", name, idRaw))
+		return 0, false
+	}
+
+	return id, true
+}
+
+// Helper method: parse the page_id and page_size query parameters into a limit and an offset.
+// On failure, it writes the error response
+func (server *Server) parsePagination(w http.ResponseWriter, r *http.Request) (limit, offset int32, ok bool) {
+	params := r.URL.Query()
+	pageIdRaw, pageSizeRaw := params.Get("page_id"), params.Get("page_size")
+
+	pageId, err := strconv.ParseInt(pageIdRaw, 10, 32)
+	if err != nil || pageId <= 0 {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter page_id: %s", pageIdRaw))
+		return 0, 0, false
+	}
+
+	pageSize, err := strconv.ParseInt(pageSizeRaw, 10, 32)
+	if err != nil || pageSize <= 0 {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter page_size: %s", pageSizeRaw))
+		return 0, 0, false
+	}
+
+	return int32(pageSize), int32((pageId - 1) * pageSize), true
+}
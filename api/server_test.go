@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"gobank/health"
+	"gobank/metrics"
+	"gobank/util"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Helper method: create a server without database, listening on a random local port
+func startTestServer(t *testing.T) (*Server, string, chan error) {
+	config := util.Config{
+		Server: util.ServerConfig{
+			Domain:          "127.0.0.1",
+			Port:            "0",
+			ReadTimeout:     time.Second,
+			WriteTimeout:    5 * time.Second,
+			IdleTimeout:     time.Second,
+			ShutdownTimeout: 5 * time.Second,
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := NewServer(config, nil, nil, metrics.New(), health.NewChecker(time.Second), logger)
+
+	listener, err := net.Listen("tcp", server.httpServer.Addr)
+	require.NoError(t, err)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	return server, "http://" + listener.Addr().String(), serveErr
+}
+
+func TestServerStartAndShutdown(t *testing.T) {
+	server, url, serveErr := startTestServer(t)
+
+	// The server answers requests (this one is rejected before reaching the database)
+	resp, err := http.Get(url + "/account/abc")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Shut it down, Serve returns ErrServerClosed
+	require.NoError(t, server.Shutdown(context.Background()))
+	require.ErrorIs(t, <-serveErr, http.ErrServerClosed)
+
+	// New connections are refused
+	_, err = http.Get(url + "/account/abc")
+	require.Error(t, err)
+}
+
+func TestServerShutdownDrainsRequests(t *testing.T) {
+	server, url, serveErr := startTestServer(t)
+
+	// A slow handler that only returns once released
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server.mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	// Shutdown waits for the in-flight request
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- server.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-shutdownErr:
+		t.Fatal("shutdown returned before the in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-status)
+	require.NoError(t, <-shutdownErr)
+	require.ErrorIs(t, <-serveErr, http.ErrServerClosed)
+}
+
+func TestServerShutdownDeadline(t *testing.T) {
+	server, url, serveErr := startTestServer(t)
+
+	// A handler that never finishes on its own
+	started := make(chan struct{})
+	server.mux.HandleFunc("GET /stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+
+	go func() {
+		resp, err := http.Get(url + "/stuck")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// The deadline expires, the remaining connections are closed
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, <-serveErr, http.ErrServerClosed)
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	checker := health.NewChecker(time.Second)
+	failing := false
+	checker.Add("database", func(ctx context.Context) error {
+		if failing {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	server := NewServer(util.Config{}, nil, nil, metrics.New(), checker, logger)
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	code, _ := get("/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	// A failing dependency makes the instance unready, but it stays alive
+	failing = true
+	code, body := get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, "connection refused")
+
+	code, _ = get("/healthz")
+	require.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/statement"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout accepted for the from/to query parameters besides RFC 3339
+const dateLayout = "2006-01-02"
+
+// Helper method: parse a statement period bound. A plain date is interpreted as midnight UTC; when endOfDay is
+// set, it is moved to the next midnight so the whole day is included in the period
+func parsePeriodBound(raw string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// Helper method: take a slot to export a statement. An export keeps a database connection and a repeatable read
+// transaction open for as long as the client takes to download it, so only a few may run at once, leaving the
+// connection pool to the other requests. On failure, it writes the error response
+func (server *Server) acquireStatement(w http.ResponseWriter) (func(), bool) {
+	select {
+	case server.statements <- struct{}{}:
+		return func() { <-server.statements }, true
+	default:
+		w.Header().Set("Retry-After", "30")
+		server.WriteError(w, http.StatusServiceUnavailable, "too many statements being exported, try again later")
+		return nil, false
+	}
+}
+
+func (server *Server) getStatement(w http.ResponseWriter, r *http.Request) {
+	// Get the id parameter
+	idRaw := strings.TrimSpace(r.PathValue("id"))
+
+	// Try parse ID
+	id, err := strconv.ParseInt(idRaw, 10, 64)
+	if err != nil || id <= 0 {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid ID: %s", idRaw))
+		return
+	}
+
+	if _, ok := server.authorizeAccount(w, r, id, db.AccountPermissionView); !ok {
+		return
+	}
+
+	// Get the format and the period
+	params := r.URL.Query()
+	format, fromRaw, toRaw := params.Get("format"), params.Get("from"), params.Get("to")
+
+	if format == "" {
+		format = statement.FormatCSV
+	}
+
+	from, err := parsePeriodBound(fromRaw, false)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter from: %s", fromRaw))
+		return
+	}
+
+	to, err := parsePeriodBound(toRaw, true)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter to: %s", toRaw))
+		return
+	}
+
+	if !from.Before(to) {
+		server.WriteError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	// Create the writer for the requested format
+	writer, err := statement.NewWriter(format, w)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request parameter format: %s", format))
+		return
+	}
+
+	release, ok := server.acquireStatement(w)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Stream the statement. Once the header is written the status code is sent, so later errors can only be logged
+	started := false
+	err = server.store.StatementTx(r.Context(), db.StatementTxParams{
+		AccountID: id,
+		From:      from,
+		To:        to,
+	}, func(header db.StatementTxResult) error {
+		filename := fmt.Sprintf("statement-%d-%s-%s.%s",
+			id, from.Format(dateLayout), to.Add(-time.Nanosecond).Format(dateLayout), statement.Extension(format))
+
+		// The server write timeout is meant for regular responses, large statements need longer to stream
+		deadline := time.Now().Add(server.config.Server.StatementWriteTimeout)
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+			server.logger.WarnContext(r.Context(), "GET /accounts/{id}/statement: failed to extend write deadline", "account_id", id, "error", err)
+		}
+
+		w.Header().Set("Content-Type", statement.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+		started = true
+
+		return writer.WriteHeader(header)
+	}, writer.WriteLine)
+
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		if started {
+			server.logger.ErrorContext(r.Context(), "GET /accounts/{id}/statement: failed to stream statement", "account_id", id, "error", err)
+			return
+		}
+
+		// If ID not match any record in database
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.logger.WarnContext(r.Context(), "GET /accounts/{id}/statement: account not found", "account_id", id)
+			server.WriteError(w, http.StatusNotFound, "account not found")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "GET /accounts/{id}/statement: failed to get statement", "account_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get statement of account with ID: %d", id))
+	}
+}
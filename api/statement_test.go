@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+	"gobank/health"
+	"gobank/metrics"
+	"gobank/util"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Store streaming a statement slower than the server write timeout
+type slowStatementStore struct {
+	permissionStore
+	lines int
+	delay time.Duration
+}
+
+func (store slowStatementStore) StatementTx(ctx context.Context, arg db.StatementTxParams, header func(db.StatementTxResult) error, line func(db.ListAccountEntriesRow) error) error {
+	if err := header(db.StatementTxResult{Account: db.Account{AccountID: arg.AccountID, Currency: "USD"}}); err != nil {
+		return err
+	}
+
+	for i := 1; i <= store.lines; i++ {
+		time.Sleep(store.delay)
+		if err := line(db.ListAccountEntriesRow{EntryID: int64(i), Amount: 10}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStatementOutlivesWriteTimeout(t *testing.T) {
+	config := util.Config{Server: util.ServerConfig{StatementWriteTimeout: time.Minute, StatementConcurrency: 1}}
+	store := slowStatementStore{
+		permissionStore: permissionStore{permissions: map[string]string{"alice": db.AccountPermissionView}},
+		lines:           5,
+		delay:           50 * time.Millisecond,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := NewServer(config, store, nil, metrics.New(), health.NewChecker(time.Second), logger)
+
+	// The whole statement takes longer to stream than the write timeout of the server
+	ts := httptest.NewUnstartedServer(server.Handler())
+	ts.Config.WriteTimeout = 100 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/accounts/1/statement?from=2026-01-01&to=2026-01-31", nil)
+	require.NoError(t, err)
+	req.Header.Set(headerUsername, "alice")
+
+	res, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	// The column names, the opening balance, the entries and the closing balance, none cut off
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, store.lines+3)
+	require.True(t, strings.HasPrefix(lines[len(lines)-1], "closing_balance"))
+}
+
+func TestStatementConcurrency(t *testing.T) {
+	config := util.Config{Server: util.ServerConfig{StatementWriteTimeout: time.Minute, StatementConcurrency: 1}}
+	store := slowStatementStore{
+		permissionStore: permissionStore{permissions: map[string]string{"alice": db.AccountPermissionView}},
+		lines:           1,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := NewServer(config, store, nil, metrics.New(), health.NewChecker(time.Second), logger)
+
+	getStatement := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1/statement?from=2026-01-01&to=2026-01-31", nil)
+		req.Header.Set(headerUsername, "alice")
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// Another export holds the only slot
+	server.statements <- struct{}{}
+	recorder := getStatement()
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// Once it is done, the slot is free again, and released after each export
+	<-server.statements
+	for i := 0; i < 2; i++ {
+		recorder = getStatement()
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+	require.Empty(t, server.statements)
+}
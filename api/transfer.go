@@ -0,0 +1,258 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/worker"
+	"net/http"
+)
+
+// The sender is either an account ID or an account number, the recipient is either of them or a payee of the caller
+type transferRequest struct {
+	FromAccountID     int64  `json:"from_account_id" validate:"required_without=FromAccountNumber,excluded_with=FromAccountNumber,omitempty,min=1"`
+	FromAccountNumber string `json:"from_account_number" validate:"omitempty,account_number"`
+	ToAccountID       int64  `json:"to_account_id" validate:"required_without_all=ToAccountNumber PayeeID,excluded_with=ToAccountNumber PayeeID,omitempty,min=1,nefield=FromAccountID"`
+	ToAccountNumber   string `json:"to_account_number" validate:"omitempty,excluded_with=PayeeID,account_number"`
+	PayeeID           int64  `json:"payee_id" validate:"omitempty,min=1"`
+	Amount            int64  `json:"amount" validate:"required,gt=0"`
+	Currency          string `json:"currency" validate:"required,oneof=USD VND EUR"`
+
+	// Optional details shown on the statements of both accounts. The reference follows the 35 characters limit of
+	// ISO 20022 end-to-end identifications
+	Description string         `json:"description" validate:"max=140"`
+	Reference   string         `json:"reference" validate:"omitempty,max=35,printascii"`
+	Metadata    map[string]any `json:"metadata" validate:"max=20"`
+}
+
+// Helper method: check that an account exists and uses the given currency. On failure, it writes the error response
+func (server *Server) validAccount(w http.ResponseWriter, r *http.Request, accountID int64, currency string) (db.Account, bool) {
+	account, err := server.store.GetAccount(r.Context(), accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, fmt.Sprintf("account %d not found", accountID))
+			return account, false
+		}
+
+		server.logger.ErrorContext(r.Context(), "failed to get account", "account_id", accountID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get account with ID: %d", accountID))
+		return account, false
+	}
+
+	if account.Currency != currency {
+		server.WriteError(w, http.StatusBadRequest,
+			fmt.Sprintf("account currency mismatch: %s vs %s", account.Currency, currency))
+		return account, false
+	}
+
+	return account, true
+}
+
+func (server *Server) createTransfer(w http.ResponseWriter, r *http.Request) {
+	// Get the JSON data
+	var req transferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	// Validate data
+	if err := server.validate.Struct(req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	// Account numbers are resolved to account IDs first
+	if req.FromAccountNumber != "" {
+		account, ok := server.accountByNumber(w, r, req.FromAccountNumber)
+		if !ok {
+			return
+		}
+		req.FromAccountID = account.AccountID
+	}
+	if req.ToAccountNumber != "" {
+		account, ok := server.accountByNumber(w, r, req.ToAccountNumber)
+		if !ok {
+			return
+		}
+		req.ToAccountID = account.AccountID
+	}
+
+	// The caller must be allowed to move money out of the from account, anyone can receive money
+	username, ok := server.authorizeAccount(w, r, req.FromAccountID, db.AccountPermissionTransact)
+	if !ok {
+		return
+	}
+
+	if req.PayeeID != 0 {
+		payee, ok := server.getCallerPayee(w, r, req.PayeeID, username)
+		if !ok {
+			return
+		}
+		req.ToAccountID = payee.AccountID
+	}
+
+	if req.ToAccountID == req.FromAccountID {
+		server.WriteError(w, http.StatusBadRequest, "can't transfer to the same account")
+		return
+	}
+
+	// However the recipient is given, large transfers must go to a payee past its cooling-off period
+	if !server.allowLargeTransfer(w, r, username, req.ToAccountID, req.Amount) {
+		return
+	}
+
+	// Both accounts must exist and use the currency of the transfer
+	if _, ok := server.validAccount(w, r, req.FromAccountID, req.Currency); !ok {
+		return
+	}
+	if _, ok := server.validAccount(w, r, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	// Transfer the money. The notifications are enqueued in the same transaction, so they are sent
+	// if and only if the transfer is committed
+	arg := db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		AfterTransfer: func(q *db.Queries, result db.TransferTxResult) error {
+			return server.distributor.DistributeTaskNotifyTransfer(r.Context(), q, &worker.PayloadNotifyTransfer{
+				Transfer: result,
+			})
+		},
+	}
+	if req.Metadata != nil {
+		arg.Metadata, _ = json.Marshal(req.Metadata)
+	}
+	result, err := server.store.TransferTx(r.Context(), arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			server.WriteError(w, http.StatusUnprocessableEntity, "insufficient funds")
+			return
+		}
+		if errors.Is(err, db.ErrAccountFrozen) {
+			server.WriteError(w, http.StatusUnprocessableEntity, "account is frozen")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "POST /transfers: failed to transfer money", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to transfer money")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusCreated, result)
+}
+
+// List the transfers with an end-to-end reference, from or to the accounts of the caller
+func (server *Server) listTransfers(w http.ResponseWriter, r *http.Request) {
+	reference := r.URL.Query().Get("reference")
+	if reference == "" {
+		server.WriteError(w, http.StatusBadRequest, "missing request parameter reference")
+		return
+	}
+
+	limit, offset, ok := server.parsePagination(w, r)
+	if !ok {
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	transfers, err := server.store.ListTransfersByReference(r.Context(), db.ListTransfersByReferenceParams{
+		Reference: reference,
+		Member:    username,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "GET /transfers: failed to get list of transfers", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get list of transfers")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, transfers)
+}
+
+type amountRequest struct {
+	Amount int64 `json:"amount" validate:"required,gt=0"`
+}
+
+// Helper method: decode and validate the amount of a withdrawal. On failure, it writes the error response
+func (server *Server) parseAmount(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	var req amountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return 0, false
+	}
+
+	if err := server.validate.Struct(req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return 0, false
+	}
+
+	return req.Amount, true
+}
+
+func (server *Server) withdraw(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	amount, ok := server.parseAmount(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := server.authorizeAccount(w, r, id, db.AccountPermissionTransact); !ok {
+		return
+	}
+
+	version, ok := server.parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.WithdrawTx(r.Context(), db.WithdrawTxParams{
+		AccountID:       id,
+		Amount:          amount,
+		ExpectedVersion: version,
+		AfterWithdraw: func(q *db.Queries, result db.WithdrawTxResult) error {
+			return server.distributor.DistributeTaskNotifyWithdraw(r.Context(), q, &worker.PayloadNotifyWithdraw{
+				Withdrawal: result,
+			})
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "account not found")
+			return
+		}
+		if errors.Is(err, db.ErrVersionConflict) {
+			server.WriteError(w, http.StatusPreconditionFailed, "account was modified, fetch it again")
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			server.WriteError(w, http.StatusUnprocessableEntity, "insufficient funds")
+			return
+		}
+		if errors.Is(err, db.ErrAccountFrozen) {
+			server.WriteError(w, http.StatusUnprocessableEntity, "account is frozen")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "POST /accounts/{id}/withdraw: failed to withdraw money", "account_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to withdraw money")
+		return
+	}
+
+	w.Header().Set("ETag", accountETag(result.Account))
+	server.WriteJSON(w, http.StatusCreated, result)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferRequestValidation(t *testing.T) {
+	validate := newValidator()
+
+	testCases := []struct {
+		body  string
+		valid bool
+	}{
+		{body: `{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": "USD"}`, valid: true},
+		{body: `{"from_account_id": 1, "payee_id": 3, "amount": 10, "currency": "USD"}`, valid: true},
+		{body: `{"from_account_id": 1, "amount": 10, "currency": "USD"}`, valid: false},
+		{body: `{"from_account_id": 1, "to_account_id": 2, "payee_id": 3, "amount": 10, "currency": "USD"}`, valid: false},
+		{body: `{"from_account_id": 1, "to_account_id": 1, "amount": 10, "currency": "USD"}`, valid: false},
+		{body: `{"from_account_id": 1, "payee_id": -3, "amount": 10, "currency": "USD"}`, valid: false},
+
+		// Account numbers can replace the account IDs, but not be given with them
+		{body: `{"from_account_number": "1234 5678 9012 3428", "to_account_number": "9999999999999939", "amount": 10, "currency": "USD"}`, valid: true},
+		{body: `{"from_account_number": "1234567890123428", "to_account_id": 2, "amount": 10, "currency": "USD"}`, valid: true},
+		{body: `{"from_account_id": 1, "to_account_number": "9999999999999939", "amount": 10, "currency": "USD"}`, valid: true},
+		{body: `{"from_account_number": "1234567890123428", "payee_id": 3, "amount": 10, "currency": "USD"}`, valid: true},
+		{body: `{"from_account_number": "1234567890123429", "to_account_id": 2, "amount": 10, "currency": "USD"}`, valid: false},
+		{body: `{"from_account_id": 1, "from_account_number": "1234567890123428", "to_account_id": 2, "amount": 10, "currency": "USD"}`, valid: false},
+		{body: `{"from_account_id": 1, "to_account_id": 2, "to_account_number": "9999999999999939", "amount": 10, "currency": "USD"}`, valid: false},
+		{body: `{"from_account_id": 1, "to_account_number": "9999999999999939", "payee_id": 3, "amount": 10, "currency": "USD"}`, valid: false},
+
+		// Optional details
+		{body: `{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": "USD", "description": "Rent", "reference": "INV-2025-001", "metadata": {"unit": 4, "tags": ["home"]}}`, valid: true},
+		{body: `{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": "USD", "reference": "` + strings.Repeat("a", 36) + `"}`, valid: false},
+		{body: `{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": "USD", "reference": "caf\u00e9"}`, valid: false},
+		{body: `{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": "USD", "description": "` + strings.Repeat("a", 141) + `"}`, valid: false},
+	}
+
+	for _, tc := range testCases {
+		var req transferRequest
+		require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+
+		err := validate.Struct(req)
+		if tc.valid {
+			require.NoError(t, err, tc.body)
+		} else {
+			require.Error(t, err, tc.body)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/webhook"
+	"net/http"
+	"time"
+)
+
+type createWebhookRequest struct {
+	Url        string   `json:"url" validate:"required,http_url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=AccountCreated TransferReceived TransferSent DepositMade WithdrawalMade InterestPosted"`
+}
+
+// A webhook subscription as returned by the API. The signing secret is left out, it is only returned once, when
+// the subscription is created
+type webhookResponse struct {
+	SubscriptionID int64     `json:"subscription_id"`
+	Owner          string    `json:"owner"`
+	Url            string    `json:"url"`
+	EventTypes     []string  `json:"event_types"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type createWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+// Constructor method for webhookResponse
+func newWebhookResponse(subscription db.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		SubscriptionID: subscription.SubscriptionID,
+		Owner:          subscription.Owner,
+		Url:            subscription.Url,
+		EventTypes:     subscription.EventTypes,
+		CreatedAt:      subscription.CreatedAt,
+	}
+}
+
+// Helper method: get a webhook subscription of the caller. On failure, it writes the error response
+func (server *Server) getCallerWebhook(w http.ResponseWriter, r *http.Request, id int64, owner string) (db.WebhookSubscription, bool) {
+	subscription, err := server.store.GetWebhookSubscription(r.Context(), db.GetWebhookSubscriptionParams{
+		SubscriptionID: id,
+		Owner:          owner,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "webhook not found")
+			return db.WebhookSubscription{}, false
+		}
+
+		server.logger.ErrorContext(r.Context(), "failed to get webhook", "subscription_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get webhook with ID: %d", id))
+		return db.WebhookSubscription{}, false
+	}
+
+	return subscription, true
+}
+
+func (server *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	// Get the JSON data
+	var req createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	// Validate data
+	if err := server.validate.Struct(req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	// The URL is chosen by the customer, it must not point to an internal address
+	if err := webhook.ValidateURL(r.Context(), req.Url); err != nil {
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid webhook url: %v", err))
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	// Generate the signing secret. It is returned to the client so they can verify the signatures
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "POST /webhooks: failed to generate secret", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	subscription, err := server.store.CreateWebhookSubscription(r.Context(), db.CreateWebhookSubscriptionParams{
+		Owner:      username,
+		Url:        req.Url,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "POST /webhooks: failed to create webhook", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusCreated, createWebhookResponse{
+		webhookResponse: newWebhookResponse(subscription),
+		Secret:          subscription.Secret,
+	})
+}
+
+func (server *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := server.store.ListWebhookSubscriptions(r.Context(), username)
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "GET /webhooks: failed to get list of webhooks", "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get list of webhooks")
+		return
+	}
+
+	response := make([]webhookResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		response[i] = newWebhookResponse(subscription)
+	}
+
+	server.WriteJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := server.store.DeleteWebhookSubscription(r.Context(), db.DeleteWebhookSubscriptionParams{
+		SubscriptionID: id,
+		Owner:          username,
+	})
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "DELETE /webhooks/{id}: failed to delete webhook", "subscription_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete webhook with ID: %d", id))
+		return
+	}
+	if deleted == 0 {
+		server.WriteError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (server *Server) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := server.parsePagination(w, r)
+	if !ok {
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := server.getCallerWebhook(w, r, id, username); !ok {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(r.Context(), db.ListWebhookDeliveriesParams{
+		SubscriptionID: id,
+		Owner:          username,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		server.logger.ErrorContext(r.Context(), "GET /webhooks/{id}/deliveries: failed to get list of deliveries", "subscription_id", id, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, "failed to get list of deliveries")
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, deliveries)
+}
+
+func (server *Server) replayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := server.parsePathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	deliveryID, ok := server.parsePathID(w, r, "delivery_id")
+	if !ok {
+		return
+	}
+
+	username, ok := server.caller(w, r)
+	if !ok {
+		return
+	}
+
+	// Make sure the delivery belongs to a webhook of the caller
+	delivery, err := server.store.GetWebhookDelivery(r.Context(), db.GetWebhookDeliveryParams{
+		DeliveryID:     deliveryID,
+		SubscriptionID: id,
+		Owner:          username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusNotFound, "delivery not found")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "POST /webhooks/{id}/deliveries/{delivery_id}/replay: failed to get delivery", "delivery_id", deliveryID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get delivery with ID: %d", deliveryID))
+		return
+	}
+
+	// Only failed deliveries can be replayed, pending ones will be retried anyway
+	delivery, err = server.store.ReplayWebhookDelivery(r.Context(), db.ReplayWebhookDeliveryParams{
+		DeliveryID:     deliveryID,
+		SubscriptionID: id,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.WriteError(w, http.StatusConflict, "only failed deliveries can be replayed")
+			return
+		}
+
+		server.logger.ErrorContext(r.Context(), "POST /webhooks/{id}/deliveries/{delivery_id}/replay: failed to replay delivery", "delivery_id", deliveryID, "error", err)
+		server.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to replay delivery with ID: %d", deliveryID))
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, delivery)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Store keeping webhook subscriptions in memory, every other method panics
+type webhookStore struct {
+	db.Store
+	subscriptions map[int64]db.WebhookSubscription
+}
+
+func (store webhookStore) CreateWebhookSubscription(ctx context.Context, arg db.CreateWebhookSubscriptionParams) (db.WebhookSubscription, error) {
+	subscription := db.WebhookSubscription{
+		SubscriptionID: int64(len(store.subscriptions) + 1),
+		Owner:          arg.Owner,
+		Url:            arg.Url,
+		Secret:         arg.Secret,
+		EventTypes:     arg.EventTypes,
+	}
+	store.subscriptions[subscription.SubscriptionID] = subscription
+	return subscription, nil
+}
+
+func (store webhookStore) ListWebhookSubscriptions(ctx context.Context, owner string) ([]db.WebhookSubscription, error) {
+	var subscriptions []db.WebhookSubscription
+	for _, subscription := range store.subscriptions {
+		if subscription.Owner == owner {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (store webhookStore) DeleteWebhookSubscription(ctx context.Context, arg db.DeleteWebhookSubscriptionParams) (int64, error) {
+	subscription, ok := store.subscriptions[arg.SubscriptionID]
+	if !ok || subscription.Owner != arg.Owner {
+		return 0, nil
+	}
+	delete(store.subscriptions, arg.SubscriptionID)
+	return 1, nil
+}
+
+func TestWebhookOwnership(t *testing.T) {
+	store := webhookStore{subscriptions: map[int64]db.WebhookSubscription{}}
+	server := &Server{
+		store:    store,
+		validate: newValidator(),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	do := func(handler http.HandlerFunc, target, username, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, strings.NewReader(body))
+		r.SetPathValue("id", "1")
+		if username != "" {
+			r.Header.Set(headerUsername, username)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	body := `{"url": "https://93.184.216.34/hooks", "event_types": ["TransferReceived"]}`
+	require.Equal(t, http.StatusUnauthorized, do(server.createWebhook, "/webhooks", "", body).Code)
+	require.Equal(t, http.StatusBadRequest, do(server.createWebhook, "/webhooks", "alice",
+		`{"url": "http://169.254.169.254/latest", "event_types": ["TransferReceived"]}`).Code)
+
+	// The subscription belongs to the caller, the secret is only returned on creation
+	w := do(server.createWebhook, "/webhooks", "alice", body)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct {
+		Data map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, "alice", created.Data["owner"])
+	require.NotEmpty(t, created.Data["secret"])
+
+	w = do(server.listWebhooks, "/webhooks", "alice", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"owner":"alice"`)
+	require.NotContains(t, w.Body.String(), "secret")
+
+	w = do(server.listWebhooks, "/webhooks?owner=alice", "mallory", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotContains(t, w.Body.String(), "alice")
+
+	// Only the owner can delete it
+	require.Equal(t, http.StatusNotFound, do(server.deleteWebhook, "/webhooks/1", "mallory", "").Code)
+	require.Equal(t, http.StatusNoContent, do(server.deleteWebhook, "/webhooks/1", "alice", "").Code)
+	require.Empty(t, store.subscriptions)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func (app *app) newAccountCommand() *cobra.Command {
+	account := &cobra.Command{
+		Use:   "account",
+		Short: "Inspect and manage accounts",
+	}
+
+	account.AddCommand(
+		&cobra.Command{
+			Use:   "show ID",
+			Short: "Print an account",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return app.withAccount(args[0], func(ctx context.Context, store db.Store, id int64) error {
+					account, err := store.GetAccount(ctx, id)
+					if err != nil {
+						return err
+					}
+					return app.printJSON(account)
+				})
+			},
+		},
+		app.newAccountStatusCommand("freeze", "Freeze an account, so it can't send or receive money", db.AccountStatusFrozen),
+		app.newAccountStatusCommand("unfreeze", "Unfreeze a frozen account", db.AccountStatusActive),
+		&cobra.Command{
+			Use:   "set-interest-rate ID BPS",
+			Short: "Set the annual interest rate of a savings account, in basis points (250 = 2.50%)",
+			Long: "Set the annual interest rate of a savings account, in basis points (250 = 2.50%). The new rate " +
+				"applies to the days not accrued yet.",
+			Args: cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				rate, err := strconv.ParseInt(args[1], 10, 32)
+				if err != nil || rate < 0 {
+					return fmt.Errorf("invalid interest rate %q, expected basis points", args[1])
+				}
+
+				return app.withAccount(args[0], func(ctx context.Context, store db.Store, id int64) error {
+					account, err := store.UpdateAccountInterestRate(ctx, db.UpdateAccountInterestRateParams{
+						AccountID:       id,
+						InterestRateBps: int32(rate),
+					})
+					if db.ErrorCode(err) == db.CheckViolation {
+						return fmt.Errorf("account %d is not a savings account", id)
+					}
+					if err != nil {
+						return err
+					}
+					return app.printJSON(account)
+				})
+			},
+		},
+		app.newAccountOverdraftCommand(),
+		&cobra.Command{
+			Use:   "deposit ID AMOUNT",
+			Short: "Deposit cash into an account, in minor units",
+			Long: "Deposit cash into an account, in minor units. The money is transferred from the cash clearing " +
+				"account of the bank in the currency of the account. Deposits are only made by operators, customers " +
+				"can't create money through the API.",
+			Args: cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				amount, err := strconv.ParseInt(args[1], 10, 64)
+				if err != nil || amount <= 0 {
+					return fmt.Errorf("invalid amount %q, expected positive minor units", args[1])
+				}
+
+				return app.withAccount(args[0], func(ctx context.Context, store db.Store, id int64) error {
+					result, err := store.DepositTx(ctx, db.DepositTxParams{AccountID: id, Amount: amount})
+					if errors.Is(err, db.ErrAccountFrozen) {
+						return fmt.Errorf("account %d is frozen", id)
+					}
+					if err != nil {
+						return err
+					}
+					return app.printJSON(result)
+				})
+			},
+		},
+	)
+
+	return account
+}
+
+// Helper method: create the subcommand granting or removing the overdraft facility of an account
+func (app *app) newAccountOverdraftCommand() *cobra.Command {
+	var rate int32
+	var fee int64
+
+	cmd := &cobra.Command{
+		Use:   "set-overdraft ID LIMIT",
+		Short: "Set how far an account may go negative, in minor units. 0 removes the overdraft",
+		Long: "Set how far an account may go negative, in minor units. 0 removes the overdraft, an account already " +
+			"overdrawn stays so until it is paid back. Overdrawn days are charged interest at --rate and a flat --fee, " +
+			"posted monthly.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil || limit < 0 {
+				return fmt.Errorf("invalid overdraft limit %q, expected minor units", args[1])
+			}
+			if rate < 0 {
+				return fmt.Errorf("invalid overdraft rate %d, expected basis points", rate)
+			}
+			if fee < 0 {
+				return fmt.Errorf("invalid overdraft fee %d, expected minor units", fee)
+			}
+
+			return app.withAccount(args[0], func(ctx context.Context, store db.Store, id int64) error {
+				account, err := store.UpdateAccountOverdraft(ctx, db.UpdateAccountOverdraftParams{
+					AccountID:        id,
+					OverdraftLimit:   limit,
+					OverdraftRateBps: rate,
+					OverdraftFee:     fee,
+				})
+				if err != nil {
+					return err
+				}
+				return app.printJSON(account)
+			})
+		},
+	}
+
+	cmd.Flags().Int32Var(&rate, "rate", 0, "annual overdraft interest rate, in basis points (1500 = 15.00%)")
+	cmd.Flags().Int64Var(&fee, "fee", 0, "fee charged for every day that ends overdrawn, in minor units")
+	return cmd
+}
+
+// Helper method: create a subcommand setting the status of an account
+func (app *app) newAccountStatusCommand(name, short, status string) *cobra.Command {
+	return &cobra.Command{
+		Use:   name + " ID",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.withAccount(args[0], func(ctx context.Context, store db.Store, id int64) error {
+				account, err := store.GetAccount(ctx, id)
+				if err != nil {
+					return err
+				}
+
+				// The update only applies to the version read above, so a concurrent change isn't overwritten
+				account, err = store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+					AccountID: id,
+					Status:    status,
+					Version:   account.Version,
+				})
+				if errors.Is(err, db.ErrRecordNotFound) {
+					return fmt.Errorf("account %d was modified concurrently, try again", id)
+				}
+				if err != nil {
+					return err
+				}
+				return app.printJSON(account)
+			})
+		},
+	}
+}
+
+// Helper method: parse the account ID argument and run fn with a store, reporting unknown accounts clearly
+func (app *app) withAccount(arg string, fn func(ctx context.Context, store db.Store, id int64) error) error {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid account ID %q", arg)
+	}
+
+	return app.withStore(context.Background(), func(store db.Store) error {
+		err := fn(context.Background(), store, id)
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account %d not found", id)
+		}
+		return err
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/interest"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (app *app) newInterestCommand() *cobra.Command {
+	var through string
+
+	cmd := &cobra.Command{
+		Use:   "interest",
+		Short: "Accrue and post the interest of savings and overdrawn accounts",
+		Long: "Accrue the daily interest of every savings and overdrawable account through the given day, posting " +
+			"every month completed on the way. The server does the same in the background, this command catches up by hand, " +
+			"e.g. after an outage. Days and months already processed are skipped.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			day := interest.LastCompleteDay(time.Now())
+			if through != "" {
+				var err error
+				day, err = time.Parse(time.DateOnly, through)
+				if err != nil {
+					return fmt.Errorf("invalid --through date %q, expected YYYY-MM-DD", through)
+				}
+				if day.After(interest.LastCompleteDay(time.Now())) {
+					return fmt.Errorf("--through must be a day that is over, got %s", through)
+				}
+			}
+
+			return app.withStore(context.Background(), func(store db.Store) error {
+				worker := interest.NewWorker(store, app.logger, 0, app.config.Workers.InterestBatchSize)
+				processed, err := worker.RunOnce(context.Background(), day)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(app.stdout, "Processed the interest of %d accounts through %s\n", processed, day.Format(time.DateOnly))
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&through, "through", "", "last day to accrue (YYYY-MM-DD), yesterday by default")
+
+	return cmd
+}
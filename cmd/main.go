@@ -1,37 +1,107 @@
 package main
 
 import (
-	"database/sql"
-	"gobank/api"
+	"context"
+	"fmt"
 	db "gobank/db/sqlc"
 	"gobank/util"
+	"io"
 	"log/slog"
 	"os"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
 )
 
+// Dependencies shared by the commands. The config and the logger are loaded before any command runs
+type app struct {
+	configPath string
+	config     util.Config
+	logger     *slog.Logger
+	stdout     io.Writer
+}
+
 func main() {
 	// Initialize logger
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	// Load config from .env
-	config, err := util.LoadConfig(".")
+	app := &app{logger: logger, stdout: os.Stdout}
+	if err := app.newRootCommand().Execute(); err != nil {
+		logger.Error("Command failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func (app *app) newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "gobank",
+		Short:         "Gobank server and administration commands",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Load config from .env
+			config, err := util.LoadConfig(app.configPath)
+			if err != nil {
+				return err
+			}
+			app.config = config
+			return nil
+		},
+	}
+	root.PersistentFlags().StringVar(&app.configPath, "config-path", ".", "directory containing app.env")
+
+	root.AddCommand(
+		app.newServeCommand(),
+		app.newMigrateCommand(),
+		app.newReconcileCommand(),
+		app.newCreateUserCommand(),
+		app.newSeedCommand(),
+		app.newAccountCommand(),
+		app.newInterestCommand(),
+	)
+	return root
+}
+
+// Helper method: connect to the primary database, giving up after the configured number of attempts
+func (app *app) connect(ctx context.Context) (*pgxpool.Pool, error) {
+	config, err := app.poolConfig(app.config.Database.Source)
 	if err != nil {
-		logger.Error("Failed to load configurations from .env", "error", err)
-		return
+		return nil, err
 	}
 
-	// Connect to database
-	conn, err := sql.Open(config.DbDriver, config.DbSource)
+	return db.Connect(ctx, config, app.config.Database.ConnectAttempts, app.config.Database.ConnectBackoff, app.logger)
+}
+
+// Helper method: parse a connection string and apply the pool settings
+func (app *app) poolConfig(source string) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(source)
 	if err != nil {
-		logger.Error("Error creating test connection", "error", err)
-		return
+		return nil, fmt.Errorf("invalid database source: %w", err)
 	}
 
-	// Create a server
-	svr := api.NewServer(db.NewStore(conn), logger)
-	if err := svr.Start(config.Domain, config.Port); err != nil {
-		logger.Error("Error: server shutdown", "error", err)
+	config.MaxConns = app.config.Database.MaxConns
+	config.MinConns = app.config.Database.MinConns
+	config.MaxConnLifetime = app.config.Database.ConnMaxLifetime
+	config.MaxConnIdleTime = app.config.Database.ConnMaxIdleTime
+	return config, nil
+}
+
+// Helper method: connect to the database and run fn with a store, closing the connection afterwards
+func (app *app) withStore(ctx context.Context, fn func(store db.Store) error) error {
+	conn, err := app.connect(ctx)
+	if err != nil {
+		return err
 	}
+	defer conn.Close()
+
+	return fn(app.newStore(conn, nil))
+}
+
+// Helper method: create a store retrying transactions as configured. onRetry is called before each retry, if set
+func (app *app) newStore(conn *pgxpool.Pool, onRetry func(code string)) db.Store {
+	return db.NewStoreWithRetry(conn, db.TxRetry{
+		MaxAttempts: app.config.Database.TxMaxAttempts,
+		Backoff:     app.config.Database.TxRetryBackoff,
+		OnRetry:     onRetry,
+	})
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gobank/db/migration"
+	"io"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+func (app *app) newMigrateCommand() *cobra.Command {
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply or roll back the embedded schema migrations",
+	}
+
+	// Each subcommand runs one migrator operation, then reports the resulting version
+	run := func(op func(*migration.Migrator) error) error {
+		conn, err := app.connect(context.Background())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		return withMigrator(conn, app.stdout, op)
+	}
+
+	migrate.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply every pending migration",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return run((*migration.Migrator).Up)
+			},
+		},
+		&cobra.Command{
+			Use:   "down N",
+			Short: "Roll back the last N migrations",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				n, err := strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid number of migrations %q: %w", args[0], err)
+				}
+				return run(func(migrator *migration.Migrator) error {
+					return migrator.Down(n)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "version",
+			Short: "Print the current schema version",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return run(func(*migration.Migrator) error { return nil })
+			},
+		},
+		&cobra.Command{
+			Use:   "force VERSION",
+			Short: "Set the schema version without running migrations, after fixing a failed one by hand",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid version %q: %w", args[0], err)
+				}
+				return run(func(migrator *migration.Migrator) error {
+					return migrator.Force(version)
+				})
+			},
+		},
+	)
+
+	return migrate
+}
+
+// Helper method: run a migrator operation on the database, then print the resulting version
+func withMigrator(pool *pgxpool.Pool, out io.Writer, op func(*migration.Migrator) error) (err error) {
+	migrator, err := migration.New(pool)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, migrator.Close())
+	}()
+
+	if err := op(migrator); err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	latest, err := migration.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "version %d (latest %d)", version, latest)
+	if dirty {
+		fmt.Fprint(out, " dirty")
+	}
+	fmt.Fprintln(out)
+	return nil
+}
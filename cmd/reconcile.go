@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	db "gobank/db/sqlc"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (app *app) newReconcileCommand() *cobra.Command {
+	var since string
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Check that every transfer has matching debit and credit entries",
+		Long: "Check that every transfer has exactly one debit entry on the from account and one credit entry on the " +
+			"to account, both for the transfer amount. It exits with an error when an unbalanced transfer is found. " +
+			"Entries are only linked to their transfer since migration 2, so older transfers are always reported.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var from time.Time
+			if since != "" {
+				var err error
+				from, err = time.Parse(time.DateOnly, since)
+				if err != nil {
+					return fmt.Errorf("invalid --since date %q, expected YYYY-MM-DD", since)
+				}
+			}
+
+			return app.withStore(context.Background(), func(store db.Store) error {
+				return app.reconcile(context.Background(), store, from)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&since, "since", "", "only check transfers created on or after this date (YYYY-MM-DD)")
+
+	return cmd
+}
+
+// Helper method: report the unbalanced transfers created since the given time
+func (app *app) reconcile(ctx context.Context, store db.Store, since time.Time) error {
+	transfers, err := store.ListUnbalancedTransfers(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	if len(transfers) == 0 {
+		fmt.Fprintln(app.stdout, "All transfers are balanced")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(app.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSFER\tFROM\tTO\tAMOUNT\tENTRIES\tENTRY SUM")
+	for _, t := range transfers {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%d\n",
+			t.TransferID, t.FromAccountID, t.ToAccountID, t.Amount, t.EntryCount, t.EntrySum)
+	}
+	w.Flush()
+
+	return fmt.Errorf("found %d unbalanced transfers", len(transfers))
+}
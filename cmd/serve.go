@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gobank/api"
+	"gobank/db/migration"
+	db "gobank/db/sqlc"
+	"gobank/event"
+	"gobank/health"
+	"gobank/interest"
+	"gobank/metrics"
+	"gobank/notification"
+	"gobank/tracing"
+	"gobank/util"
+	"gobank/webhook"
+	"gobank/worker"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+func (app *app) newServeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP server and the background workers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.serve()
+		},
+	}
+}
+
+// Run the server until SIGINT or SIGTERM, then drain in-flight requests and background work
+func (app *app) serve() error {
+	config, logger := app.config, app.logger
+
+	// Export traces, the pending spans are flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), config)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	// Connect to database, giving up after the configured number of attempts
+	conn, err := app.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if config.Database.MigrateOnStart {
+		if err := withMigrator(conn, app.stdout, (*migration.Migrator).Up); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+	}
+
+	// Schema version this binary expects, checked by the readiness probe
+	schemaVersion, err := migration.LatestVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	// Cancelled on SIGINT or SIGTERM, which starts the graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Background workers all stop when ctx is cancelled, the wait group tracks when they are done
+	var workers sync.WaitGroup
+	runWorker := func(start func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			start(ctx)
+		}()
+	}
+
+	// Collect metrics about the database pool and the transactions
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(conn, "gobank")
+	var dbStore db.Store = app.newStore(conn, appMetrics.ObserveTxRetry)
+
+	// Route reads to the replica when there is one. It isn't required at startup: reads use the primary
+	// until the replica answers and has caught up
+	if config.Database.ReplicaSource != "" {
+		replicaConfig, err := app.poolConfig(config.Database.ReplicaSource)
+		if err != nil {
+			return fmt.Errorf("failed to configure read replica: %w", err)
+		}
+		replicaConn, err := pgxpool.NewWithConfig(context.Background(), replicaConfig)
+		if err != nil {
+			return fmt.Errorf("failed to open read replica: %w", err)
+		}
+		defer replicaConn.Close()
+		appMetrics.RegisterDB(replicaConn, "gobank_replica")
+
+		replicaStore := db.NewReplicaStore(dbStore, replicaConn, config.Database.ReplicaMaxLag, logger)
+		runWorker(func(ctx context.Context) {
+			replicaStore.Start(ctx, config.Database.ReplicaCheckInterval)
+		})
+		dbStore = replicaStore
+	}
+	store := metrics.NewStore(dbStore, appMetrics)
+
+	// Start the outbox relay
+	publisher, err := newPublisher(config, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create event publisher: %w", err)
+	}
+	if closer, ok := publisher.(io.Closer); ok {
+		defer closer.Close()
+	}
+	publisher = event.NewMultiPublisher(publisher, webhook.NewDispatcher(store))
+	runWorker(event.NewRelay(store, publisher, logger, config.Workers.PollInterval, config.Workers.OutboxBatchSize).Start)
+
+	// Start the webhook delivery worker
+	client := webhook.NewClient(10 * time.Second)
+	runWorker(webhook.NewWorker(store, client, logger, config.Workers.PollInterval, config.Workers.WebhookBatchSize).Start)
+
+	// Start the interest worker, accruing and posting the interest of savings and overdrawn accounts
+	runWorker(interest.NewWorker(store, logger, config.Workers.InterestInterval, config.Workers.InterestBatchSize).Start)
+
+	// Start the background task processor
+	processor := worker.NewTaskProcessor(store, logger,
+		config.Workers.Concurrency, config.Workers.PollInterval, config.Workers.TaskLease)
+	processor.HandleNotifications(newNotificationService(config, store, logger))
+	runWorker(processor.Start)
+
+	// Dependencies checked by the readiness probe
+	checker := health.NewChecker(config.Health.CheckTimeout)
+	checker.Add("database", health.DatabaseCheck(store))
+	checker.Add("schema", health.SchemaCheck(store, int64(schemaVersion)))
+	checker.Add("task_processor", health.HeartbeatCheck(processor.LastHeartbeat, config.Health.HeartbeatMaxAge))
+
+	// Create a server
+	svr := api.NewServer(config, store, worker.NewPostgresTaskDistributor(), appMetrics, checker, logger)
+	serverErr := runServer(ctx, svr, logger)
+	stop()
+
+	// Drain in-flight requests and background work, giving up after the shutdown timeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := svr.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to drain in-flight requests", "error", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info("Background workers stopped")
+	case <-shutdownCtx.Done():
+		logger.Error("Timed out waiting for background workers")
+	}
+
+	return serverErr
+}
+
+// Helper method: start the server and wait for a signal, or for the server to fail on its own, e.g. when the port
+// is already in use. The failure is returned so the process doesn't exit as if it was stopped cleanly
+func runServer(ctx context.Context, svr *api.Server, logger *slog.Logger) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- svr.Start()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		logger.Error("Server stopped unexpectedly", "error", err)
+		return fmt.Errorf("server: %w", err)
+	case <-ctx.Done():
+		logger.Info("Shutdown signal received")
+		return nil
+	}
+}
+
+// Helper method: create the event publisher selected in the config. Without one, events would pile up in the
+// outbox or reach no consumer, so it must be chosen explicitly, even the log publisher used in development
+func newPublisher(config util.Config, logger *slog.Logger) (event.Publisher, error) {
+	switch config.Outbox.Publisher {
+	case "":
+		return nil, errors.New("no outbox publisher configured, set OUTBOX_PUBLISHER (log for development)")
+	case "log":
+		return event.NewLogPublisher(logger), nil
+	case "webhook":
+		return event.NewWebhookPublisher(config.Outbox.WebhookURL, 10*time.Second), nil
+	case "redis":
+		return event.NewRedisPublisher(config.Outbox.RedisAddress, "gobank"), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher: %s", config.Outbox.Publisher)
+	}
+}
+
+// Helper method: create the notification service. Emails go through SMTP when configured, SMS are only logged
+func newNotificationService(config util.Config, store db.Store, logger *slog.Logger) *notification.Service {
+	var email notification.Notifier = notification.NewLogNotifier(logger)
+	if config.Notification.SMTPAddress != "" {
+		email = notification.NewSMTPNotifier(config.Notification.SMTPAddress, config.Notification.SMTPFrom)
+	}
+
+	return notification.NewService(store, email, notification.NewLogNotifier(logger))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"gobank/api"
+	"gobank/event"
+	"gobank/health"
+	"gobank/metrics"
+	"gobank/util"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunServerPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	config := util.Config{Server: util.ServerConfig{Domain: host, Port: port}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svr := api.NewServer(config, nil, nil, metrics.New(), health.NewChecker(time.Second), logger)
+
+	// A server that can't start is an error, not a clean stop
+	err = runServer(context.Background(), svr, logger)
+	require.Error(t, err)
+	require.ErrorContains(t, err, "address already in use")
+}
+
+func TestNewPublisher(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// A publisher must be chosen, events would go nowhere otherwise
+	_, err := newPublisher(util.Config{}, logger)
+	require.ErrorContains(t, err, "no outbox publisher configured")
+
+	publisher, err := newPublisher(util.Config{Outbox: util.OutboxConfig{Publisher: "log"}}, logger)
+	require.NoError(t, err)
+	require.IsType(t, &event.LogPublisher{}, publisher)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/util"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func (app *app) newCreateUserCommand() *cobra.Command {
+	var arg db.CreateUserParams
+
+	cmd := &cobra.Command{
+		Use:   "create-user",
+		Short: "Create a user, whose username can then own accounts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if arg.Username == db.BankOwner {
+				return fmt.Errorf("username %s is reserved", arg.Username)
+			}
+
+			return app.withStore(context.Background(), func(store db.Store) error {
+				user, err := store.CreateUser(context.Background(), arg)
+				if err != nil {
+					return err
+				}
+				return app.printJSON(user)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&arg.Username, "username", "", "unique username, used as the owner of accounts")
+	cmd.Flags().StringVar(&arg.FullName, "full-name", "", "full name of the user")
+	cmd.Flags().StringVar(&arg.Email, "email", "", "unique email address")
+	cmd.MarkFlagRequired("username")
+	cmd.MarkFlagRequired("full-name")
+	cmd.MarkFlagRequired("email")
+
+	return cmd
+}
+
+func (app *app) newSeedCommand() *cobra.Command {
+	var users, accounts int
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Create random users and accounts, for local development only",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.withStore(context.Background(), func(store db.Store) error {
+				return app.seed(context.Background(), store, users, accounts)
+			})
+		},
+	}
+	cmd.Flags().IntVar(&users, "users", 5, "number of users to create")
+	cmd.Flags().IntVar(&accounts, "accounts", 2, "number of accounts to create for each user")
+
+	return cmd
+}
+
+// Helper method: create random users, each with the given number of accounts in random currencies. Rows are
+// inserted with COPY, so seeding large databases is fast, but no AccountCreated event is recorded
+func (app *app) seed(ctx context.Context, store db.Store, users, accounts int) error {
+	currencies := []string{util.USD, util.EUR, util.VND}
+	accountTypes := []string{db.AccountTypeChecking, db.AccountTypeSavings, db.AccountTypeBusiness}
+
+	userRows := make([]db.CopyUsersParams, 0, users)
+	accountRows := make([]db.CopyAccountsParams, 0, users*accounts)
+	for range users {
+		username := strings.ToLower(util.RandomString(8))
+		userRows = append(userRows, db.CopyUsersParams{
+			Username: username,
+			FullName: util.RandomString(6) + " " + util.RandomString(8),
+			Email:    username + "@example.com",
+		})
+
+		for range accounts {
+			accountRows = append(accountRows, db.CopyAccountsParams{
+				Owner:       username,
+				Balance:     util.RandomInt(0, 1_000_000),
+				Currency:    currencies[util.RandomInt(0, int64(len(currencies)-1))],
+				AccountType: accountTypes[util.RandomInt(0, int64(len(accountTypes)-1))],
+			})
+		}
+	}
+
+	createdUsers, err := store.CopyUsers(ctx, userRows)
+	if err != nil {
+		return err
+	}
+	createdAccounts, err := store.CopyAccounts(ctx, accountRows)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stdout, "Created %d users and %d accounts\n", createdUsers, createdAccounts)
+	return nil
+}
+
+// Helper method: print a value as indented JSON
+func (app *app) printJSON(v any) error {
+	encoder := json.NewEncoder(app.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "entry_account_id_created_at_idx";
+ALTER TABLE "entry" DROP COLUMN IF EXISTS "transfer_id";
@@ -0,0 +1,7 @@
+-- Link each entry to the transfer that produced it, so statements can show the counterparty
+ALTER TABLE "entry" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entry" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfer" ("transfer_id");
+
+-- Statements scan entries of a single account within a period
+CREATE INDEX ON "entry" ("account_id", "created_at");
@@ -0,0 +1,16 @@
+-- Outbox table. Domain events are written in the same transaction as the change they describe,
+-- then published to downstream services by the relay worker
+CREATE TABLE "outbox_event" (
+  "event_id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+-- The relay only ever scans events that haven't been published yet
+CREATE INDEX ON "outbox_event" ("event_id") WHERE "published_at" IS NULL;
+
+CREATE INDEX ON "outbox_event" ("aggregate_type", "aggregate_id");
@@ -0,0 +1,38 @@
+-- Webhook subscription table. Each owner can register URLs to be called back on account activity
+CREATE TABLE "webhook_subscription" (
+  "subscription_id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Webhook delivery table. One row per event per subscription, also used as the delivery log
+CREATE TABLE "webhook_delivery" (
+  "delivery_id" bigserial PRIMARY KEY,
+  "subscription_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_status_code" int,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz
+);
+
+CREATE INDEX ON "webhook_subscription" ("owner");
+
+CREATE INDEX ON "webhook_delivery" ("subscription_id");
+
+CREATE INDEX ON "webhook_delivery" ("next_attempt_at") WHERE "status" = 'pending';
+
+-- An event is delivered at most once per subscription, even if the outbox relay publishes it again
+CREATE UNIQUE INDEX ON "webhook_delivery" ("subscription_id", "event_id", "event_type");
+
+ALTER TABLE "webhook_delivery" ADD FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscription" ("subscription_id") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_delivery" ADD FOREIGN KEY ("event_id") REFERENCES "outbox_event" ("event_id");
@@ -0,0 +1,12 @@
+-- Notification preference table. Thresholds are in the minor units of the account currency,
+-- a NULL threshold disables the corresponding notification
+CREATE TABLE "notification_preference" (
+  "owner" varchar PRIMARY KEY,
+  "email" varchar,
+  "phone" varchar,
+  "email_enabled" boolean NOT NULL DEFAULT false,
+  "sms_enabled" boolean NOT NULL DEFAULT false,
+  "low_balance_threshold" bigint,
+  "large_debit_threshold" bigint,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
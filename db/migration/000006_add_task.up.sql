@@ -0,0 +1,22 @@
+-- Task table. Background work queued by the API and processed by the workers.
+-- A task is pending until it runs, running while a worker holds its lease, then completed, or dead once it
+-- has failed max_attempts times (the dead letter queue)
+CREATE TABLE "task" (
+  "task_id" bigserial PRIMARY KEY,
+  "type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "max_attempts" int NOT NULL,
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "task" ("run_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "task" ("locked_until") WHERE "status" = 'running';
+
+CREATE INDEX ON "task" ("status", "type");
@@ -0,0 +1,3 @@
+ALTER TABLE "account" DROP COLUMN IF EXISTS "status";
+
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,12 @@
+-- User table. Accounts reference their owner by username, existing owners are not required to have a user
+CREATE TABLE "users" (
+  "username" varchar PRIMARY KEY,
+  "full_name" varchar NOT NULL,
+  "email" varchar UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- A frozen account can't send or receive money until an operator unfreezes it
+ALTER TABLE "account" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "account" ADD CONSTRAINT "account_status_check" CHECK ("status" IN ('active', 'frozen'));
@@ -0,0 +1,2 @@
+-- Bumped on every change of the account, so clients can detect concurrent updates (optimistic concurrency)
+ALTER TABLE "account" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+ALTER TABLE "account" DROP COLUMN IF EXISTS "labels";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "nickname";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "account_type";
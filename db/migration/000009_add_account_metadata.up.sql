@@ -0,0 +1,11 @@
+-- Account metadata, so customers can tell their accounts apart. Labels are owner-defined key/value pairs
+ALTER TABLE "account" ADD COLUMN "account_type" varchar NOT NULL DEFAULT 'checking';
+ALTER TABLE "account" ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
+ALTER TABLE "account" ADD COLUMN "labels" jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE "account" ADD CONSTRAINT "account_type_check" CHECK ("account_type" IN ('checking', 'savings', 'business'));
+
+ALTER TABLE "account" ADD CONSTRAINT "account_labels_check" CHECK (jsonb_typeof("labels") = 'object');
+
+-- Accounts are filtered by label, with the containment (@>) and key existence (?&) operators
+CREATE INDEX ON "account" USING GIN ("labels");
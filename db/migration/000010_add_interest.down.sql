@@ -0,0 +1,8 @@
+-- The interest expense accounts are kept: the interest already posted is booked against them
+DROP TABLE IF EXISTS "bank_account";
+
+ALTER TABLE "account" DROP CONSTRAINT IF EXISTS "account_interest_rate_check";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "interest_posted_through";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "interest_accrued_through";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "accrued_interest";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "interest_rate_bps";
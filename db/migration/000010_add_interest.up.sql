@@ -0,0 +1,28 @@
+-- Interest on savings accounts. The rate is annual, in basis points (250 = 2.50%). Interest accrues every day on the
+-- end-of-day balance (UTC) into accrued_interest, in millionths of the minor unit, and is posted once a month
+ALTER TABLE "account" ADD COLUMN "interest_rate_bps" integer NOT NULL DEFAULT 0;
+ALTER TABLE "account" ADD COLUMN "accrued_interest" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "account" ADD COLUMN "interest_accrued_through" date;
+ALTER TABLE "account" ADD COLUMN "interest_posted_through" date;
+
+ALTER TABLE "account" ADD CONSTRAINT "account_interest_rate_check"
+  CHECK ("interest_rate_bps" >= 0 AND ("interest_rate_bps" = 0 OR "account_type" = 'savings'));
+
+-- Accounts of the bank itself, one per purpose and currency. Interest is paid from the interest expense accounts
+CREATE TABLE "bank_account" (
+  "purpose" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  PRIMARY KEY ("purpose", "currency")
+);
+
+ALTER TABLE "bank_account" ADD FOREIGN KEY ("account_id") REFERENCES "account" ("account_id");
+
+WITH created AS (
+  INSERT INTO "account" ("owner", "balance", "currency", "account_type", "nickname")
+  SELECT 'bank', 0, "currency", 'business', 'Interest expense'
+  FROM (VALUES ('USD'), ('EUR'), ('VND')) AS currencies ("currency")
+  RETURNING "account_id", "currency"
+)
+INSERT INTO "bank_account" ("purpose", "currency", "account_id")
+SELECT 'interest_expense', "currency", "account_id" FROM created;
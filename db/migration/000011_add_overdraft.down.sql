@@ -0,0 +1,6 @@
+-- The overdraft income accounts are kept: the interest already charged is booked against them
+DELETE FROM "bank_account" WHERE "purpose" = 'overdraft_income';
+
+ALTER TABLE "account" DROP CONSTRAINT IF EXISTS "account_overdraft_check";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "overdraft_rate_bps";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "overdraft_limit";
@@ -0,0 +1,16 @@
+-- Overdraft facility: the balance may go down to -overdraft_limit. Overdrawn days are charged interest at the annual
+-- overdraft_rate_bps, accrued into accrued_interest as a negative amount and posted with the savings interest
+ALTER TABLE "account" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "account" ADD COLUMN "overdraft_rate_bps" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "account" ADD CONSTRAINT "account_overdraft_check" CHECK ("overdraft_limit" >= 0 AND "overdraft_rate_bps" >= 0);
+
+-- Overdraft interest is paid into the overdraft income accounts of the bank
+WITH created AS (
+  INSERT INTO "account" ("owner", "balance", "currency", "account_type", "nickname")
+  SELECT 'bank', 0, "currency", 'business', 'Overdraft income'
+  FROM (VALUES ('USD'), ('EUR'), ('VND')) AS currencies ("currency")
+  RETURNING "account_id", "currency"
+)
+INSERT INTO "bank_account" ("purpose", "currency", "account_id")
+SELECT 'overdraft_income', "currency", "account_id" FROM created;
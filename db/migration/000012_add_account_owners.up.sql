@@ -0,0 +1,17 @@
+-- Co-owners of joint accounts. The owner column of the account stays its primary owner, who holds every permission
+-- without a row here. A co-owner invited by another co-owner is pending until the primary owner approves
+CREATE TABLE "account_owners" (
+  "account_id" bigint NOT NULL REFERENCES "account" ("account_id") ON DELETE CASCADE,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "permission" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "invited_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "approved_at" timestamptz,
+  PRIMARY KEY ("account_id", "owner"),
+  CONSTRAINT "account_owners_permission_check" CHECK ("permission" IN ('view', 'transact', 'manage')),
+  CONSTRAINT "account_owners_status_check" CHECK ("status" IN ('pending', 'active'))
+);
+
+-- Finds the joint accounts of a user
+CREATE INDEX ON "account_owners" ("owner");
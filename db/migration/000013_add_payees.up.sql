@@ -0,0 +1,11 @@
+-- Saved transfer recipients of a user. owner_name is the name of the target account's owner when the payee was
+-- added, shown so the user can check they saved the right account
+CREATE TABLE "payees" (
+  "payee_id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "nickname" varchar NOT NULL,
+  "account_id" bigint NOT NULL REFERENCES "account" ("account_id") ON DELETE CASCADE,
+  "owner_name" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "payees_owner_account_key" UNIQUE ("owner", "account_id")
+);
@@ -0,0 +1,3 @@
+ALTER TABLE "account" DROP COLUMN IF EXISTS "account_number";
+
+DROP FUNCTION IF EXISTS "generate_account_number"();
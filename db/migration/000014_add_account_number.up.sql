@@ -0,0 +1,13 @@
+-- External account number given to customers instead of the sequential account_id: 14 random digits followed by
+-- 2 check digits computed like an IBAN (ISO 7064 mod 97-10), so the whole number is 1 modulo 97 and most typos
+-- are detected. Generated by the database, so every way of creating an account gets one
+CREATE FUNCTION "generate_account_number"() RETURNS varchar
+LANGUAGE sql VOLATILE AS $$
+  SELECT payload || lpad((98 - (payload::bigint * 100) % 97)::text, 2, '0')
+  FROM (SELECT lpad(floor(random() * 1e14)::bigint::text, 14, '0') AS payload) AS generated
+$$;
+
+-- The default is evaluated for each existing account
+ALTER TABLE "account" ADD COLUMN "account_number" varchar NOT NULL DEFAULT generate_account_number();
+
+ALTER TABLE "account" ADD CONSTRAINT "account_account_number_key" UNIQUE ("account_number");
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS "transfer_reference_idx";
+
+ALTER TABLE "entry" DROP CONSTRAINT IF EXISTS "entry_metadata_check";
+ALTER TABLE "entry" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE "entry" DROP COLUMN IF EXISTS "reference";
+ALTER TABLE "entry" DROP COLUMN IF EXISTS "description";
+
+ALTER TABLE "transfer" DROP CONSTRAINT IF EXISTS "transfer_metadata_check";
+ALTER TABLE "transfer" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE "transfer" DROP COLUMN IF EXISTS "reference";
+ALTER TABLE "transfer" DROP COLUMN IF EXISTS "description";
@@ -0,0 +1,15 @@
+-- What a transfer is for: a free text description, the end-to-end reference chosen by the sender (e.g. an invoice
+-- number, passed unchanged to the recipient) and structured metadata. The entries of a transfer carry the same
+-- details, so statements don't need the transfer to show them
+ALTER TABLE "transfer" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfer" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfer" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE "transfer" ADD CONSTRAINT "transfer_metadata_check" CHECK (jsonb_typeof("metadata") = 'object');
+
+ALTER TABLE "entry" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "entry" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "entry" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE "entry" ADD CONSTRAINT "entry_metadata_check" CHECK (jsonb_typeof("metadata") = 'object');
+
+-- Finds the transfers of a reference. Most transfers have none
+CREATE INDEX "transfer_reference_idx" ON "transfer" ("reference") WHERE "reference" <> '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "entry_search_idx";
+DROP FUNCTION IF EXISTS "entry_search_vector"(varchar, varchar, varchar);
+
+ALTER TABLE "entry" DROP COLUMN IF EXISTS "counterparty_name";
@@ -0,0 +1,23 @@
+-- Name of the owner of the account on the other side of the transfer, as it was when the entry was booked: the full
+-- name of the owner when they are a user, the owner itself otherwise
+ALTER TABLE "entry" ADD COLUMN "counterparty_name" varchar NOT NULL DEFAULT '';
+
+UPDATE "entry" e
+SET "counterparty_name" = COALESCE(u."full_name", a."owner")
+FROM "transfer" t, "account" a
+LEFT JOIN "users" u ON u."username" = a."owner"
+WHERE t."transfer_id" = e."transfer_id"
+  AND a."account_id" = CASE WHEN t."from_account_id" = e."account_id" THEN t."to_account_id" ELSE t."from_account_id" END;
+
+-- Full-text search document of an entry, the description weighing more than the counterparty and the reference. The
+-- simple configuration doesn't stem words, so names and references match as typed, case insensitively
+CREATE FUNCTION "entry_search_vector"("description" varchar, "counterparty_name" varchar, "reference" varchar)
+RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+  SELECT setweight(to_tsvector('simple', description), 'A')
+      || setweight(to_tsvector('simple', counterparty_name), 'B')
+      || setweight(to_tsvector('simple', reference), 'C')
+$$;
+
+-- Searches must use the same expression to use the index
+CREATE INDEX "entry_search_idx" ON "entry" USING GIN (entry_search_vector("description", "counterparty_name", "reference"));
@@ -0,0 +1,2 @@
+-- The cash clearing accounts are kept: the deposits and withdrawals already made are booked against them
+DELETE FROM "bank_account" WHERE "purpose" = 'cash_clearing';
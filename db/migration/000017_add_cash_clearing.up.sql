@@ -0,0 +1,10 @@
+-- Deposits and withdrawals are transfers with the cash clearing accounts of the bank, so every entry has a
+-- counterpart and the ledger stays balanced. A clearing account goes negative by the cash taken in
+WITH created AS (
+  INSERT INTO "account" ("owner", "balance", "currency", "account_type", "nickname")
+  SELECT 'bank', 0, "currency", 'business', 'Cash clearing'
+  FROM (VALUES ('USD'), ('EUR'), ('VND')) AS currencies ("currency")
+  RETURNING "account_id", "currency"
+)
+INSERT INTO "bank_account" ("purpose", "currency", "account_id")
+SELECT 'cash_clearing', "currency", "account_id" FROM created;
@@ -0,0 +1,3 @@
+-- Lease of the unpublished events claimed by a relay. The events are published outside of any transaction, the
+-- lease keeps other relays away from them meanwhile, and expires if the relay dies
+ALTER TABLE "outbox_event" ADD COLUMN "locked_until" timestamptz;
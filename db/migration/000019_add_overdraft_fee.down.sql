@@ -0,0 +1,2 @@
+ALTER TABLE "account" DROP CONSTRAINT IF EXISTS "account_overdraft_fee_check";
+ALTER TABLE "account" DROP COLUMN IF EXISTS "overdraft_fee";
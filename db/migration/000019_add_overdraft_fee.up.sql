@@ -0,0 +1,5 @@
+-- Flat fee charged for every day that ends overdrawn, in minor units. It is accrued into accrued_interest with the
+-- overdraft interest, and posted with it to the overdraft income account
+ALTER TABLE "account" ADD COLUMN "overdraft_fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "account" ADD CONSTRAINT "account_overdraft_fee_check" CHECK ("overdraft_fee" >= 0);
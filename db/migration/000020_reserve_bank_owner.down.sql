@@ -0,0 +1 @@
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_username_reserved_check";
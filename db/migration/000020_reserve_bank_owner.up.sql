@@ -0,0 +1,2 @@
+-- The accounts of the bank itself are owned by 'bank', so no user may take that name
+ALTER TABLE "users" ADD CONSTRAINT "users_username_reserved_check" CHECK ("username" <> 'bank');
@@ -0,0 +1,2 @@
+ALTER TABLE "notification_preference" DROP CONSTRAINT IF EXISTS "notification_preference_threshold_currency_check";
+ALTER TABLE "notification_preference" DROP COLUMN IF EXISTS "threshold_currency";
@@ -0,0 +1,11 @@
+-- Thresholds are amounts in minor units, which only mean something in one currency: they now apply to the accounts
+-- in threshold_currency only. Existing thresholds are taken to be in the currency of the owner's first account
+ALTER TABLE "notification_preference" ADD COLUMN "threshold_currency" varchar;
+
+UPDATE "notification_preference" SET "threshold_currency" = COALESCE(
+  (SELECT "currency" FROM "account" WHERE "account"."owner" = "notification_preference"."owner" ORDER BY "account_id" LIMIT 1),
+  'USD')
+WHERE "low_balance_threshold" IS NOT NULL OR "large_debit_threshold" IS NOT NULL;
+
+ALTER TABLE "notification_preference" ADD CONSTRAINT "notification_preference_threshold_currency_check"
+  CHECK (("low_balance_threshold" IS NULL AND "large_debit_threshold" IS NULL) OR "threshold_currency" IS NOT NULL);
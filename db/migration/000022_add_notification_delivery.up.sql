@@ -0,0 +1,9 @@
+-- Notifications already sent for a transfer, per kind and channel. A notification task that fails on one channel is
+-- retried, and the channels it already reached are skipped instead of notified twice
+CREATE TABLE "notification_delivery" (
+  "transfer_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "channel" varchar NOT NULL,
+  "delivered_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("transfer_id", "kind", "channel")
+);
@@ -0,0 +1,116 @@
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// The migration files, embedded so the binary and the tests apply exactly the same schema
+//
+//go:embed *.sql
+var files embed.FS
+
+// Migrator applies the embedded migrations to a database
+type Migrator struct {
+	m    *migrate.Migrate
+	conn *sql.DB
+}
+
+// Constructor method for Migrator. The pool is kept open when the migrator is closed
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	// golang-migrate works on database/sql, the adapter borrows connections from the pool. The driver closes the
+	// *sql.DB it is given when the migrator is closed, so it must be this adapter and never a handle of the caller:
+	// closing the adapter releases its connections to the pool without closing the pool
+	conn := stdlib.OpenDBFromPool(pool)
+	driver, err := pgx.WithInstance(conn, &pgx.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "pgx5", driver)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Migrator{m: m, conn: conn}, nil
+}
+
+// Method to apply every pending migration. Being already up to date is not an error
+func (migrator *Migrator) Up() error {
+	if err := migrator.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Method to roll back the last n migrations
+func (migrator *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("number of migrations to roll back must be positive, got %d", n)
+	}
+	return migrator.m.Steps(-n)
+}
+
+// Method to get the current version, and whether the last migration failed half way. A database without any
+// migration is at version 0
+func (migrator *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := migrator.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Method to set the version without running any migration, to recover from a dirty state once fixed by hand
+func (migrator *Migrator) Force(version int) error {
+	return migrator.m.Force(version)
+}
+
+// Method to release the migration source and the session used for locking. The pool stays open. The driver already
+// closes the adapter, closing it again is a no-op kept for the case where the driver didn't get that far
+func (migrator *Migrator) Close() error {
+	sourceErr, databaseErr := migrator.m.Close()
+	return errors.Join(sourceErr, databaseErr, migrator.conn.Close())
+}
+
+// Get the version of the latest embedded migration, which is the schema version this binary expects
+func LatestVersion() (uint, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint64
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration file name %s: %w", entry.Name(), err)
+		}
+		latest = max(latest, version)
+	}
+
+	return uint(latest), nil
+}
@@ -0,0 +1,24 @@
+package migration
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedFiles(t *testing.T) {
+	// Every migration ships with its rollback
+	ups, err := fs.Glob(files, "*.up.sql")
+	require.NoError(t, err)
+	downs, err := fs.Glob(files, "*.down.sql")
+	require.NoError(t, err)
+
+	require.NotEmpty(t, ups)
+	require.Len(t, downs, len(ups))
+
+	// Migrations are numbered sequentially, so the latest version is the number of migrations
+	version, err := LatestVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint(len(ups)), version)
+}
@@ -2,38 +2,91 @@
 INSERT INTO account (
     owner, 
     balance, 
-    currency
+    currency,
+    account_type,
+    nickname,
+    labels
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, COALESCE(sqlc.narg(labels)::jsonb, '{}')
 ) RETURNING *;
 
+-- name: CopyAccounts :copyfrom
+-- Bulk insert with COPY, used to seed databases. It bypasses the outbox, so no AccountCreated event is recorded
+INSERT INTO account (
+    owner,
+    balance,
+    currency,
+    account_type
+) VALUES (
+    $1, $2, $3, $4
+);
+
 -- name: GetAccount :one
 SELECT * FROM account
 WHERE account_id = $1;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM account
+WHERE account_number = $1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM account
 WHERE account_id = $1
 FOR NO KEY UPDATE;
 
 -- name: ListAccount :many
+-- Every filter is optional. The accounts must hold every given label (key and value) and every given label key, and
+-- be owned or co-owned (actively) by the given member
 SELECT * FROM account
+WHERE (sqlc.narg(member)::varchar IS NULL OR owner = sqlc.narg(member) OR EXISTS (
+    SELECT 1 FROM account_owners
+    WHERE account_owners.account_id = account.account_id
+      AND account_owners.owner = sqlc.narg(member)
+      AND account_owners.status = 'active'
+  ))
+  AND (sqlc.narg(account_type)::varchar IS NULL OR account_type = sqlc.narg(account_type))
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND (sqlc.narg(labels)::jsonb IS NULL OR labels @> sqlc.narg(labels))
+  AND (sqlc.narg(label_keys)::text[] IS NULL OR labels ?& sqlc.narg(label_keys))
 ORDER BY account_id
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: UpdateAccount :one
+-- Only updates the account if it is still at the expected version, otherwise no row is returned
 UPDATE account
-SET balance = $2
+SET balance = $2,
+    version = version + 1
 WHERE account_id = $1
+  AND version = sqlc.arg(version)
 RETURNING *;
 
 -- name: AddAccountBalance :one
 UPDATE account
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount),
+    version = version + 1
 WHERE account_id = sqlc.arg(id)
 RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM account
-WHERE account_id = $1;
\ No newline at end of file
+WHERE account_id = $1;
+
+-- name: UpdateAccountStatus :one
+-- Only updates the account if it is still at the expected version, otherwise no row is returned
+UPDATE account
+SET status = $2,
+    version = version + 1
+WHERE account_id = $1
+  AND version = sqlc.arg(version)
+RETURNING *;
+
+-- name: UpdateAccountDetails :one
+-- Only the given fields are changed
+UPDATE account
+SET account_type = COALESCE(sqlc.narg(account_type), account_type),
+    nickname = COALESCE(sqlc.narg(nickname), nickname),
+    labels = COALESCE(sqlc.narg(labels), labels),
+    version = version + 1
+WHERE account_id = sqlc.arg(account_id)
+RETURNING *;
@@ -0,0 +1,47 @@
+-- name: GetAccountPermission :one
+-- The permission of a user on an account: manage for the primary owner, the permission of an active co-owner
+-- otherwise. No row is returned when the user can't access the account. Nobody has access to the accounts of the
+-- bank itself, they are only moved by the bank's own transactions
+SELECT 'manage'::varchar AS permission FROM account
+WHERE account.account_id = sqlc.arg(account_id) AND account.owner = sqlc.arg(owner)
+  AND account.account_id NOT IN (SELECT account_id FROM bank_account)
+UNION ALL
+SELECT account_owners.permission FROM account_owners
+WHERE account_owners.account_id = sqlc.arg(account_id)
+  AND account_owners.owner = sqlc.arg(owner)
+  AND account_owners.status = 'active'
+  AND account_owners.account_id NOT IN (SELECT account_id FROM bank_account)
+LIMIT 1;
+
+-- name: CreateAccountOwner :one
+INSERT INTO account_owners (
+    account_id,
+    owner,
+    permission,
+    status,
+    invited_by,
+    approved_at
+) VALUES (
+    sqlc.arg(account_id), sqlc.arg(owner), sqlc.arg(permission), sqlc.arg(status), sqlc.arg(invited_by),
+    CASE WHEN sqlc.arg(status) = 'active' THEN now() END
+) RETURNING *;
+
+-- name: ListAccountOwners :many
+SELECT * FROM account_owners
+WHERE account_id = $1
+ORDER BY created_at, owner;
+
+-- name: ApproveAccountOwner :one
+UPDATE account_owners
+SET status = 'active',
+    approved_at = now()
+WHERE account_id = $1
+  AND owner = $2
+  AND status = 'pending'
+RETURNING *;
+
+-- name: DeleteAccountOwner :one
+DELETE FROM account_owners
+WHERE account_id = $1
+  AND owner = $2
+RETURNING *;
@@ -1,9 +1,39 @@
 -- name: CreateEntry :one
+-- The counterparty name is snapshotted from the optional counterparty account
 INSERT INTO entry (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    description,
+    reference,
+    metadata,
+    counterparty_name
 ) VALUES (
-    $1, $2
+    $1, $2, $3, $4, $5, COALESCE(sqlc.narg(metadata)::jsonb, '{}'),
+    COALESCE((
+        SELECT COALESCE(users.full_name, account.owner) FROM account
+        LEFT JOIN users ON users.username = account.owner
+        WHERE account.account_id = sqlc.narg(counterparty_account_id)::bigint
+    ), '')
+) RETURNING *;
+
+-- name: CreateEntries :batchone
+-- Same as CreateEntry, but all the entries are sent to the database in a single round trip
+INSERT INTO entry (
+    account_id,
+    amount,
+    transfer_id,
+    description,
+    reference,
+    metadata,
+    counterparty_name
+) VALUES (
+    $1, $2, $3, $4, $5, COALESCE(sqlc.narg(metadata)::jsonb, '{}'),
+    COALESCE((
+        SELECT COALESCE(users.full_name, account.owner) FROM account
+        LEFT JOIN users ON users.username = account.owner
+        WHERE account.account_id = sqlc.narg(counterparty_account_id)::bigint
+    ), '')
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -16,6 +46,66 @@ ORDER BY entry_id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountEntries :many
+SELECT 
+    entry.entry_id,
+    entry.account_id,
+    entry.amount,
+    entry.transfer_id,
+    entry.created_at,
+    entry.description,
+    entry.reference,
+    entry.metadata,
+    transfer.from_account_id,
+    transfer.to_account_id
+FROM entry
+LEFT JOIN transfer ON transfer.transfer_id = entry.transfer_id
+WHERE entry.account_id = sqlc.arg(account_id)
+    AND entry.created_at >= sqlc.arg(from_time)
+    AND entry.created_at < sqlc.arg(to_time)
+    AND entry.entry_id > sqlc.arg(after_entry_id)
+ORDER BY entry.entry_id
+LIMIT sqlc.arg(page_size);
+
+-- name: SearchEntries :many
+-- Full-text search of the entries of the accounts the member owns or actively co-owns, best matches first. The
+-- query uses the web search syntax ("quoted phrases", or, -excluded). Matched terms are wrapped in \x02 and \x03 in
+-- the highlights, which are the whole texts
+SELECT
+    entry.entry_id,
+    entry.account_id,
+    entry.amount,
+    entry.transfer_id,
+    entry.created_at,
+    entry.description,
+    entry.reference,
+    entry.counterparty_name,
+    ts_rank(entry_search_vector(entry.description, entry.counterparty_name, entry.reference), query)::float4 AS rank,
+    ts_headline('simple', entry.description, query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::varchar AS description_highlight,
+    ts_headline('simple', entry.counterparty_name, query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::varchar AS counterparty_name_highlight,
+    ts_headline('simple', entry.reference, query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::varchar AS reference_highlight
+FROM entry, websearch_to_tsquery('simple', sqlc.arg(query)) AS query
+WHERE entry_search_vector(entry.description, entry.counterparty_name, entry.reference) @@ query
+  AND entry.account_id IN (
+    SELECT account.account_id FROM account WHERE account.owner = sqlc.arg(member)
+    UNION
+    SELECT account_owners.account_id FROM account_owners
+    WHERE account_owners.owner = sqlc.arg(member) AND account_owners.status = 'active'
+  )
+  AND (sqlc.narg(account_id)::bigint IS NULL OR entry.account_id = sqlc.narg(account_id))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(entry.amount) >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(entry.amount) <= sqlc.narg(max_amount))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR entry.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR entry.created_at < sqlc.narg(to_time))
+ORDER BY rank DESC, entry.entry_id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: SumAccountEntriesSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM entry
+WHERE account_id = sqlc.arg(account_id)
+    AND created_at >= sqlc.arg(since);
+
 -- name: UpdateEntry :one
 UPDATE entry
 SET amount = $2
@@ -25,4 +115,3 @@ RETURNING *;
 -- name: DeleteEntry :exec
 DELETE FROM entry 
 WHERE entry_id = $1;
-
@@ -0,0 +1,55 @@
+-- name: ListAccountsDueForInterest :many
+-- Savings and overdrawable accounts whose interest hasn't been accrued through the given day yet, or accrued through
+-- the end of a month but not posted. The accounts of the bank itself go negative by design and are never charged
+SELECT account.account_id FROM account
+WHERE (account_type = 'savings' OR overdraft_limit > 0 OR balance < 0)
+  AND account.account_id NOT IN (SELECT bank_account.account_id FROM bank_account)
+  AND (interest_accrued_through IS NULL
+    OR interest_accrued_through < sqlc.arg(through)::date
+    OR (interest_accrued_through = (date_trunc('month', interest_accrued_through) + interval '1 month - 1 day')::date
+      AND interest_posted_through IS DISTINCT FROM interest_accrued_through))
+  AND account.account_id > sqlc.arg(after_account_id)
+ORDER BY account.account_id
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateAccountInterestRate :one
+UPDATE account
+SET interest_rate_bps = $2,
+    version = version + 1
+WHERE account_id = $1
+RETURNING *;
+
+-- name: UpdateAccountOverdraft :one
+-- Accounts other than savings never accrued interest before, they start accruing from today instead of the day they
+-- were opened
+UPDATE account
+SET overdraft_limit = $2,
+    overdraft_rate_bps = $3,
+    overdraft_fee = $4,
+    interest_accrued_through = CASE
+      WHEN account_type = 'savings' THEN interest_accrued_through
+      ELSE COALESCE(interest_accrued_through, (now() AT TIME ZONE 'UTC')::date - 1)
+    END,
+    version = version + 1
+WHERE account_id = $1
+RETURNING *;
+
+-- name: AccrueAccountInterest :one
+UPDATE account
+SET accrued_interest = accrued_interest + sqlc.arg(amount),
+    interest_accrued_through = sqlc.arg(accrued_through)::date
+WHERE account_id = sqlc.arg(account_id)
+RETURNING *;
+
+-- name: PostAccountInterest :one
+-- Only the remainder that couldn't be posted is kept, it is carried over to the next month
+UPDATE account
+SET accrued_interest = sqlc.arg(remainder),
+    interest_posted_through = interest_accrued_through
+WHERE account_id = sqlc.arg(account_id)
+RETURNING *;
+
+-- name: GetBankAccount :one
+SELECT account_id FROM bank_account
+WHERE purpose = $1
+  AND currency = $2;
@@ -0,0 +1,44 @@
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preference (
+    owner,
+    email,
+    phone,
+    email_enabled,
+    sms_enabled,
+    low_balance_threshold,
+    large_debit_threshold,
+    threshold_currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) ON CONFLICT (owner) DO UPDATE
+SET email = EXCLUDED.email,
+    phone = EXCLUDED.phone,
+    email_enabled = EXCLUDED.email_enabled,
+    sms_enabled = EXCLUDED.sms_enabled,
+    low_balance_threshold = EXCLUDED.low_balance_threshold,
+    large_debit_threshold = EXCLUDED.large_debit_threshold,
+    threshold_currency = EXCLUDED.threshold_currency,
+    updated_at = now()
+RETURNING *;
+
+-- name: GetNotificationPreference :one
+SELECT * FROM notification_preference
+WHERE owner = $1;
+
+-- name: DeleteNotificationPreference :exec
+DELETE FROM notification_preference
+WHERE owner = $1;
+
+-- name: ListNotificationDeliveries :many
+SELECT * FROM notification_delivery
+WHERE transfer_id = $1;
+
+-- name: CreateNotificationDelivery :exec
+-- Delivering twice is harmless here, the row only has to exist
+INSERT INTO notification_delivery (
+    transfer_id,
+    kind,
+    channel
+) VALUES (
+    $1, $2, $3
+) ON CONFLICT DO NOTHING;
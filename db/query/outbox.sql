@@ -0,0 +1,53 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_event (
+    aggregate_type,
+    aggregate_id,
+    event_type,
+    payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT * FROM outbox_event
+WHERE event_id = $1;
+
+-- name: LockOutboxClaims :exec
+-- Serialize the claims until the end of the transaction, so two relays never take parts of the same aggregate
+SELECT pg_advisory_xact_lock(hashtext('outbox_event'));
+
+-- name: ClaimOutboxEvents :many
+-- Lease a batch of unpublished events, oldest first. The events of an aggregate with an older event leased by
+-- another relay are left out, so the events of an aggregate are never published out of order
+UPDATE outbox_event
+SET locked_until = sqlc.arg(locked_until)
+WHERE event_id IN (
+    SELECT event.event_id FROM outbox_event AS event
+    WHERE event.published_at IS NULL
+        AND (event.locked_until IS NULL OR event.locked_until <= now())
+        AND NOT EXISTS (
+            SELECT 1 FROM outbox_event AS older
+            WHERE older.aggregate_type = event.aggregate_type
+                AND older.aggregate_id = event.aggregate_id
+                AND older.event_id < event.event_id
+                AND older.published_at IS NULL
+                AND older.locked_until > now()
+        )
+    ORDER BY event.event_id
+    LIMIT sqlc.arg('limit')
+)
+RETURNING *;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_event
+SET published_at = now(),
+    locked_until = NULL
+WHERE event_id = $1;
+
+-- name: ReleaseOutboxEvents :exec
+-- Give back the events of a lease that weren't published, unless the lease already ended and another relay took them
+UPDATE outbox_event
+SET locked_until = NULL
+WHERE event_id = ANY(sqlc.arg(event_ids)::bigint[])
+    AND published_at IS NULL
+    AND locked_until = sqlc.arg(locked_until);
@@ -0,0 +1,47 @@
+-- name: CreatePayee :one
+-- Confirmation of payee: the account is looked up by its number and only saved when the given name matches the name
+-- of its owner, ignoring case and surrounding spaces. That name, snapshotted as owner_name, is the full name of the
+-- owner when they are a user, the owner itself otherwise. No row is returned when the account doesn't exist or the
+-- name doesn't match, so the two can't be told apart. The accounts of the bank itself can't be saved
+INSERT INTO payees (
+    owner,
+    nickname,
+    account_id,
+    owner_name
+)
+SELECT sqlc.arg(owner)::varchar, sqlc.arg(nickname)::varchar, account.account_id, COALESCE(users.full_name, account.owner)
+FROM account
+LEFT JOIN users ON users.username = account.owner
+WHERE account.account_number = sqlc.arg(account_number)
+  AND lower(trim(COALESCE(users.full_name, account.owner))) = lower(trim(sqlc.arg(owner_name)::varchar))
+  AND account.account_id NOT IN (SELECT account_id FROM bank_account)
+RETURNING *;
+
+-- name: GetPayee :one
+SELECT * FROM payees
+WHERE payee_id = $1
+  AND owner = $2;
+
+-- name: GetPayeeByAccount :one
+SELECT * FROM payees
+WHERE owner = $1
+  AND account_id = $2;
+
+-- name: ListPayees :many
+SELECT * FROM payees
+WHERE owner = $1
+ORDER BY nickname, payee_id;
+
+-- name: UpdatePayeeNickname :one
+-- Only the nickname can change: pointing a payee to another account would skip the cooling-off period
+UPDATE payees
+SET nickname = $3
+WHERE payee_id = $1
+  AND owner = $2
+RETURNING *;
+
+-- name: DeletePayee :one
+DELETE FROM payees
+WHERE payee_id = $1
+  AND owner = $2
+RETURNING *;
@@ -0,0 +1,81 @@
+-- name: CreateTask :one
+INSERT INTO task (
+    type,
+    payload,
+    max_attempts,
+    run_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetTask :one
+SELECT * FROM task
+WHERE task_id = $1;
+
+-- name: ClaimTasks :many
+WITH due AS (
+    SELECT task_id FROM task
+    WHERE (status = 'pending' AND run_at <= now())
+        OR (status = 'running' AND locked_until < now())
+    ORDER BY run_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE task
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_until = sqlc.arg(locked_until),
+    updated_at = now()
+FROM due
+WHERE task.task_id = due.task_id
+RETURNING task.*;
+
+-- name: CompleteTask :execrows
+-- Completing, retrying and killing a task only apply while the lease it was claimed with is still held. Once it
+-- expires, another worker may have claimed the task again, and no row is updated
+UPDATE task
+SET status = 'completed',
+    locked_until = NULL,
+    last_error = NULL,
+    updated_at = now()
+WHERE task_id = sqlc.arg(task_id)
+  AND status = 'running'
+  AND locked_until = sqlc.arg(locked_until);
+
+-- name: RetryTask :execrows
+UPDATE task
+SET status = 'pending',
+    run_at = sqlc.arg(run_at),
+    locked_until = NULL,
+    last_error = sqlc.arg(last_error),
+    updated_at = now()
+WHERE task_id = sqlc.arg(task_id)
+  AND status = 'running'
+  AND locked_until = sqlc.arg(locked_until);
+
+-- name: KillTask :execrows
+UPDATE task
+SET status = 'dead',
+    locked_until = NULL,
+    last_error = sqlc.arg(last_error),
+    updated_at = now()
+WHERE task_id = sqlc.arg(task_id)
+  AND status = 'running'
+  AND locked_until = sqlc.arg(locked_until);
+
+-- name: ListDeadTasks :many
+SELECT * FROM task
+WHERE status = 'dead'
+ORDER BY task_id
+LIMIT $1
+OFFSET $2;
+
+-- name: RequeueDeadTask :one
+UPDATE task
+SET status = 'pending',
+    attempts = 0,
+    run_at = now(),
+    updated_at = now()
+WHERE task_id = $1
+    AND status = 'dead'
+RETURNING *;
@@ -2,9 +2,12 @@
 INSERT INTO transfer (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    description,
+    reference,
+    metadata
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, COALESCE(sqlc.narg(metadata)::jsonb, '{}')
 ) RETURNING *;
 
 -- name: GetTransaction :one
@@ -17,6 +20,22 @@ ORDER BY transfer_id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListTransfersByReference :many
+-- Transfers with the given end-to-end reference from or to an account the member owns or actively co-owns
+SELECT transfer.* FROM transfer
+WHERE transfer.reference = sqlc.arg(reference)
+  AND EXISTS (
+    SELECT 1 FROM account
+    LEFT JOIN account_owners ON account_owners.account_id = account.account_id
+      AND account_owners.owner = sqlc.arg(member)
+      AND account_owners.status = 'active'
+    WHERE account.account_id IN (transfer.from_account_id, transfer.to_account_id)
+      AND (account.owner = sqlc.arg(member) OR account_owners.owner IS NOT NULL)
+  )
+ORDER BY transfer.transfer_id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
 -- name: UpdateTransaction :one
 UPDATE transfer
 SET amount = $2
@@ -27,3 +46,20 @@ RETURNING *;
 DELETE FROM transfer 
 WHERE transfer_id = $1;
 
+-- name: ListUnbalancedTransfers :many
+-- A transfer is balanced when it has exactly one debit entry on the from account and one credit entry on the
+-- to account, both for the transfer amount
+SELECT t.transfer_id,
+       t.from_account_id,
+       t.to_account_id,
+       t.amount,
+       count(e.entry_id) AS entry_count,
+       COALESCE(sum(e.amount), 0)::bigint AS entry_sum
+FROM transfer t
+LEFT JOIN entry e ON e.transfer_id = t.transfer_id
+WHERE t.created_at >= sqlc.arg(since)::timestamptz
+GROUP BY t.transfer_id
+HAVING count(e.entry_id) <> 2
+    OR count(e.entry_id) FILTER (WHERE e.account_id = t.from_account_id AND e.amount = -t.amount) <> 1
+    OR count(e.entry_id) FILTER (WHERE e.account_id = t.to_account_id AND e.amount = t.amount) <> 1
+ORDER BY t.transfer_id;
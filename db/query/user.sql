@@ -0,0 +1,22 @@
+-- name: CreateUser :one
+INSERT INTO users (
+    username,
+    full_name,
+    email
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: CopyUsers :copyfrom
+-- Bulk insert with COPY, used to seed databases
+INSERT INTO users (
+    username,
+    full_name,
+    email
+) VALUES (
+    $1, $2, $3
+);
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE username = $1;
@@ -0,0 +1,107 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscription (
+    owner,
+    url,
+    secret,
+    event_types
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscription
+WHERE subscription_id = $1
+    AND owner = $2;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscription
+WHERE owner = $1
+ORDER BY subscription_id;
+
+-- name: ListWebhookSubscriptionsForEvent :many
+SELECT * FROM webhook_subscription
+WHERE owner = sqlc.arg(owner)
+    AND sqlc.arg(event_type)::varchar = ANY(event_types)
+ORDER BY subscription_id;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscription
+WHERE subscription_id = $1
+    AND owner = $2;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_delivery (
+    subscription_id,
+    event_id,
+    event_type,
+    payload
+) VALUES (
+    $1, $2, $3, $4
+) ON CONFLICT (subscription_id, event_id, event_type) DO NOTHING;
+
+-- name: GetWebhookDelivery :one
+SELECT webhook_delivery.* FROM webhook_delivery
+JOIN webhook_subscription ON webhook_subscription.subscription_id = webhook_delivery.subscription_id
+WHERE webhook_delivery.delivery_id = sqlc.arg(delivery_id)
+    AND webhook_delivery.subscription_id = sqlc.arg(subscription_id)
+    AND webhook_subscription.owner = sqlc.arg(owner);
+
+-- name: ListWebhookDeliveries :many
+SELECT webhook_delivery.* FROM webhook_delivery
+JOIN webhook_subscription ON webhook_subscription.subscription_id = webhook_delivery.subscription_id
+WHERE webhook_delivery.subscription_id = sqlc.arg(subscription_id)
+    AND webhook_subscription.owner = sqlc.arg(owner)
+ORDER BY webhook_delivery.delivery_id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Lease a batch of due deliveries by moving their next attempt to leased_until: other workers skip them until then,
+-- and they are picked up again if the worker dies before recording the outcome
+UPDATE webhook_delivery
+SET next_attempt_at = sqlc.arg(leased_until)
+FROM webhook_subscription
+WHERE webhook_subscription.subscription_id = webhook_delivery.subscription_id
+    AND webhook_delivery.delivery_id IN (
+        SELECT delivery_id FROM webhook_delivery
+        WHERE status = 'pending'
+            AND next_attempt_at <= now()
+        ORDER BY next_attempt_at
+        LIMIT sqlc.arg('limit')
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING
+    webhook_delivery.*,
+    webhook_subscription.url,
+    webhook_subscription.secret;
+
+-- name: UpdateWebhookDeliveryAttempt :execrows
+-- Only applies while the lease the delivery was claimed with is still held. Once it expires, another worker may have
+-- claimed the delivery again, and no row is updated
+UPDATE webhook_delivery
+SET status = sqlc.arg(status),
+    attempts = attempts + 1,
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_status_code = sqlc.arg(last_status_code),
+    last_error = sqlc.arg(last_error),
+    delivered_at = sqlc.arg(delivered_at)
+WHERE delivery_id = sqlc.arg(delivery_id)
+    AND status = 'pending'
+    AND next_attempt_at = sqlc.arg(leased_until);
+
+-- name: ReleaseWebhookDeliveries :exec
+-- Give back leased deliveries that weren't attempted, e.g. on shutdown, without counting an attempt
+UPDATE webhook_delivery
+SET next_attempt_at = now()
+WHERE delivery_id = ANY(sqlc.arg(delivery_ids)::bigint[])
+    AND status = 'pending'
+    AND next_attempt_at = sqlc.arg(leased_until);
+
+-- name: ReplayWebhookDelivery :one
+UPDATE webhook_delivery
+SET status = 'pending',
+    next_attempt_at = now()
+WHERE delivery_id = $1
+    AND subscription_id = $2
+    AND status = 'failed'
+RETURNING *;
@@ -7,13 +7,16 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE account
-SET balance = balance + $1
+SET balance = balance + $1,
+    version = version + 1
 WHERE account_id = $2
-RETURNING account_id, owner, balance, currency, created_at
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
 `
 
 type AddAccountBalanceParams struct {
@@ -22,7 +25,7 @@ type AddAccountBalanceParams struct {
 }
 
 func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, addAccountBalance, arg.Amount, arg.ID)
+	row := q.db.QueryRow(ctx, addAccountBalance, arg.Amount, arg.ID)
 	var i Account
 	err := row.Scan(
 		&i.AccountID,
@@ -30,28 +33,61 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
 	)
 	return i, err
 }
 
+type CopyAccountsParams struct {
+	Owner       string `json:"owner"`
+	Balance     int64  `json:"balance"`
+	Currency    string `json:"currency"`
+	AccountType string `json:"account_type"`
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO account (
     owner, 
     balance, 
-    currency
+    currency,
+    account_type,
+    nickname,
+    labels
 ) VALUES (
-    $1, $2, $3
-) RETURNING account_id, owner, balance, currency, created_at
+    $1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}')
+) RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner       string          `json:"owner"`
+	Balance     int64           `json:"balance"`
+	Currency    string          `json:"currency"`
+	AccountType string          `json:"account_type"`
+	Nickname    string          `json:"nickname"`
+	Labels      json.RawMessage `json:"labels"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRow(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.AccountType,
+		arg.Nickname,
+		arg.Labels,
+	)
 	var i Account
 	err := row.Scan(
 		&i.AccountID,
@@ -59,6 +95,19 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
 	)
 	return i, err
 }
@@ -69,17 +118,17 @@ WHERE account_id = $1
 `
 
 func (q *Queries) DeleteAccount(ctx context.Context, accountID int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, accountID)
+	_, err := q.db.Exec(ctx, deleteAccount, accountID)
 	return err
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT account_id, owner, balance, currency, created_at FROM account
+SELECT account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee FROM account
 WHERE account_id = $1
 `
 
 func (q *Queries) GetAccount(ctx context.Context, accountID int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, accountID)
+	row := q.db.QueryRow(ctx, getAccount, accountID)
 	var i Account
 	err := row.Scan(
 		&i.AccountID,
@@ -87,18 +136,62 @@ func (q *Queries) GetAccount(ctx context.Context, accountID int64) (Account, err
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee FROM account
+WHERE account_number = $1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountByNumber, accountNumber)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT account_id, owner, balance, currency, created_at FROM account
+SELECT account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee FROM account
 WHERE account_id = $1
 FOR NO KEY UPDATE
 `
 
 func (q *Queries) GetAccountForUpdate(ctx context.Context, accountID int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccountForUpdate, accountID)
+	row := q.db.QueryRow(ctx, getAccountForUpdate, accountID)
 	var i Account
 	err := row.Scan(
 		&i.AccountID,
@@ -106,24 +199,62 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, accountID int64) (Acc
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
 	)
 	return i, err
 }
 
 const listAccount = `-- name: ListAccount :many
-SELECT account_id, owner, balance, currency, created_at FROM account
+SELECT account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee FROM account
+WHERE ($1::varchar IS NULL OR owner = $1 OR EXISTS (
+    SELECT 1 FROM account_owners
+    WHERE account_owners.account_id = account.account_id
+      AND account_owners.owner = $1
+      AND account_owners.status = 'active'
+  ))
+  AND ($2::varchar IS NULL OR account_type = $2)
+  AND ($3::varchar IS NULL OR currency = $3)
+  AND ($4::jsonb IS NULL OR labels @> $4)
+  AND ($5::text[] IS NULL OR labels ?& $5)
 ORDER BY account_id
-LIMIT $1
-OFFSET $2
+LIMIT $7
+OFFSET $6
 `
 
 type ListAccountParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Member      sql.NullString  `json:"member"`
+	AccountType sql.NullString  `json:"account_type"`
+	Currency    sql.NullString  `json:"currency"`
+	Labels      json.RawMessage `json:"labels"`
+	LabelKeys   []string        `json:"label_keys"`
+	Offset      int32           `json:"offset"`
+	Limit       int32           `json:"limit"`
 }
 
+// Every filter is optional. The accounts must hold every given label (key and value) and every given label key, and
+// be owned or co-owned (actively) by the given member
 func (q *Queries) ListAccount(ctx context.Context, arg ListAccountParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccount, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listAccount,
+		arg.Member,
+		arg.AccountType,
+		arg.Currency,
+		arg.Labels,
+		arg.LabelKeys,
+		arg.Offset,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -137,14 +268,24 @@ func (q *Queries) ListAccount(ctx context.Context, arg ListAccountParams) ([]Acc
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Status,
+			&i.Version,
+			&i.AccountType,
+			&i.Nickname,
+			&i.Labels,
+			&i.InterestRateBps,
+			&i.AccruedInterest,
+			&i.InterestAccruedThrough,
+			&i.InterestPostedThrough,
+			&i.OverdraftLimit,
+			&i.OverdraftRateBps,
+			&i.AccountNumber,
+			&i.OverdraftFee,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
@@ -153,18 +294,113 @@ func (q *Queries) ListAccount(ctx context.Context, arg ListAccountParams) ([]Acc
 
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE account
-SET balance = $2
+SET balance = $2,
+    version = version + 1
 WHERE account_id = $1
-RETURNING account_id, owner, balance, currency, created_at
+  AND version = $3
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
 `
 
 type UpdateAccountParams struct {
 	AccountID int64 `json:"account_id"`
 	Balance   int64 `json:"balance"`
+	Version   int64 `json:"version"`
 }
 
+// Only updates the account if it is still at the expected version, otherwise no row is returned
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, updateAccount, arg.AccountID, arg.Balance)
+	row := q.db.QueryRow(ctx, updateAccount, arg.AccountID, arg.Balance, arg.Version)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
+	)
+	return i, err
+}
+
+const updateAccountDetails = `-- name: UpdateAccountDetails :one
+UPDATE account
+SET account_type = COALESCE($1, account_type),
+    nickname = COALESCE($2, nickname),
+    labels = COALESCE($3, labels),
+    version = version + 1
+WHERE account_id = $4
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
+`
+
+type UpdateAccountDetailsParams struct {
+	AccountType sql.NullString  `json:"account_type"`
+	Nickname    sql.NullString  `json:"nickname"`
+	Labels      json.RawMessage `json:"labels"`
+	AccountID   int64           `json:"account_id"`
+}
+
+// Only the given fields are changed
+func (q *Queries) UpdateAccountDetails(ctx context.Context, arg UpdateAccountDetailsParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountDetails,
+		arg.AccountType,
+		arg.Nickname,
+		arg.Labels,
+		arg.AccountID,
+	)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE account
+SET status = $2,
+    version = version + 1
+WHERE account_id = $1
+  AND version = $3
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
+`
+
+type UpdateAccountStatusParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+	Version   int64  `json:"version"`
+}
+
+// Only updates the account if it is still at the expected version, otherwise no row is returned
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountStatus, arg.AccountID, arg.Status, arg.Version)
 	var i Account
 	err := row.Scan(
 		&i.AccountID,
@@ -172,6 +408,19 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
 	)
 	return i, err
 }
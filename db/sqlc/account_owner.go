@@ -0,0 +1,27 @@
+package db
+
+// Permissions of the co-owners of an account, each one including the ones before it. The primary owner, the owner
+// column of the account, has them all
+const (
+	AccountPermissionView     = "view"     // See the account and its statements
+	AccountPermissionTransact = "transact" // Withdraw and transfer money
+	AccountPermissionManage   = "manage"   // Update the account and invite co-owners
+)
+
+// Statuses of a co-owner. Only active co-owners can use their permission
+const (
+	AccountOwnerStatusPending = "pending"
+	AccountOwnerStatusActive  = "active"
+)
+
+var permissionRanks = map[string]int{
+	AccountPermissionView:     1,
+	AccountPermissionTransact: 2,
+	AccountPermissionManage:   3,
+}
+
+// Check whether a granted permission allows an action requiring another one. Unknown permissions allow nothing
+func HasPermission(granted, required string) bool {
+	rank, ok := permissionRanks[granted]
+	return ok && rank >= permissionRanks[required]
+}
@@ -0,0 +1,172 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: account_owner.sql
+
+package db
+
+import (
+	"context"
+)
+
+const approveAccountOwner = `-- name: ApproveAccountOwner :one
+UPDATE account_owners
+SET status = 'active',
+    approved_at = now()
+WHERE account_id = $1
+  AND owner = $2
+  AND status = 'pending'
+RETURNING account_id, owner, permission, status, invited_by, created_at, approved_at
+`
+
+type ApproveAccountOwnerParams struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+}
+
+func (q *Queries) ApproveAccountOwner(ctx context.Context, arg ApproveAccountOwnerParams) (AccountOwner, error) {
+	row := q.db.QueryRow(ctx, approveAccountOwner, arg.AccountID, arg.Owner)
+	var i AccountOwner
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Permission,
+		&i.Status,
+		&i.InvitedBy,
+		&i.CreatedAt,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const createAccountOwner = `-- name: CreateAccountOwner :one
+INSERT INTO account_owners (
+    account_id,
+    owner,
+    permission,
+    status,
+    invited_by,
+    approved_at
+) VALUES (
+    $1, $2, $3, $4, $5,
+    CASE WHEN $4 = 'active' THEN now() END
+) RETURNING account_id, owner, permission, status, invited_by, created_at, approved_at
+`
+
+type CreateAccountOwnerParams struct {
+	AccountID  int64  `json:"account_id"`
+	Owner      string `json:"owner"`
+	Permission string `json:"permission"`
+	Status     string `json:"status"`
+	InvitedBy  string `json:"invited_by"`
+}
+
+func (q *Queries) CreateAccountOwner(ctx context.Context, arg CreateAccountOwnerParams) (AccountOwner, error) {
+	row := q.db.QueryRow(ctx, createAccountOwner,
+		arg.AccountID,
+		arg.Owner,
+		arg.Permission,
+		arg.Status,
+		arg.InvitedBy,
+	)
+	var i AccountOwner
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Permission,
+		&i.Status,
+		&i.InvitedBy,
+		&i.CreatedAt,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const deleteAccountOwner = `-- name: DeleteAccountOwner :one
+DELETE FROM account_owners
+WHERE account_id = $1
+  AND owner = $2
+RETURNING account_id, owner, permission, status, invited_by, created_at, approved_at
+`
+
+type DeleteAccountOwnerParams struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+}
+
+func (q *Queries) DeleteAccountOwner(ctx context.Context, arg DeleteAccountOwnerParams) (AccountOwner, error) {
+	row := q.db.QueryRow(ctx, deleteAccountOwner, arg.AccountID, arg.Owner)
+	var i AccountOwner
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Permission,
+		&i.Status,
+		&i.InvitedBy,
+		&i.CreatedAt,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const getAccountPermission = `-- name: GetAccountPermission :one
+SELECT 'manage'::varchar AS permission FROM account
+WHERE account.account_id = $1 AND account.owner = $2
+  AND account.account_id NOT IN (SELECT account_id FROM bank_account)
+UNION ALL
+SELECT account_owners.permission FROM account_owners
+WHERE account_owners.account_id = $1
+  AND account_owners.owner = $2
+  AND account_owners.status = 'active'
+  AND account_owners.account_id NOT IN (SELECT account_id FROM bank_account)
+LIMIT 1
+`
+
+type GetAccountPermissionParams struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+}
+
+// The permission of a user on an account: manage for the primary owner, the permission of an active co-owner
+// otherwise. No row is returned when the user can't access the account. Nobody has access to the accounts of the
+// bank itself, they are only moved by the bank's own transactions
+func (q *Queries) GetAccountPermission(ctx context.Context, arg GetAccountPermissionParams) (string, error) {
+	row := q.db.QueryRow(ctx, getAccountPermission, arg.AccountID, arg.Owner)
+	var permission string
+	err := row.Scan(&permission)
+	return permission, err
+}
+
+const listAccountOwners = `-- name: ListAccountOwners :many
+SELECT account_id, owner, permission, status, invited_by, created_at, approved_at FROM account_owners
+WHERE account_id = $1
+ORDER BY created_at, owner
+`
+
+func (q *Queries) ListAccountOwners(ctx context.Context, accountID int64) ([]AccountOwner, error) {
+	rows, err := q.db.Query(ctx, listAccountOwners, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountOwner{}
+	for rows.Next() {
+		var i AccountOwner
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Owner,
+			&i.Permission,
+			&i.Status,
+			&i.InvitedBy,
+			&i.CreatedAt,
+			&i.ApprovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
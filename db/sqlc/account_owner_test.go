@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"gobank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasPermission(t *testing.T) {
+	require.True(t, HasPermission(AccountPermissionManage, AccountPermissionTransact))
+	require.True(t, HasPermission(AccountPermissionTransact, AccountPermissionTransact))
+	require.True(t, HasPermission(AccountPermissionTransact, AccountPermissionView))
+	require.False(t, HasPermission(AccountPermissionView, AccountPermissionTransact))
+	require.False(t, HasPermission(AccountPermissionTransact, AccountPermissionManage))
+	require.False(t, HasPermission("", AccountPermissionView))
+}
+
+func TestAccountPermission(t *testing.T) {
+	account := createAccountMock(t)
+	coOwner := createUserMock(t)
+	stranger := createUserMock(t)
+
+	// The primary owner manages the account without a co-owner row
+	permission, err := testQueries.GetAccountPermission(context.Background(), GetAccountPermissionParams{
+		AccountID: account.AccountID,
+		Owner:     account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountPermissionManage, permission)
+
+	_, err = testQueries.GetAccountPermission(context.Background(), GetAccountPermissionParams{
+		AccountID: account.AccountID,
+		Owner:     stranger.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// A pending co-owner has no access until approved
+	owner, err := testQueries.CreateAccountOwner(context.Background(), CreateAccountOwnerParams{
+		AccountID:  account.AccountID,
+		Owner:      coOwner.Username,
+		Permission: AccountPermissionTransact,
+		Status:     AccountOwnerStatusPending,
+		InvitedBy:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.False(t, owner.ApprovedAt.Valid)
+
+	_, err = testQueries.GetAccountPermission(context.Background(), GetAccountPermissionParams{
+		AccountID: account.AccountID,
+		Owner:     coOwner.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	listed, err := testQueries.ListAccount(context.Background(), ListAccountParams{
+		Member: sql.NullString{String: coOwner.Username, Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, listed)
+
+	owner, err = testQueries.ApproveAccountOwner(context.Background(), ApproveAccountOwnerParams{
+		AccountID: account.AccountID,
+		Owner:     coOwner.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountOwnerStatusActive, owner.Status)
+	require.True(t, owner.ApprovedAt.Valid)
+
+	permission, err = testQueries.GetAccountPermission(context.Background(), GetAccountPermissionParams{
+		AccountID: account.AccountID,
+		Owner:     coOwner.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountPermissionTransact, permission)
+
+	// Joint accounts are listed for their co-owners
+	listed, err = testQueries.ListAccount(context.Background(), ListAccountParams{
+		Member: sql.NullString{String: coOwner.Username, Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, account.AccountID, listed[0].AccountID)
+
+	// Approving twice finds no pending invitation
+	_, err = testQueries.ApproveAccountOwner(context.Background(), ApproveAccountOwnerParams{
+		AccountID: account.AccountID,
+		Owner:     coOwner.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// The same user can't be invited twice
+	_, err = testQueries.CreateAccountOwner(context.Background(), CreateAccountOwnerParams{
+		AccountID:  account.AccountID,
+		Owner:      coOwner.Username,
+		Permission: AccountPermissionView,
+		Status:     AccountOwnerStatusActive,
+		InvitedBy:  account.Owner,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// Co-owners must be users
+	_, err = testQueries.CreateAccountOwner(context.Background(), CreateAccountOwnerParams{
+		AccountID:  account.AccountID,
+		Owner:      "no-such-user",
+		Permission: AccountPermissionView,
+		Status:     AccountOwnerStatusActive,
+		InvitedBy:  account.Owner,
+	})
+	require.Equal(t, ForeignKeyViolation, ErrorCode(err))
+
+	_, err = testQueries.DeleteAccountOwner(context.Background(), DeleteAccountOwnerParams{
+		AccountID: account.AccountID,
+		Owner:     coOwner.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetAccountPermission(context.Background(), GetAccountPermissionParams{
+		AccountID: account.AccountID,
+		Owner:     coOwner.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestBankAccountPermission(t *testing.T) {
+	clearing := getCashClearingMock(t, util.USD)
+	require.Equal(t, BankOwner, clearing.Owner)
+
+	// The bank's own accounts can't be reached through the owner name
+	_, err := testQueries.GetAccountPermission(context.Background(), GetAccountPermissionParams{
+		AccountID: clearing.AccountID,
+		Owner:     BankOwner,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// Nor can a user register under it
+	_, err = testQueries.CreateUser(context.Background(), CreateUserParams{
+		Username: BankOwner,
+		FullName: util.RandomString(6),
+		Email:    util.RandomString(8) + "@example.com",
+	})
+	require.Error(t, err)
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"gobank/util"
 	"testing"
 	"time"
@@ -22,9 +23,10 @@ func createAccountMock(t *testing.T) Account {
 
 	// Create test data
 	arg := CreateAccountParams{
-		Owner:    util.RandomString(7),
-		Balance:  util.RandomInt(1, 10000),
-		Currency: currencies[util.RandomInt(0, int64(len(currencies)-1))],
+		Owner:       util.RandomString(7),
+		Balance:     util.RandomInt(1, 10000),
+		Currency:    currencies[util.RandomInt(0, int64(len(currencies)-1))],
+		AccountType: AccountTypeChecking,
 	}
 
 	// Run the function in test
@@ -66,6 +68,30 @@ func TestGetAccount(t *testing.T) {
 	require.WithinDuration(t, mock.CreatedAt.Time, account.CreatedAt.Time, time.Second)
 }
 
+func TestGetAccountByNumber(t *testing.T) {
+	mock := createAccountMock(t)
+
+	// Every account gets a number with valid check digits
+	number, ok := util.NormalizeAccountNumber(mock.AccountNumber)
+	require.True(t, ok)
+	require.Equal(t, mock.AccountNumber, number)
+
+	account, err := testQueries.GetAccountByNumber(context.Background(), mock.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, mock.AccountID, account.AccountID)
+
+	// Numbers are unique
+	other := createAccountMock(t)
+	require.NotEqual(t, mock.AccountNumber, other.AccountNumber)
+	_, err = conn.Exec(context.Background(), "UPDATE account SET account_number = $2 WHERE account_id = $1",
+		other.AccountID, mock.AccountNumber)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+	require.Equal(t, "account_account_number_key", ErrorConstraint(err))
+
+	_, err = testQueries.GetAccountByNumber(context.Background(), "0000000000000195")
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
 func TestUpdateAccount(t *testing.T) {
 	// Create mock account
 	mock := createAccountMock(t)
@@ -74,6 +100,7 @@ func TestUpdateAccount(t *testing.T) {
 	arg := UpdateAccountParams{
 		AccountID: mock.AccountID,
 		Balance:   util.RandomInt(1, 10000),
+		Version:   mock.Version,
 	}
 
 	// Test update account
@@ -86,6 +113,22 @@ func TestUpdateAccount(t *testing.T) {
 	require.Equal(t, arg.Balance, account.Balance) // Here, the expected value should be arg.Balance
 	require.Equal(t, mock.Currency, account.Currency)
 	require.WithinDuration(t, mock.CreatedAt.Time, account.CreatedAt.Time, time.Second)
+	require.Equal(t, mock.Version+1, account.Version)
+
+	// The version read before the update is now stale, so updating with it doesn't match any row
+	_, err = testQueries.UpdateAccount(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestAddAccountBalanceBumpsVersion(t *testing.T) {
+	mock := createAccountMock(t)
+
+	account, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     mock.AccountID,
+		Amount: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, mock.Version+1, account.Version)
 }
 
 func TestDeleteAccount(t *testing.T) {
@@ -96,10 +139,10 @@ func TestDeleteAccount(t *testing.T) {
 	err := testQueries.DeleteAccount(context.Background(), mock.AccountID)
 	require.NoError(t, err)
 
-	// Try getting the mock account, if fail (err == sql.ErrNoRows and account is empty), we successfully delete
+	// Try getting the mock account, if fail (err is ErrRecordNotFound and account is empty), we successfully delete
 	account, err := testQueries.GetAccount(context.Background(), mock.AccountID)
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err, ErrRecordNotFound)
 	require.Empty(t, account)
 }
 
@@ -122,3 +165,91 @@ func TestListAccount(t *testing.T) {
 		require.NotEmpty(t, account)
 	}
 }
+
+func TestListAccountFilters(t *testing.T) {
+	// A label unique to this test run, so accounts created by other tests don't match
+	team := util.RandomString(10)
+
+	savings, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Currency:    util.EUR,
+		AccountType: AccountTypeSavings,
+		Nickname:    "Holidays",
+		Labels:      json.RawMessage(`{"team": "` + team + `", "purpose": "holidays"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Holidays", savings.Nickname)
+
+	checking, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+		Labels:      json.RawMessage(`{"team": "` + team + `"}`),
+	})
+	require.NoError(t, err)
+
+	list := func(arg ListAccountParams) []int64 {
+		arg.Limit = 10
+		arg.Labels = json.RawMessage(`{"team": "` + team + `"}`)
+		accounts, err := testQueries.ListAccount(context.Background(), arg)
+		require.NoError(t, err)
+
+		ids := make([]int64, len(accounts))
+		for i, account := range accounts {
+			ids[i] = account.AccountID
+		}
+		return ids
+	}
+
+	require.Equal(t, []int64{savings.AccountID, checking.AccountID}, list(ListAccountParams{}))
+	require.Equal(t, []int64{savings.AccountID}, list(ListAccountParams{
+		AccountType: sql.NullString{String: AccountTypeSavings, Valid: true},
+	}))
+	require.Equal(t, []int64{checking.AccountID}, list(ListAccountParams{
+		Currency: sql.NullString{String: util.USD, Valid: true},
+	}))
+	require.Equal(t, []int64{savings.AccountID}, list(ListAccountParams{LabelKeys: []string{"purpose"}}))
+	require.Empty(t, list(ListAccountParams{LabelKeys: []string{"unknown"}}))
+}
+
+func TestUpdateAccountDetailsTx(t *testing.T) {
+	store := NewStore(conn)
+	mock := createAccountMock(t)
+	require.Equal(t, AccountTypeChecking, mock.AccountType)
+	require.JSONEq(t, `{}`, string(mock.Labels))
+
+	// Only the given fields change
+	account, err := store.UpdateAccountDetailsTx(context.Background(), UpdateAccountDetailsTxParams{
+		UpdateAccountDetailsParams: UpdateAccountDetailsParams{
+			AccountID: mock.AccountID,
+			Nickname:  sql.NullString{String: "Rent", Valid: true},
+			Labels:    json.RawMessage(`{"purpose": "rent"}`),
+		},
+		ExpectedVersion: mock.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Rent", account.Nickname)
+	require.JSONEq(t, `{"purpose": "rent"}`, string(account.Labels))
+	require.Equal(t, AccountTypeChecking, account.AccountType)
+	require.Equal(t, mock.Balance, account.Balance)
+	require.Equal(t, mock.Version+1, account.Version)
+
+	// A stale version is rejected
+	_, err = store.UpdateAccountDetailsTx(context.Background(), UpdateAccountDetailsTxParams{
+		UpdateAccountDetailsParams: UpdateAccountDetailsParams{
+			AccountID:   mock.AccountID,
+			AccountType: sql.NullString{String: AccountTypeBusiness, Valid: true},
+		},
+		ExpectedVersion: mock.Version,
+	})
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	// An unknown account is not found
+	_, err = store.UpdateAccountDetailsTx(context.Background(), UpdateAccountDetailsTxParams{
+		UpdateAccountDetailsParams: UpdateAccountDetailsParams{
+			AccountID: -1,
+			Nickname:  sql.NullString{String: "Rent", Valid: true},
+		},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,106 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: batch.go
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrBatchAlreadyClosed = errors.New("batch already closed")
+)
+
+const createEntries = `-- name: CreateEntries :batchone
+INSERT INTO entry (
+    account_id,
+    amount,
+    transfer_id,
+    description,
+    reference,
+    metadata,
+    counterparty_name
+) VALUES (
+    $1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}'),
+    COALESCE((
+        SELECT COALESCE(users.full_name, account.owner) FROM account
+        LEFT JOIN users ON users.username = account.owner
+        WHERE account.account_id = $7::bigint
+    ), '')
+) RETURNING entry_id, account_id, amount, created_at, transfer_id, description, reference, metadata, counterparty_name
+`
+
+type CreateEntriesBatchResults struct {
+	br     pgx.BatchResults
+	tot    int
+	closed bool
+}
+
+type CreateEntriesParams struct {
+	AccountID             int64           `json:"account_id"`
+	Amount                int64           `json:"amount"`
+	TransferID            sql.NullInt64   `json:"transfer_id"`
+	Description           string          `json:"description"`
+	Reference             string          `json:"reference"`
+	Metadata              json.RawMessage `json:"metadata"`
+	CounterpartyAccountID sql.NullInt64   `json:"counterparty_account_id"`
+}
+
+// Same as CreateEntry, but all the entries are sent to the database in a single round trip
+func (q *Queries) CreateEntries(ctx context.Context, arg []CreateEntriesParams) *CreateEntriesBatchResults {
+	batch := &pgx.Batch{}
+	for _, a := range arg {
+		vals := []interface{}{
+			a.AccountID,
+			a.Amount,
+			a.TransferID,
+			a.Description,
+			a.Reference,
+			a.Metadata,
+			a.CounterpartyAccountID,
+		}
+		batch.Queue(createEntries, vals...)
+	}
+	br := q.db.SendBatch(ctx, batch)
+	return &CreateEntriesBatchResults{br, len(arg), false}
+}
+
+func (b *CreateEntriesBatchResults) QueryRow(f func(int, Entry, error)) {
+	defer b.br.Close()
+	for t := 0; t < b.tot; t++ {
+		var i Entry
+		if b.closed {
+			if f != nil {
+				f(t, i, ErrBatchAlreadyClosed)
+			}
+			continue
+		}
+		row := b.br.QueryRow()
+		err := row.Scan(
+			&i.EntryID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.CounterpartyName,
+		)
+		if f != nil {
+			f(t, i, err)
+		}
+	}
+}
+
+func (b *CreateEntriesBatchResults) Close() error {
+	b.closed = true
+	return b.br.Close()
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"gobank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Benchmarks comparing one round trip per row, as the data layer did before pgx, with the batch and COPY
+// statements used now. Run with: go test ./db/sqlc -run '^$' -bench .
+
+// Helper method: create an account to attach the benchmark entries to
+func createBenchAccount(b *testing.B) Account {
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Balance:     0,
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(b, err)
+	return account
+}
+
+func BenchmarkTransferEntries(b *testing.B) {
+	// The entries are not linked to a transfer, so the reconciliation doesn't report them as unbalanced
+	from, to := createBenchAccount(b), createBenchAccount(b)
+	entries := []CreateEntriesParams{
+		{AccountID: from.AccountID, Amount: -10},
+		{AccountID: to.AccountID, Amount: 10},
+	}
+
+	b.Run("Sequential", func(b *testing.B) {
+		for b.Loop() {
+			for _, entry := range entries {
+				_, err := testQueries.CreateEntry(context.Background(), CreateEntryParams(entry))
+				require.NoError(b, err)
+			}
+		}
+	})
+
+	b.Run("Batch", func(b *testing.B) {
+		for b.Loop() {
+			var err error
+			testQueries.CreateEntries(context.Background(), entries).QueryRow(func(i int, entry Entry, entryErr error) {
+				err = entryErr
+			})
+			require.NoError(b, err)
+		}
+	})
+}
+
+func BenchmarkSeedAccounts(b *testing.B) {
+	const size = 1000
+
+	rows := make([]CopyAccountsParams, size)
+	for i := range rows {
+		rows[i] = CopyAccountsParams{
+			Owner:       util.RandomString(7),
+			Balance:     util.RandomInt(0, 1_000_000),
+			Currency:    util.USD,
+			AccountType: AccountTypeChecking,
+		}
+	}
+
+	b.Run("Insert", func(b *testing.B) {
+		for b.Loop() {
+			for _, row := range rows {
+				_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+					Owner:       row.Owner,
+					Balance:     row.Balance,
+					Currency:    row.Currency,
+					AccountType: row.AccountType,
+				})
+				require.NoError(b, err)
+			}
+		}
+	})
+
+	b.Run("Copy", func(b *testing.B) {
+		for b.Loop() {
+			n, err := testQueries.CopyAccounts(context.Background(), rows)
+			require.NoError(b, err)
+			require.Equal(b, int64(size), n)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Create a connection pool and wait until the database answers. Failed pings are retried up to attempts times,
+// doubling the backoff after each one, so the server fails fast instead of starting without a database
+func Connect(ctx context.Context, config *pgxpool.Config, attempts int, backoff time.Duration, logger *slog.Logger) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = pool.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			return pool, nil
+		}
+
+		if attempt >= attempts {
+			pool.Close()
+			return nil, fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warn("Database unreachable, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
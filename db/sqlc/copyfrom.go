@@ -0,0 +1,81 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: copyfrom.go
+
+package db
+
+import (
+	"context"
+)
+
+// iteratorForCopyAccounts implements pgx.CopyFromSource.
+type iteratorForCopyAccounts struct {
+	rows                 []CopyAccountsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCopyAccounts) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCopyAccounts) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].Owner,
+		r.rows[0].Balance,
+		r.rows[0].Currency,
+		r.rows[0].AccountType,
+	}, nil
+}
+
+func (r iteratorForCopyAccounts) Err() error {
+	return nil
+}
+
+// Bulk insert with COPY, used to seed databases. It bypasses the outbox, so no AccountCreated event is recorded
+func (q *Queries) CopyAccounts(ctx context.Context, arg []CopyAccountsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"account"}, []string{"owner", "balance", "currency", "account_type"}, &iteratorForCopyAccounts{rows: arg})
+}
+
+// iteratorForCopyUsers implements pgx.CopyFromSource.
+type iteratorForCopyUsers struct {
+	rows                 []CopyUsersParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCopyUsers) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCopyUsers) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].Username,
+		r.rows[0].FullName,
+		r.rows[0].Email,
+	}, nil
+}
+
+func (r iteratorForCopyUsers) Err() error {
+	return nil
+}
+
+// Bulk insert with COPY, used to seed databases
+func (q *Queries) CopyUsers(ctx context.Context, arg []CopyUsersParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"users"}, []string{"username", "full_name", "email"}, &iteratorForCopyUsers{rows: arg})
+}
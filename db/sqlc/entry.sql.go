@@ -7,30 +7,61 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entry (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    description,
+    reference,
+    metadata,
+    counterparty_name
 ) VALUES (
-    $1, $2
-) RETURNING entry_id, account_id, amount, created_at
+    $1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}'),
+    COALESCE((
+        SELECT COALESCE(users.full_name, account.owner) FROM account
+        LEFT JOIN users ON users.username = account.owner
+        WHERE account.account_id = $7::bigint
+    ), '')
+) RETURNING entry_id, account_id, amount, created_at, transfer_id, description, reference, metadata, counterparty_name
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID             int64           `json:"account_id"`
+	Amount                int64           `json:"amount"`
+	TransferID            sql.NullInt64   `json:"transfer_id"`
+	Description           string          `json:"description"`
+	Reference             string          `json:"reference"`
+	Metadata              json.RawMessage `json:"metadata"`
+	CounterpartyAccountID sql.NullInt64   `json:"counterparty_account_id"`
 }
 
+// The counterparty name is snapshotted from the optional counterparty account
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.Description,
+		arg.Reference,
+		arg.Metadata,
+		arg.CounterpartyAccountID,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.EntryID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.CounterpartyName,
 	)
 	return i, err
 }
@@ -41,29 +72,114 @@ WHERE entry_id = $1
 `
 
 func (q *Queries) DeleteEntry(ctx context.Context, entryID int64) error {
-	_, err := q.db.ExecContext(ctx, deleteEntry, entryID)
+	_, err := q.db.Exec(ctx, deleteEntry, entryID)
 	return err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT entry_id, account_id, amount, created_at FROM entry
+SELECT entry_id, account_id, amount, created_at, transfer_id, description, reference, metadata, counterparty_name FROM entry
 WHERE entry_id = $1
 `
 
 func (q *Queries) GetEntry(ctx context.Context, entryID int64) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, getEntry, entryID)
+	row := q.db.QueryRow(ctx, getEntry, entryID)
 	var i Entry
 	err := row.Scan(
 		&i.EntryID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.CounterpartyName,
 	)
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT 
+    entry.entry_id,
+    entry.account_id,
+    entry.amount,
+    entry.transfer_id,
+    entry.created_at,
+    entry.description,
+    entry.reference,
+    entry.metadata,
+    transfer.from_account_id,
+    transfer.to_account_id
+FROM entry
+LEFT JOIN transfer ON transfer.transfer_id = entry.transfer_id
+WHERE entry.account_id = $1
+    AND entry.created_at >= $2
+    AND entry.created_at < $3
+    AND entry.entry_id > $4
+ORDER BY entry.entry_id
+LIMIT $5
+`
+
+type ListAccountEntriesParams struct {
+	AccountID    int64        `json:"account_id"`
+	FromTime     sql.NullTime `json:"from_time"`
+	ToTime       sql.NullTime `json:"to_time"`
+	AfterEntryID int64        `json:"after_entry_id"`
+	PageSize     int32        `json:"page_size"`
+}
+
+type ListAccountEntriesRow struct {
+	EntryID       int64           `json:"entry_id"`
+	AccountID     int64           `json:"account_id"`
+	Amount        int64           `json:"amount"`
+	TransferID    sql.NullInt64   `json:"transfer_id"`
+	CreatedAt     sql.NullTime    `json:"created_at"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	FromAccountID sql.NullInt64   `json:"from_account_id"`
+	ToAccountID   sql.NullInt64   `json:"to_account_id"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterEntryID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesRow{}
+	for rows.Next() {
+		var i ListAccountEntriesRow
+		if err := rows.Scan(
+			&i.EntryID,
+			&i.AccountID,
+			&i.Amount,
+			&i.TransferID,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.FromAccountID,
+			&i.ToAccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntry = `-- name: ListEntry :many
-SELECT entry_id, account_id, amount, created_at FROM entry
+SELECT entry_id, account_id, amount, created_at, transfer_id, description, reference, metadata, counterparty_name FROM entry
 ORDER BY entry_id
 LIMIT $1
 OFFSET $2
@@ -75,7 +191,7 @@ type ListEntryParams struct {
 }
 
 func (q *Queries) ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntry, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listEntry, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -88,25 +204,150 @@ func (q *Queries) ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry,
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.CounterpartyName,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	return items, nil
+}
+
+const searchEntries = `-- name: SearchEntries :many
+SELECT
+    entry.entry_id,
+    entry.account_id,
+    entry.amount,
+    entry.transfer_id,
+    entry.created_at,
+    entry.description,
+    entry.reference,
+    entry.counterparty_name,
+    ts_rank(entry_search_vector(entry.description, entry.counterparty_name, entry.reference), query)::float4 AS rank,
+    ts_headline('simple', entry.description, query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::varchar AS description_highlight,
+    ts_headline('simple', entry.counterparty_name, query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::varchar AS counterparty_name_highlight,
+    ts_headline('simple', entry.reference, query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::varchar AS reference_highlight
+FROM entry, websearch_to_tsquery('simple', $1) AS query
+WHERE entry_search_vector(entry.description, entry.counterparty_name, entry.reference) @@ query
+  AND entry.account_id IN (
+    SELECT account.account_id FROM account WHERE account.owner = $2
+    UNION
+    SELECT account_owners.account_id FROM account_owners
+    WHERE account_owners.owner = $2 AND account_owners.status = 'active'
+  )
+  AND ($3::bigint IS NULL OR entry.account_id = $3)
+  AND ($4::bigint IS NULL OR abs(entry.amount) >= $4)
+  AND ($5::bigint IS NULL OR abs(entry.amount) <= $5)
+  AND ($6::timestamptz IS NULL OR entry.created_at >= $6)
+  AND ($7::timestamptz IS NULL OR entry.created_at < $7)
+ORDER BY rank DESC, entry.entry_id DESC
+LIMIT $9
+OFFSET $8
+`
+
+type SearchEntriesParams struct {
+	Query     string        `json:"query"`
+	Member    string        `json:"member"`
+	AccountID sql.NullInt64 `json:"account_id"`
+	MinAmount sql.NullInt64 `json:"min_amount"`
+	MaxAmount sql.NullInt64 `json:"max_amount"`
+	FromTime  sql.NullTime  `json:"from_time"`
+	ToTime    sql.NullTime  `json:"to_time"`
+	Offset    int32         `json:"offset"`
+	Limit     int32         `json:"limit"`
+}
+
+type SearchEntriesRow struct {
+	EntryID                   int64         `json:"entry_id"`
+	AccountID                 int64         `json:"account_id"`
+	Amount                    int64         `json:"amount"`
+	TransferID                sql.NullInt64 `json:"transfer_id"`
+	CreatedAt                 sql.NullTime  `json:"created_at"`
+	Description               string        `json:"description"`
+	Reference                 string        `json:"reference"`
+	CounterpartyName          string        `json:"counterparty_name"`
+	Rank                      float32       `json:"rank"`
+	DescriptionHighlight      string        `json:"description_highlight"`
+	CounterpartyNameHighlight string        `json:"counterparty_name_highlight"`
+	ReferenceHighlight        string        `json:"reference_highlight"`
+}
+
+// Full-text search of the entries of the accounts the member owns or actively co-owns, best matches first. The
+// query uses the web search syntax ("quoted phrases", or, -excluded). Matched terms are wrapped in \x02 and \x03 in
+// the highlights, which are the whole texts
+func (q *Queries) SearchEntries(ctx context.Context, arg SearchEntriesParams) ([]SearchEntriesRow, error) {
+	rows, err := q.db.Query(ctx, searchEntries,
+		arg.Query,
+		arg.Member,
+		arg.AccountID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchEntriesRow{}
+	for rows.Next() {
+		var i SearchEntriesRow
+		if err := rows.Scan(
+			&i.EntryID,
+			&i.AccountID,
+			&i.Amount,
+			&i.TransferID,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.CounterpartyName,
+			&i.Rank,
+			&i.DescriptionHighlight,
+			&i.CounterpartyNameHighlight,
+			&i.ReferenceHighlight,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
+const sumAccountEntriesSince = `-- name: SumAccountEntriesSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM entry
+WHERE account_id = $1
+    AND created_at >= $2
+`
+
+type SumAccountEntriesSinceParams struct {
+	AccountID int64        `json:"account_id"`
+	Since     sql.NullTime `json:"since"`
+}
+
+func (q *Queries) SumAccountEntriesSince(ctx context.Context, arg SumAccountEntriesSinceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, sumAccountEntriesSince, arg.AccountID, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const updateEntry = `-- name: UpdateEntry :one
 UPDATE entry
 SET amount = $2
 WHERE entry_id = $1
-RETURNING entry_id, account_id, amount, created_at
+RETURNING entry_id, account_id, amount, created_at, transfer_id, description, reference, metadata, counterparty_name
 `
 
 type UpdateEntryParams struct {
@@ -115,13 +356,18 @@ type UpdateEntryParams struct {
 }
 
 func (q *Queries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, updateEntry, arg.EntryID, arg.Amount)
+	row := q.db.QueryRow(ctx, updateEntry, arg.EntryID, arg.Amount)
 	var i Entry
 	err := row.Scan(
 		&i.EntryID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.CounterpartyName,
 	)
 	return i, err
 }
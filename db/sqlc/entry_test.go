@@ -74,7 +74,7 @@ func TestDeleteEntry(t *testing.T) {
 
 	entry, err := testQueries.GetEntry(context.Background(), mock.EntryID)
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err, ErrRecordNotFound)
 	require.Empty(t, entry)
 }
 
@@ -96,3 +96,126 @@ func TestListEntry(t *testing.T) {
 		require.NotEmpty(t, entry)
 	}
 }
+
+func TestListAccountEntries(t *testing.T) {
+	account := createAccountMock(t)
+	from := time.Now().Add(-time.Minute)
+
+	var total int64
+	for range 5 {
+		arg := CreateEntryParams{
+			AccountID: account.AccountID,
+			Amount:    util.RandomInt(-1000, 1000),
+		}
+		_, err := testQueries.CreateEntry(context.Background(), arg)
+		require.NoError(t, err)
+		total += arg.Amount
+	}
+
+	arg := ListAccountEntriesParams{
+		AccountID:    account.AccountID,
+		FromTime:     sql.NullTime{Time: from, Valid: true},
+		ToTime:       sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		AfterEntryID: 0,
+		PageSize:     3,
+	}
+
+	// First page
+	entries, err := testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	// Second page, starting after the last entry of the first page
+	arg.AfterEntryID = entries[len(entries)-1].EntryID
+	rest, err := testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rest, 2)
+
+	for _, entry := range append(entries, rest...) {
+		require.Equal(t, account.AccountID, entry.AccountID)
+		require.False(t, entry.TransferID.Valid)
+	}
+
+	// The sum of the entries since the start of the period should match
+	sum, err := testQueries.SumAccountEntriesSince(context.Background(), SumAccountEntriesSinceParams{
+		AccountID: account.AccountID,
+		Since:     sql.NullTime{Time: from, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, total, sum)
+}
+
+func TestSearchEntries(t *testing.T) {
+	store := NewStore(conn)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+	word := util.RandomString(12)
+	reference := "INV-" + util.RandomString(10)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        10,
+		Description:   "Rent " + word,
+		Reference:     reference,
+	})
+	require.NoError(t, err)
+	require.Equal(t, acc2.Owner, result.FromEntry.CounterpartyName)
+	require.Equal(t, acc1.Owner, result.ToEntry.CounterpartyName)
+
+	search := func(arg SearchEntriesParams) []SearchEntriesRow {
+		arg.Limit = 10
+		rows, err := testQueries.SearchEntries(context.Background(), arg)
+		require.NoError(t, err)
+		return rows
+	}
+
+	// Each side only finds its own entry, with the matched terms marked
+	rows := search(SearchEntriesParams{Query: word, Member: acc1.Owner})
+	require.Len(t, rows, 1)
+	require.Equal(t, result.FromEntry.EntryID, rows[0].EntryID)
+	require.Equal(t, "Rent \x02"+word+"\x03", rows[0].DescriptionHighlight)
+	require.Equal(t, acc2.Owner, rows[0].CounterpartyNameHighlight)
+	require.Positive(t, rows[0].Rank)
+
+	rows = search(SearchEntriesParams{Query: word, Member: acc2.Owner})
+	require.Len(t, rows, 1)
+	require.Equal(t, result.ToEntry.EntryID, rows[0].EntryID)
+
+	require.Empty(t, search(SearchEntriesParams{Query: word, Member: util.RandomString(7)}))
+
+	// The counterparty name and the reference are searched too
+	rows = search(SearchEntriesParams{Query: acc2.Owner + " " + word, Member: acc1.Owner})
+	require.Len(t, rows, 1)
+	require.Equal(t, "\x02"+acc2.Owner+"\x03", rows[0].CounterpartyNameHighlight)
+
+	rows = search(SearchEntriesParams{Query: reference, Member: acc1.Owner})
+	require.Len(t, rows, 1)
+	require.Contains(t, rows[0].ReferenceHighlight, "\x02")
+
+	// The filters narrow the results down
+	require.Len(t, search(SearchEntriesParams{
+		Query:     word,
+		Member:    acc1.Owner,
+		AccountID: sql.NullInt64{Int64: acc1.AccountID, Valid: true},
+		MinAmount: sql.NullInt64{Int64: 10, Valid: true},
+		MaxAmount: sql.NullInt64{Int64: 10, Valid: true},
+		FromTime:  sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	}), 1)
+	require.Empty(t, search(SearchEntriesParams{
+		Query:     word,
+		Member:    acc1.Owner,
+		MinAmount: sql.NullInt64{Int64: 11, Valid: true},
+	}))
+	require.Empty(t, search(SearchEntriesParams{
+		Query:  word,
+		Member: acc1.Owner,
+		ToTime: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	}))
+	require.Empty(t, search(SearchEntriesParams{
+		Query:     word,
+		Member:    acc1.Owner,
+		AccountID: sql.NullInt64{Int64: acc2.AccountID, Valid: true},
+	}))
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Returned when a query expecting a row finds none. It also matches sql.ErrNoRows with errors.Is
+var ErrRecordNotFound = pgx.ErrNoRows
+
+// Postgres error codes (SQLSTATE) handled by the application
+const (
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+	CheckViolation       = "23514"
+	ForeignKeyViolation  = "23503"
+	UniqueViolation      = "23505"
+)
+
+// Get the SQLSTATE of a Postgres error, or "" if err doesn't come from Postgres
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// Get the constraint a Postgres error is about, or "" if there is none
+func ErrorConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
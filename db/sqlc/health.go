@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+)
+
+// Row of the schema_migrations table maintained by golang-migrate
+type SchemaMigration struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+}
+
+// The table is created by golang-migrate rather than by our migrations, so sqlc doesn't know about it
+const getSchemaVersion = `-- name: GetSchemaVersion :one
+SELECT version, dirty FROM schema_migrations LIMIT 1
+`
+
+// Method to get the migration version the database is at
+func (q *Queries) GetSchemaVersion(ctx context.Context) (SchemaMigration, error) {
+	row := q.db.QueryRow(ctx, getSchemaVersion)
+	var i SchemaMigration
+	err := row.Scan(&i.Version, &i.Dirty)
+	return i, err
+}
+
+// Method to check that the database is reachable
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.pool.Ping(ctx)
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"gobank/db/migration"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSchemaVersion(t *testing.T) {
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	schema, err := testQueries.GetSchemaVersion(context.Background())
+	require.NoError(t, err)
+	require.False(t, schema.Dirty)
+	require.Equal(t, int64(latest), schema.Version)
+}
+
+func TestPing(t *testing.T) {
+	require.NoError(t, NewStore(conn).Ping(context.Background()))
+}
+
+func TestMigratorKeepsPoolOpen(t *testing.T) {
+	// Closing a migrator must not close the pool it was given, the server keeps using it after MIGRATE_ON_START
+	migrator, err := migration.New(conn)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Close())
+
+	require.NoError(t, conn.Ping(context.Background()))
+	_, err = testQueries.GetSchemaVersion(context.Background())
+	require.NoError(t, err)
+}
+
+func TestConnectUnreachable(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	config, err := pgxpool.ParseConfig("postgresql://root@127.0.0.1:1/gobank?sslmode=disable")
+	require.NoError(t, err)
+
+	_, err = Connect(context.Background(), config, 3, time.Millisecond, logger)
+	require.ErrorContains(t, err, "after 3 attempts")
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gobank/util"
+	"time"
+)
+
+// Purposes of the accounts of the bank itself, see the bank_account table
+const (
+	BankAccountInterestExpense = "interest_expense"
+	BankAccountOverdraftIncome = "overdraft_income"
+	BankAccountCashClearing    = "cash_clearing"
+)
+
+// Owner of the accounts of the bank itself. The name is reserved, no user can be created with it
+const BankOwner = "bank"
+
+var ErrInterestNotPosted = errors.New("interest of the previous month is not posted yet")
+
+// Parameter struct for accruing the interest of a savings or overdrawable account
+type AccrueInterestTxParams struct {
+	AccountID int64     `json:"account_id"`
+	Through   time.Time `json:"through"`
+}
+
+// Result struct return after accruing interest
+type AccrueInterestTxResult struct {
+	Account Account `json:"account"`
+	Days    int     `json:"days"`
+	Accrued int64   `json:"accrued"`
+}
+
+// Helper method: get the day of a timestamp, at midnight UTC
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Method to accrue the daily interest of a savings or overdrawable account, from the day after the last accrual (or
+// the day the account was opened) through arg.Through. It stops at the end of a month, which must be posted with PostInterestTx
+// before the next month accrues, otherwise ErrInterestNotPosted is returned.
+//
+// Each day earns interest on its end-of-day balance (UTC): the current balance minus every entry booked since. A
+// negative end-of-day balance is charged overdraft interest and the daily overdraft fee instead, which makes the
+// accrued interest go down.
+// Days already accrued are skipped, so running it again is harmless
+func (store *SQLStore) AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error) {
+	var result AccrueInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		result.Account = account
+
+		first := day(arg.Through)
+		switch {
+		case account.InterestAccruedThrough.Valid:
+			accruedThrough := day(account.InterestAccruedThrough.Time)
+			if accruedThrough.Equal(util.EndOfMonth(accruedThrough)) && !account.InterestPostedThrough.Time.Equal(accruedThrough) {
+				return ErrInterestNotPosted
+			}
+			first = accruedThrough.AddDate(0, 0, 1)
+		case account.CreatedAt.Valid:
+			first = day(account.CreatedAt.Time)
+		}
+
+		last := day(arg.Through)
+		if endOfMonth := util.EndOfMonth(first); endOfMonth.Before(last) {
+			last = endOfMonth
+		}
+
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			bookedSince, err := q.SumAccountEntriesSince(ctx, SumAccountEntriesSinceParams{
+				AccountID: arg.AccountID,
+				Since:     sql.NullTime{Time: d.AddDate(0, 0, 1), Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			balance := account.Balance - bookedSince
+			result.Accrued += util.DailyInterest(balance, account.InterestRateBps, d) +
+				util.DailyOverdraftInterest(balance, account.OverdraftRateBps, d) +
+				util.DailyOverdraftFee(balance, account.OverdraftFee)
+			result.Days++
+		}
+		if result.Days == 0 {
+			return nil
+		}
+
+		result.Account, err = q.AccrueAccountInterest(ctx, AccrueAccountInterestParams{
+			AccountID:      arg.AccountID,
+			Amount:         result.Accrued,
+			AccruedThrough: last,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// Result struct return after posting interest. Posted is negative when overdraft interest was charged, and Transfer
+// is empty when nothing was posted
+type PostInterestTxResult struct {
+	Account  Account  `json:"account"`
+	Transfer Transfer `json:"transfer"`
+	Posted   int64    `json:"posted"`
+}
+
+// Method to post the interest of an account once it has accrued through the end of a month. The whole minor units
+// accrued are transferred from the interest expense account of the currency, the remainder is carried over. When
+// overdraft interest outweighs what was earned, the whole minor units owed are transferred to the overdraft income
+// account instead, even past the overdraft limit: it is a charge of the bank, not a payment of the customer.
+// Frozen accounts are posted too: freezing stops the customer from moving money, not the bank's bookkeeping.
+// It does nothing when the account isn't at the end of a month, or the month is already posted
+func (store *SQLStore) PostInterestTx(ctx context.Context, accountID int64) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		result.Account = account
+
+		accruedThrough := account.InterestAccruedThrough
+		if !accruedThrough.Valid || !accruedThrough.Time.Equal(util.EndOfMonth(accruedThrough.Time)) ||
+			account.InterestPostedThrough.Time.Equal(accruedThrough.Time) {
+			return nil
+		}
+
+		posted, remainder := util.SplitInterest(account.AccruedInterest)
+		if posted != 0 {
+			purpose, amount, description := BankAccountInterestExpense, posted, "Interest"
+			if posted < 0 {
+				purpose, amount, description = BankAccountOverdraftIncome, -posted, "Overdraft interest and fees"
+			}
+
+			bankID, err := q.GetBankAccount(ctx, GetBankAccountParams{
+				Purpose:  purpose,
+				Currency: account.Currency,
+			})
+			if err != nil {
+				return err
+			}
+
+			fromID, toID := bankID, accountID
+			if posted < 0 {
+				fromID, toID = accountID, bankID
+			}
+
+			// The interest expense account may go negative, it records what the bank has paid
+			result.Transfer, _, _, err = createBankTransfer(ctx, q, fromID, toID, amount, description)
+			if err != nil {
+				return err
+			}
+
+			if _, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: fromID, Amount: -amount}); err != nil {
+				return err
+			}
+			if _, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: toID, Amount: amount}); err != nil {
+				return err
+			}
+		}
+
+		result.Posted = posted
+		result.Account, err = q.PostAccountInterest(ctx, PostAccountInterestParams{
+			AccountID: accountID,
+			Remainder: remainder,
+		})
+		if err != nil || posted == 0 {
+			return err
+		}
+
+		return recordEvent(ctx, q, AggregateAccount, accountID, EventInterestPosted, result)
+	})
+
+	return result, err
+}
+
+// Helper method: record a transfer made by the bank between one of its accounts and a customer account, with its
+// two entries. The balances are left to the caller
+func createBankTransfer(ctx context.Context, q *Queries, fromID, toID, amount int64, description string) (Transfer, Entry, Entry, error) {
+	transfer, err := q.CreateTransaction(ctx, CreateTransactionParams{
+		FromAccountID: fromID,
+		ToAccountID:   toID,
+		Amount:        amount,
+		Description:   description,
+	})
+	if err != nil {
+		return transfer, Entry{}, Entry{}, err
+	}
+
+	transferID := sql.NullInt64{Int64: transfer.TransferID, Valid: true}
+	entries := make([]Entry, 2)
+	q.CreateEntries(ctx, []CreateEntriesParams{
+		{
+			AccountID:             fromID,
+			Amount:                -amount,
+			TransferID:            transferID,
+			Description:           description,
+			CounterpartyAccountID: sql.NullInt64{Int64: toID, Valid: true},
+		},
+		{
+			AccountID:             toID,
+			Amount:                amount,
+			TransferID:            transferID,
+			Description:           description,
+			CounterpartyAccountID: sql.NullInt64{Int64: fromID, Valid: true},
+		},
+	}).QueryRow(func(i int, entry Entry, entryErr error) {
+		entries[i] = entry
+		err = errors.Join(err, entryErr)
+	})
+
+	return transfer, entries[0], entries[1], err
+}
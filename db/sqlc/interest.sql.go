@@ -0,0 +1,243 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: interest.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const accrueAccountInterest = `-- name: AccrueAccountInterest :one
+UPDATE account
+SET accrued_interest = accrued_interest + $1,
+    interest_accrued_through = $2::date
+WHERE account_id = $3
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
+`
+
+type AccrueAccountInterestParams struct {
+	Amount         int64     `json:"amount"`
+	AccruedThrough time.Time `json:"accrued_through"`
+	AccountID      int64     `json:"account_id"`
+}
+
+func (q *Queries) AccrueAccountInterest(ctx context.Context, arg AccrueAccountInterestParams) (Account, error) {
+	row := q.db.QueryRow(ctx, accrueAccountInterest, arg.Amount, arg.AccruedThrough, arg.AccountID)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
+	)
+	return i, err
+}
+
+const getBankAccount = `-- name: GetBankAccount :one
+SELECT account_id FROM bank_account
+WHERE purpose = $1
+  AND currency = $2
+`
+
+type GetBankAccountParams struct {
+	Purpose  string `json:"purpose"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetBankAccount(ctx context.Context, arg GetBankAccountParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getBankAccount, arg.Purpose, arg.Currency)
+	var account_id int64
+	err := row.Scan(&account_id)
+	return account_id, err
+}
+
+const listAccountsDueForInterest = `-- name: ListAccountsDueForInterest :many
+SELECT account.account_id FROM account
+WHERE (account_type = 'savings' OR overdraft_limit > 0 OR balance < 0)
+  AND account.account_id NOT IN (SELECT bank_account.account_id FROM bank_account)
+  AND (interest_accrued_through IS NULL
+    OR interest_accrued_through < $1::date
+    OR (interest_accrued_through = (date_trunc('month', interest_accrued_through) + interval '1 month - 1 day')::date
+      AND interest_posted_through IS DISTINCT FROM interest_accrued_through))
+  AND account.account_id > $2
+ORDER BY account.account_id
+LIMIT $3
+`
+
+type ListAccountsDueForInterestParams struct {
+	Through        time.Time `json:"through"`
+	AfterAccountID int64     `json:"after_account_id"`
+	BatchSize      int32     `json:"batch_size"`
+}
+
+// Savings and overdrawable accounts whose interest hasn't been accrued through the given day yet, or accrued through
+// the end of a month but not posted. The accounts of the bank itself go negative by design and are never charged
+func (q *Queries) ListAccountsDueForInterest(ctx context.Context, arg ListAccountsDueForInterestParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listAccountsDueForInterest, arg.Through, arg.AfterAccountID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var account_id int64
+		if err := rows.Scan(&account_id); err != nil {
+			return nil, err
+		}
+		items = append(items, account_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const postAccountInterest = `-- name: PostAccountInterest :one
+UPDATE account
+SET accrued_interest = $1,
+    interest_posted_through = interest_accrued_through
+WHERE account_id = $2
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
+`
+
+type PostAccountInterestParams struct {
+	Remainder int64 `json:"remainder"`
+	AccountID int64 `json:"account_id"`
+}
+
+// Only the remainder that couldn't be posted is kept, it is carried over to the next month
+func (q *Queries) PostAccountInterest(ctx context.Context, arg PostAccountInterestParams) (Account, error) {
+	row := q.db.QueryRow(ctx, postAccountInterest, arg.Remainder, arg.AccountID)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
+	)
+	return i, err
+}
+
+const updateAccountInterestRate = `-- name: UpdateAccountInterestRate :one
+UPDATE account
+SET interest_rate_bps = $2,
+    version = version + 1
+WHERE account_id = $1
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
+`
+
+type UpdateAccountInterestRateParams struct {
+	AccountID       int64 `json:"account_id"`
+	InterestRateBps int32 `json:"interest_rate_bps"`
+}
+
+func (q *Queries) UpdateAccountInterestRate(ctx context.Context, arg UpdateAccountInterestRateParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountInterestRate, arg.AccountID, arg.InterestRateBps)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
+	)
+	return i, err
+}
+
+const updateAccountOverdraft = `-- name: UpdateAccountOverdraft :one
+UPDATE account
+SET overdraft_limit = $2,
+    overdraft_rate_bps = $3,
+    overdraft_fee = $4,
+    interest_accrued_through = CASE
+      WHEN account_type = 'savings' THEN interest_accrued_through
+      ELSE COALESCE(interest_accrued_through, (now() AT TIME ZONE 'UTC')::date - 1)
+    END,
+    version = version + 1
+WHERE account_id = $1
+RETURNING account_id, owner, balance, currency, created_at, status, version, account_type, nickname, labels, interest_rate_bps, accrued_interest, interest_accrued_through, interest_posted_through, overdraft_limit, overdraft_rate_bps, account_number, overdraft_fee
+`
+
+type UpdateAccountOverdraftParams struct {
+	AccountID        int64 `json:"account_id"`
+	OverdraftLimit   int64 `json:"overdraft_limit"`
+	OverdraftRateBps int32 `json:"overdraft_rate_bps"`
+	OverdraftFee     int64 `json:"overdraft_fee"`
+}
+
+// Accounts other than savings never accrued interest before, they start accruing from today instead of the day they
+// were opened
+func (q *Queries) UpdateAccountOverdraft(ctx context.Context, arg UpdateAccountOverdraftParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountOverdraft,
+		arg.AccountID,
+		arg.OverdraftLimit,
+		arg.OverdraftRateBps,
+		arg.OverdraftFee,
+	)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Version,
+		&i.AccountType,
+		&i.Nickname,
+		&i.Labels,
+		&i.InterestRateBps,
+		&i.AccruedInterest,
+		&i.InterestAccruedThrough,
+		&i.InterestPostedThrough,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.AccountNumber,
+		&i.OverdraftFee,
+	)
+	return i, err
+}
@@ -0,0 +1,278 @@
+package db
+
+import (
+	"context"
+	"gobank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Helper method: create a savings account earning the given rate, opened on the given day
+func createSavingsAccountMock(t *testing.T, balance int64, rateBps int32, openedOn time.Time) Account {
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Balance:     balance,
+		Currency:    util.USD,
+		AccountType: AccountTypeSavings,
+	})
+	require.NoError(t, err)
+
+	account, err = testQueries.UpdateAccountInterestRate(context.Background(), UpdateAccountInterestRateParams{
+		AccountID:       account.AccountID,
+		InterestRateBps: rateBps,
+	})
+	require.NoError(t, err)
+
+	// The opening balance has no entry, so it counts in the end-of-day balance of every day since the account opened
+	_, err = conn.Exec(context.Background(), "UPDATE account SET created_at = $2 WHERE account_id = $1",
+		account.AccountID, openedOn)
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestInterestRateOnlyOnSavings(t *testing.T) {
+	account := createAccountMock(t)
+
+	_, err := testQueries.UpdateAccountInterestRate(context.Background(), UpdateAccountInterestRateParams{
+		AccountID:       account.AccountID,
+		InterestRateBps: 100,
+	})
+	require.Equal(t, CheckViolation, ErrorCode(err))
+}
+
+func TestAccrueAndPostInterest(t *testing.T) {
+	store := NewStore(conn)
+
+	// 1000.00 at 3.65%, opened in a leap year: 9972678 millionths of a cent a day
+	account := createSavingsAccountMock(t, 100_000, 365, time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC))
+	expenseID, err := store.GetBankAccount(context.Background(), GetBankAccountParams{
+		Purpose:  BankAccountInterestExpense,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	expense, err := store.GetAccount(context.Background(), expenseID)
+	require.NoError(t, err)
+
+	// Accrual stops at the end of January, the first month
+	through := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+	accrual, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   through,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 17, accrual.Days)
+	require.Equal(t, int64(17*9_972_678), accrual.Account.AccruedInterest)
+	require.Equal(t, time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC), accrual.Account.InterestAccruedThrough.Time)
+
+	// February can't accrue before January is posted
+	_, err = store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   through,
+	})
+	require.ErrorIs(t, err, ErrInterestNotPosted)
+
+	// January pays 169535526 millionths: 169 cents posted, 535526 carried over
+	posting, err := store.PostInterestTx(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(169), posting.Posted)
+	require.Equal(t, int64(535_526), posting.Account.AccruedInterest)
+	require.Equal(t, account.Balance+169, posting.Account.Balance)
+	require.Equal(t, expenseID, posting.Transfer.FromAccountID)
+	require.Equal(t, account.AccountID, posting.Transfer.ToAccountID)
+
+	// Posting again does nothing
+	again, err := store.PostInterestTx(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Zero(t, again.Posted)
+	require.Equal(t, posting.Account.Balance, again.Account.Balance)
+
+	// February has 29 days. The January interest is booked today, so it doesn't count in February's balances
+	accrual, err = store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   through,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 29, accrual.Days)
+
+	posting, err = store.PostInterestTx(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(289), posting.Posted) // 535526 + 29 * 9972678 = 289743188
+	require.Equal(t, int64(743_188), posting.Account.AccruedInterest)
+
+	// March accrues through the 10th, and isn't posted until the month is over
+	accrual, err = store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   through,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, accrual.Days)
+	require.Equal(t, int64(743_188+10*9_972_678), accrual.Account.AccruedInterest)
+
+	posting, err = store.PostInterestTx(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Zero(t, posting.Posted)
+
+	// Nothing left to accrue
+	accrual, err = store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   through,
+	})
+	require.NoError(t, err)
+	require.Zero(t, accrual.Days)
+
+	// The interest was paid by the bank
+	res, err := store.GetAccount(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+169+289, res.Balance)
+
+	resExpense, err := store.GetAccount(context.Background(), expenseID)
+	require.NoError(t, err)
+	require.LessOrEqual(t, resExpense.Balance, expense.Balance-169-289)
+}
+
+func TestAccrueInterestEndOfDayBalance(t *testing.T) {
+	store := NewStore(conn)
+
+	// Opened yesterday with 1000.00 at 3.65% in the current year
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	account := createSavingsAccountMock(t, 100_000, 365, yesterday)
+
+	// A deposit made today doesn't count in yesterday's end-of-day balance
+	_, err := store.DepositTx(context.Background(), DepositTxParams{AccountID: account.AccountID, Amount: 50_000})
+	require.NoError(t, err)
+
+	accrual, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   yesterday,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, accrual.Days)
+	require.Equal(t, util.DailyInterest(100_000, 365, yesterday), accrual.Accrued)
+}
+
+func TestAccrueAndPostOverdraftInterest(t *testing.T) {
+	store := NewStore(conn)
+
+	// 1000.00 overdrawn at 18.25% in 2023 is charged 0.50 a day
+	account := createAccountMock(t)
+	account, err := store.UpdateAccountOverdraft(context.Background(), UpdateAccountOverdraftParams{
+		AccountID:        account.AccountID,
+		OverdraftLimit:   200_000,
+		OverdraftRateBps: 1825,
+	})
+	require.NoError(t, err)
+	require.True(t, account.InterestAccruedThrough.Valid)
+
+	// Overdrawn since before June, which starts the accrual
+	mayEnd := time.Date(2023, time.May, 31, 0, 0, 0, 0, time.UTC)
+	_, err = conn.Exec(context.Background(), `UPDATE account SET balance = -100000, interest_accrued_through = $2,
+		interest_posted_through = $2 WHERE account_id = $1`, account.AccountID, mayEnd)
+	require.NoError(t, err)
+
+	incomeID, err := store.GetBankAccount(context.Background(), GetBankAccountParams{
+		Purpose:  BankAccountOverdraftIncome,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+	income, err := store.GetAccount(context.Background(), incomeID)
+	require.NoError(t, err)
+
+	accrual, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   time.Date(2023, time.June, 30, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 30, accrual.Days)
+	require.Equal(t, int64(-30*50*util.InterestScale), accrual.Account.AccruedInterest)
+
+	// The 15.00 charged is transferred from the account to the overdraft income account
+	posting, err := store.PostInterestTx(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(-1500), posting.Posted)
+	require.Equal(t, int64(-101_500), posting.Account.Balance)
+	require.Zero(t, posting.Account.AccruedInterest)
+	require.Equal(t, account.AccountID, posting.Transfer.FromAccountID)
+	require.Equal(t, incomeID, posting.Transfer.ToAccountID)
+	require.Equal(t, int64(1500), posting.Transfer.Amount)
+
+	resIncome, err := store.GetAccount(context.Background(), incomeID)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, resIncome.Balance, income.Balance+1500)
+}
+
+func TestAccrueAndPostOverdraftFee(t *testing.T) {
+	store := NewStore(conn)
+
+	// 1.00 charged for every day that ends overdrawn, without interest
+	account := createAccountMock(t)
+	account, err := store.UpdateAccountOverdraft(context.Background(), UpdateAccountOverdraftParams{
+		AccountID:      account.AccountID,
+		OverdraftLimit: 200_000,
+		OverdraftFee:   100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.OverdraftFee)
+
+	// Overdrawn through the whole of June
+	mayEnd := time.Date(2023, time.May, 31, 0, 0, 0, 0, time.UTC)
+	_, err = conn.Exec(context.Background(), `UPDATE account SET balance = -100000, interest_accrued_through = $2,
+		interest_posted_through = $2 WHERE account_id = $1`, account.AccountID, mayEnd)
+	require.NoError(t, err)
+
+	accrual, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: account.AccountID,
+		Through:   time.Date(2023, time.June, 30, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 30, accrual.Days)
+	require.Equal(t, int64(-30*100*util.InterestScale), accrual.Accrued)
+
+	// The 30.00 of fees are posted to the overdraft income account
+	posting, err := store.PostInterestTx(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(-3000), posting.Posted)
+	require.Equal(t, int64(-103_000), posting.Account.Balance)
+	require.Equal(t, "Overdraft interest and fees", posting.Transfer.Description)
+}
+
+func TestInterestSkipsBankAccounts(t *testing.T) {
+	store := NewStore(conn)
+
+	// A deposit takes the cash clearing account below zero, as paid interest does the interest expense account
+	account := createAccountMock(t)
+	_, err := store.DepositTx(context.Background(), DepositTxParams{AccountID: account.AccountID, Amount: 100})
+	require.NoError(t, err)
+	clearing := getCashClearingMock(t, account.Currency)
+	require.Negative(t, clearing.Balance)
+
+	var bankIDs []int64
+	for _, purpose := range []string{BankAccountInterestExpense, BankAccountOverdraftIncome, BankAccountCashClearing} {
+		for _, currency := range []string{util.USD, util.EUR, util.VND} {
+			id, err := store.GetBankAccount(context.Background(), GetBankAccountParams{Purpose: purpose, Currency: currency})
+			require.NoError(t, err)
+			bankIDs = append(bankIDs, id)
+		}
+	}
+
+	// A whole interest pass never picks them, so no overdraft interest is booked as income of the bank
+	var afterAccountID int64
+	for {
+		accountIDs, err := store.ListAccountsDueForInterest(context.Background(), ListAccountsDueForInterestParams{
+			Through:        time.Now().UTC(),
+			AfterAccountID: afterAccountID,
+			BatchSize:      1000,
+		})
+		require.NoError(t, err)
+		if len(accountIDs) == 0 {
+			break
+		}
+
+		for _, accountID := range accountIDs {
+			require.NotContains(t, bankIDs, accountID)
+		}
+		afterAccountID = accountIDs[len(accountIDs)-1]
+	}
+}
@@ -1,13 +1,14 @@
 package db
 
 import (
-	"database/sql"
+	"context"
+	"gobank/db/migration"
 	"gobank/util"
 	"log/slog"
 	"os"
 	"testing"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // MAIN entry of the package level test
@@ -15,7 +16,7 @@ import (
 // Package level variables
 var (
 	testQueries *Queries
-	conn        *sql.DB
+	conn        *pgxpool.Pool
 )
 
 func TestMain(m *testing.M) {
@@ -28,12 +29,23 @@ func TestMain(m *testing.M) {
 		logger.Error("Failed to load db config from main_test.go", "error", err)
 	}
 
-	conn, err = sql.Open(config.DbDriver, config.DbSource)
+	conn, err = pgxpool.New(context.Background(), config.Database.Source)
 	if err != nil {
 		logger.Error("Error creating test connection", "error", err)
 		os.Exit(1)
 	}
 
+	// Apply the embedded migrations, so the tests run against the schema the binary ships with
+	migrator, err := migration.New(conn)
+	if err == nil {
+		err = migrator.Up()
+		migrator.Close()
+	}
+	if err != nil {
+		logger.Error("Failed to apply migrations", "error", err)
+		os.Exit(1)
+	}
+
 	testQueries = New(conn)
 
 	os.Exit(m.Run())
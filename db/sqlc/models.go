@@ -6,27 +6,150 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 )
 
 type Account struct {
-	AccountID int64        `json:"account_id"`
-	Owner     string       `json:"owner"`
-	Balance   int64        `json:"balance"`
-	Currency  string       `json:"currency"`
-	CreatedAt sql.NullTime `json:"created_at"`
+	AccountID              int64           `json:"account_id"`
+	Owner                  string          `json:"owner"`
+	Balance                int64           `json:"balance"`
+	Currency               string          `json:"currency"`
+	CreatedAt              sql.NullTime    `json:"created_at"`
+	Status                 string          `json:"status"`
+	Version                int64           `json:"version"`
+	AccountType            string          `json:"account_type"`
+	Nickname               string          `json:"nickname"`
+	Labels                 json.RawMessage `json:"labels"`
+	InterestRateBps        int32           `json:"interest_rate_bps"`
+	AccruedInterest        int64           `json:"accrued_interest"`
+	InterestAccruedThrough sql.NullTime    `json:"interest_accrued_through"`
+	InterestPostedThrough  sql.NullTime    `json:"interest_posted_through"`
+	OverdraftLimit         int64           `json:"overdraft_limit"`
+	OverdraftRateBps       int32           `json:"overdraft_rate_bps"`
+	AccountNumber          string          `json:"account_number"`
+	OverdraftFee           int64           `json:"overdraft_fee"`
+}
+
+type AccountOwner struct {
+	AccountID  int64        `json:"account_id"`
+	Owner      string       `json:"owner"`
+	Permission string       `json:"permission"`
+	Status     string       `json:"status"`
+	InvitedBy  string       `json:"invited_by"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ApprovedAt sql.NullTime `json:"approved_at"`
+}
+
+type BankAccount struct {
+	Purpose   string `json:"purpose"`
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
 }
 
 type Entry struct {
-	EntryID   int64        `json:"entry_id"`
-	AccountID int64        `json:"account_id"`
-	Amount    int64        `json:"amount"`
-	CreatedAt sql.NullTime `json:"created_at"`
+	EntryID          int64           `json:"entry_id"`
+	AccountID        int64           `json:"account_id"`
+	Amount           int64           `json:"amount"`
+	CreatedAt        sql.NullTime    `json:"created_at"`
+	TransferID       sql.NullInt64   `json:"transfer_id"`
+	Description      string          `json:"description"`
+	Reference        string          `json:"reference"`
+	Metadata         json.RawMessage `json:"metadata"`
+	CounterpartyName string          `json:"counterparty_name"`
+}
+
+type NotificationDelivery struct {
+	TransferID  int64     `json:"transfer_id"`
+	Kind        string    `json:"kind"`
+	Channel     string    `json:"channel"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+type NotificationPreference struct {
+	Owner               string         `json:"owner"`
+	Email               sql.NullString `json:"email"`
+	Phone               sql.NullString `json:"phone"`
+	EmailEnabled        bool           `json:"email_enabled"`
+	SmsEnabled          bool           `json:"sms_enabled"`
+	LowBalanceThreshold sql.NullInt64  `json:"low_balance_threshold"`
+	LargeDebitThreshold sql.NullInt64  `json:"large_debit_threshold"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	ThresholdCurrency   sql.NullString `json:"threshold_currency"`
+}
+
+type OutboxEvent struct {
+	EventID       int64           `json:"event_id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   sql.NullTime    `json:"published_at"`
+	LockedUntil   sql.NullTime    `json:"locked_until"`
+}
+
+type Payee struct {
+	PayeeID   int64     `json:"payee_id"`
+	Owner     string    `json:"owner"`
+	Nickname  string    `json:"nickname"`
+	AccountID int64     `json:"account_id"`
+	OwnerName string    `json:"owner_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Task struct {
+	TaskID      int64           `json:"task_id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedUntil sql.NullTime    `json:"locked_until"`
+	LastError   sql.NullString  `json:"last_error"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 }
 
 type Transfer struct {
-	TransferID    int64        `json:"transfer_id"`
-	FromAccountID int64        `json:"from_account_id"`
-	ToAccountID   int64        `json:"to_account_id"`
-	Amount        int64        `json:"amount"`
-	CreatedAt     sql.NullTime `json:"created_at"`
+	TransferID    int64           `json:"transfer_id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	CreatedAt     sql.NullTime    `json:"created_at"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+}
+
+type User struct {
+	Username  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID     int64           `json:"delivery_id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode sql.NullInt32   `json:"last_status_code"`
+	LastError      sql.NullString  `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at"`
+}
+
+type WebhookSubscription struct {
+	SubscriptionID int64     `json:"subscription_id"`
+	Owner          string    `json:"owner"`
+	Url            string    `json:"url"`
+	Secret         string    `json:"secret"`
+	EventTypes     []string  `json:"event_types"`
+	CreatedAt      time.Time `json:"created_at"`
 }
@@ -0,0 +1,156 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: notification.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createNotificationDelivery = `-- name: CreateNotificationDelivery :exec
+INSERT INTO notification_delivery (
+    transfer_id,
+    kind,
+    channel
+) VALUES (
+    $1, $2, $3
+) ON CONFLICT DO NOTHING
+`
+
+type CreateNotificationDeliveryParams struct {
+	TransferID int64  `json:"transfer_id"`
+	Kind       string `json:"kind"`
+	Channel    string `json:"channel"`
+}
+
+// Delivering twice is harmless here, the row only has to exist
+func (q *Queries) CreateNotificationDelivery(ctx context.Context, arg CreateNotificationDeliveryParams) error {
+	_, err := q.db.Exec(ctx, createNotificationDelivery, arg.TransferID, arg.Kind, arg.Channel)
+	return err
+}
+
+const deleteNotificationPreference = `-- name: DeleteNotificationPreference :exec
+DELETE FROM notification_preference
+WHERE owner = $1
+`
+
+func (q *Queries) DeleteNotificationPreference(ctx context.Context, owner string) error {
+	_, err := q.db.Exec(ctx, deleteNotificationPreference, owner)
+	return err
+}
+
+const getNotificationPreference = `-- name: GetNotificationPreference :one
+SELECT owner, email, phone, email_enabled, sms_enabled, low_balance_threshold, large_debit_threshold, updated_at, threshold_currency FROM notification_preference
+WHERE owner = $1
+`
+
+func (q *Queries) GetNotificationPreference(ctx context.Context, owner string) (NotificationPreference, error) {
+	row := q.db.QueryRow(ctx, getNotificationPreference, owner)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.Owner,
+		&i.Email,
+		&i.Phone,
+		&i.EmailEnabled,
+		&i.SmsEnabled,
+		&i.LowBalanceThreshold,
+		&i.LargeDebitThreshold,
+		&i.UpdatedAt,
+		&i.ThresholdCurrency,
+	)
+	return i, err
+}
+
+const listNotificationDeliveries = `-- name: ListNotificationDeliveries :many
+SELECT transfer_id, kind, channel, delivered_at FROM notification_delivery
+WHERE transfer_id = $1
+`
+
+func (q *Queries) ListNotificationDeliveries(ctx context.Context, transferID int64) ([]NotificationDelivery, error) {
+	rows, err := q.db.Query(ctx, listNotificationDeliveries, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationDelivery{}
+	for rows.Next() {
+		var i NotificationDelivery
+		if err := rows.Scan(
+			&i.TransferID,
+			&i.Kind,
+			&i.Channel,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertNotificationPreference = `-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preference (
+    owner,
+    email,
+    phone,
+    email_enabled,
+    sms_enabled,
+    low_balance_threshold,
+    large_debit_threshold,
+    threshold_currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) ON CONFLICT (owner) DO UPDATE
+SET email = EXCLUDED.email,
+    phone = EXCLUDED.phone,
+    email_enabled = EXCLUDED.email_enabled,
+    sms_enabled = EXCLUDED.sms_enabled,
+    low_balance_threshold = EXCLUDED.low_balance_threshold,
+    large_debit_threshold = EXCLUDED.large_debit_threshold,
+    threshold_currency = EXCLUDED.threshold_currency,
+    updated_at = now()
+RETURNING owner, email, phone, email_enabled, sms_enabled, low_balance_threshold, large_debit_threshold, updated_at, threshold_currency
+`
+
+type UpsertNotificationPreferenceParams struct {
+	Owner               string         `json:"owner"`
+	Email               sql.NullString `json:"email"`
+	Phone               sql.NullString `json:"phone"`
+	EmailEnabled        bool           `json:"email_enabled"`
+	SmsEnabled          bool           `json:"sms_enabled"`
+	LowBalanceThreshold sql.NullInt64  `json:"low_balance_threshold"`
+	LargeDebitThreshold sql.NullInt64  `json:"large_debit_threshold"`
+	ThresholdCurrency   sql.NullString `json:"threshold_currency"`
+}
+
+func (q *Queries) UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (NotificationPreference, error) {
+	row := q.db.QueryRow(ctx, upsertNotificationPreference,
+		arg.Owner,
+		arg.Email,
+		arg.Phone,
+		arg.EmailEnabled,
+		arg.SmsEnabled,
+		arg.LowBalanceThreshold,
+		arg.LargeDebitThreshold,
+		arg.ThresholdCurrency,
+	)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.Owner,
+		&i.Email,
+		&i.Phone,
+		&i.EmailEnabled,
+		&i.SmsEnabled,
+		&i.LowBalanceThreshold,
+		&i.LargeDebitThreshold,
+		&i.UpdatedAt,
+		&i.ThresholdCurrency,
+	)
+	return i, err
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"gobank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertNotificationPreference(t *testing.T) {
+	arg := UpsertNotificationPreferenceParams{
+		Owner:               util.RandomString(7),
+		Email:               sql.NullString{String: "owner@example.com", Valid: true},
+		EmailEnabled:        true,
+		LowBalanceThreshold: sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+		ThresholdCurrency:   sql.NullString{String: util.USD, Valid: true},
+	}
+
+	pref, err := testQueries.UpsertNotificationPreference(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, pref.Owner)
+	require.Equal(t, arg.Email, pref.Email)
+	require.True(t, pref.EmailEnabled)
+	require.False(t, pref.SmsEnabled)
+	require.Equal(t, arg.LowBalanceThreshold, pref.LowBalanceThreshold)
+	require.False(t, pref.LargeDebitThreshold.Valid)
+	require.Equal(t, arg.ThresholdCurrency, pref.ThresholdCurrency)
+
+	// Saving again replaces the preferences
+	arg.EmailEnabled = false
+	arg.LargeDebitThreshold = sql.NullInt64{Int64: 5000, Valid: true}
+	_, err = testQueries.UpsertNotificationPreference(context.Background(), arg)
+	require.NoError(t, err)
+
+	pref, err = testQueries.GetNotificationPreference(context.Background(), arg.Owner)
+	require.NoError(t, err)
+	require.False(t, pref.EmailEnabled)
+	require.Equal(t, arg.LargeDebitThreshold, pref.LargeDebitThreshold)
+
+	// Thresholds need a currency
+	arg.ThresholdCurrency = sql.NullString{}
+	_, err = testQueries.UpsertNotificationPreference(context.Background(), arg)
+	require.Error(t, err)
+
+	// Delete the preferences
+	require.NoError(t, testQueries.DeleteNotificationPreference(context.Background(), arg.Owner))
+	_, err = testQueries.GetNotificationPreference(context.Background(), arg.Owner)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestNotificationDelivery(t *testing.T) {
+	transferID := util.RandomInt(1_000_000, 1_000_000_000)
+
+	deliveries, err := testQueries.ListNotificationDeliveries(context.Background(), transferID)
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+
+	// Recording a delivery twice keeps a single row
+	arg := CreateNotificationDeliveryParams{TransferID: transferID, Kind: "large_debit", Channel: "email"}
+	require.NoError(t, testQueries.CreateNotificationDelivery(context.Background(), arg))
+	require.NoError(t, testQueries.CreateNotificationDelivery(context.Background(), arg))
+
+	deliveries, err = testQueries.ListNotificationDeliveries(context.Background(), transferID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, arg.Kind, deliveries[0].Kind)
+	require.Equal(t, arg.Channel, deliveries[0].Channel)
+}
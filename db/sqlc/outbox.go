@@ -0,0 +1,121 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Aggregate types of the domain events
+const (
+	AggregateAccount  = "account"
+	AggregateTransfer = "transfer"
+)
+
+// Domain event types written to the outbox
+const (
+	EventAccountCreated    = "AccountCreated"
+	EventTransferCompleted = "TransferCompleted"
+	EventDepositMade       = "DepositMade"
+	EventWithdrawalMade    = "WithdrawalMade"
+	EventInterestPosted    = "InterestPosted"
+)
+
+// Helper method: write a domain event to the outbox. It must be called with the Queries of the transaction
+// that performs the change, so the event is stored if and only if the change is committed
+func recordEvent(ctx context.Context, q *Queries, aggregateType string, aggregateID int64, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+	})
+	return err
+}
+
+// Method to publish a batch of pending outbox events, oldest first. The batch is claimed with a lease in a short
+// transaction, then published outside of it, so no lock or connection is held while publish talks to the broker.
+// publish gets a context ending with the lease; once the lease is over, the rest of the batch is left to the next
+// claim. Concurrent relays never publish the same events, nor the events of an aggregate out of order.
+//
+// An event is marked as published only after publish returns nil, which gives at-least-once delivery. When an event
+// fails, the remaining events of the same aggregate in the batch are skipped so they are never delivered out of order.
+// The events not published are released for the next claim. It returns the number of events published.
+func (store *SQLStore) PublishOutbox(
+	ctx context.Context,
+	batchSize int32,
+	lease time.Duration,
+	publish func(ctx context.Context, event OutboxEvent) error,
+) (int, error) {
+	// Postgres keeps microseconds, the lease is compared as stored when releasing the events
+	lockedUntil := sql.NullTime{Time: time.Now().Add(lease).Truncate(time.Microsecond), Valid: true}
+
+	var events []OutboxEvent
+	err := store.execTx(ctx, func(q *Queries) error {
+		if err := q.LockOutboxClaims(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		events, err = q.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams{
+			LockedUntil: lockedUntil,
+			Limit:       batchSize,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// RETURNING doesn't keep the order of the claim
+	slices.SortFunc(events, func(a, b OutboxEvent) int {
+		return cmp.Compare(a.EventID, b.EventID)
+	})
+
+	publishCtx, cancel := context.WithDeadline(ctx, lockedUntil.Time)
+	defer cancel()
+
+	type aggregate struct {
+		kind string
+		id   int64
+	}
+	failed := make(map[aggregate]bool)
+	var unpublished []int64
+	published := 0
+
+	for _, event := range events {
+		key := aggregate{event.AggregateType, event.AggregateID}
+		if failed[key] || publishCtx.Err() != nil {
+			unpublished = append(unpublished, event.EventID)
+			continue
+		}
+
+		if err := publish(publishCtx, event); err != nil {
+			failed[key] = true
+			unpublished = append(unpublished, event.EventID)
+			continue
+		}
+
+		if err := store.MarkOutboxEventPublished(ctx, event.EventID); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	if len(unpublished) > 0 {
+		err = store.ReleaseOutboxEvents(ctx, ReleaseOutboxEventsParams{
+			EventIds:    unpublished,
+			LockedUntil: lockedUntil,
+		})
+	}
+
+	return published, err
+}
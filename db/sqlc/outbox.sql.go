@@ -0,0 +1,170 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE outbox_event
+SET locked_until = $1
+WHERE event_id IN (
+    SELECT event.event_id FROM outbox_event AS event
+    WHERE event.published_at IS NULL
+        AND (event.locked_until IS NULL OR event.locked_until <= now())
+        AND NOT EXISTS (
+            SELECT 1 FROM outbox_event AS older
+            WHERE older.aggregate_type = event.aggregate_type
+                AND older.aggregate_id = event.aggregate_id
+                AND older.event_id < event.event_id
+                AND older.published_at IS NULL
+                AND older.locked_until > now()
+        )
+    ORDER BY event.event_id
+    LIMIT $2
+)
+RETURNING event_id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, locked_until
+`
+
+type ClaimOutboxEventsParams struct {
+	LockedUntil sql.NullTime `json:"locked_until"`
+	Limit       int32        `json:"limit"`
+}
+
+// Lease a batch of unpublished events, oldest first. The events of an aggregate with an older event leased by
+// another relay are left out, so the events of an aggregate are never published out of order
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, arg.LockedUntil, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.EventID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.LockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox_event (
+    aggregate_type,
+    aggregate_id,
+    event_type,
+    payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING event_id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, locked_until
+`
+
+type CreateOutboxEventParams struct {
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, createOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+	)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.EventID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const getOutboxEvent = `-- name: GetOutboxEvent :one
+SELECT event_id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, locked_until FROM outbox_event
+WHERE event_id = $1
+`
+
+func (q *Queries) GetOutboxEvent(ctx context.Context, eventID int64) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, getOutboxEvent, eventID)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.EventID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const lockOutboxClaims = `-- name: LockOutboxClaims :exec
+SELECT pg_advisory_xact_lock(hashtext('outbox_event'))
+`
+
+// Serialize the claims until the end of the transaction, so two relays never take parts of the same aggregate
+func (q *Queries) LockOutboxClaims(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockOutboxClaims)
+	return err
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_event
+SET published_at = now(),
+    locked_until = NULL
+WHERE event_id = $1
+`
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, eventID int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventPublished, eventID)
+	return err
+}
+
+const releaseOutboxEvents = `-- name: ReleaseOutboxEvents :exec
+UPDATE outbox_event
+SET locked_until = NULL
+WHERE event_id = ANY($1::bigint[])
+    AND published_at IS NULL
+    AND locked_until = $2
+`
+
+type ReleaseOutboxEventsParams struct {
+	EventIds    []int64      `json:"event_ids"`
+	LockedUntil sql.NullTime `json:"locked_until"`
+}
+
+// Give back the events of a lease that weren't published, unless the lease already ended and another relay took them
+func (q *Queries) ReleaseOutboxEvents(ctx context.Context, arg ReleaseOutboxEventsParams) error {
+	_, err := q.db.Exec(ctx, releaseOutboxEvents, arg.EventIds, arg.LockedUntil)
+	return err
+}
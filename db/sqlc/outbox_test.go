@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gobank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Helper method: publish outbox batches until nothing is left to publish, and return the events of the given
+// aggregate that were handed to the publisher, in order
+func drainOutbox(t *testing.T, store Store, aggregateType string, aggregateID int64, publish func(OutboxEvent) error) []OutboxEvent {
+	var seen []OutboxEvent
+
+	for {
+		n, err := store.PublishOutbox(context.Background(), 100, time.Minute, func(ctx context.Context, event OutboxEvent) error {
+			if event.AggregateType != aggregateType || event.AggregateID != aggregateID {
+				return nil
+			}
+			seen = append(seen, event)
+			return publish(event)
+		})
+		require.NoError(t, err)
+
+		if n == 0 {
+			return seen
+		}
+	}
+}
+
+func TestCreateAccountTxEvent(t *testing.T) {
+	store := NewStore(conn)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Balance:     0,
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(t, err)
+
+	events := drainOutbox(t, store, AggregateAccount, account.AccountID, func(OutboxEvent) error { return nil })
+	require.Len(t, events, 1)
+	require.Equal(t, EventAccountCreated, events[0].EventType)
+
+	var payload Account
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, account.AccountID, payload.AccountID)
+	require.Equal(t, account.Owner, payload.Owner)
+
+	// The event is marked as published
+	event, err := store.GetOutboxEvent(context.Background(), events[0].EventID)
+	require.NoError(t, err)
+	require.True(t, event.PublishedAt.Valid)
+}
+
+func TestTransferTxEvent(t *testing.T) {
+	store := NewStore(conn)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+	fundAccountMock(t, &acc1, 10)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	events := drainOutbox(t, store, AggregateTransfer, result.Transfer.TransferID, func(OutboxEvent) error { return nil })
+	require.Len(t, events, 1)
+	require.Equal(t, EventTransferCompleted, events[0].EventType)
+
+	var payload TransferTxResult
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, result.Transfer.TransferID, payload.Transfer.TransferID)
+	require.Equal(t, result.FromEntry.EntryID, payload.FromEntry.EntryID)
+	require.Equal(t, result.ToEntry.EntryID, payload.ToEntry.EntryID)
+}
+
+func TestPublishOutboxOrdering(t *testing.T) {
+	store := NewStore(conn)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Balance:     0,
+		Currency:    util.EUR,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DepositTx(context.Background(), DepositTxParams{AccountID: account.AccountID, Amount: 100})
+	require.NoError(t, err)
+
+	_, err = store.WithdrawTx(context.Background(), WithdrawTxParams{AccountID: account.AccountID, Amount: 40})
+	require.NoError(t, err)
+
+	// When the first event of the account fails, the later ones must not be published before it
+	events := drainOutbox(t, store, AggregateAccount, account.AccountID, func(event OutboxEvent) error {
+		return errors.New("publisher unavailable")
+	})
+	require.Len(t, events, 1)
+	require.Equal(t, EventAccountCreated, events[0].EventType)
+
+	// Once the publisher recovers, every event is published in order
+	events = drainOutbox(t, store, AggregateAccount, account.AccountID, func(OutboxEvent) error { return nil })
+	require.Len(t, events, 3)
+	require.Equal(t, EventAccountCreated, events[0].EventType)
+	require.Equal(t, EventDepositMade, events[1].EventType)
+	require.Equal(t, EventWithdrawalMade, events[2].EventType)
+
+	// Nothing left to publish for the account
+	events = drainOutbox(t, store, AggregateAccount, account.AccountID, func(OutboxEvent) error { return nil })
+	require.Empty(t, events)
+}
+
+func TestPublishOutboxLease(t *testing.T) {
+	store := NewStore(conn)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Balance:     0,
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(t, err)
+
+	// While the first event is being published, another relay gets neither it nor the newer events of the account
+	var others []OutboxEvent
+	events := drainOutbox(t, store, AggregateAccount, account.AccountID, func(event OutboxEvent) error {
+		if event.EventType != EventAccountCreated {
+			return nil
+		}
+
+		_, err := store.DepositTx(context.Background(), DepositTxParams{AccountID: account.AccountID, Amount: 10})
+		require.NoError(t, err)
+
+		_, err = store.PublishOutbox(context.Background(), 100, time.Minute, func(ctx context.Context, other OutboxEvent) error {
+			if other.AggregateType == AggregateAccount && other.AggregateID == account.AccountID {
+				others = append(others, other)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return nil
+	})
+	require.Empty(t, others)
+
+	// The events are still published once each, in order
+	require.Len(t, events, 2)
+	require.Equal(t, EventAccountCreated, events[0].EventType)
+	require.Equal(t, EventDepositMade, events[1].EventType)
+}
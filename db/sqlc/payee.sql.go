@@ -0,0 +1,194 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: payee.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createPayee = `-- name: CreatePayee :one
+INSERT INTO payees (
+    owner,
+    nickname,
+    account_id,
+    owner_name
+)
+SELECT $1::varchar, $2::varchar, account.account_id, COALESCE(users.full_name, account.owner)
+FROM account
+LEFT JOIN users ON users.username = account.owner
+WHERE account.account_number = $3
+  AND lower(trim(COALESCE(users.full_name, account.owner))) = lower(trim($4::varchar))
+  AND account.account_id NOT IN (SELECT account_id FROM bank_account)
+RETURNING payee_id, owner, nickname, account_id, owner_name, created_at
+`
+
+type CreatePayeeParams struct {
+	Owner         string `json:"owner"`
+	Nickname      string `json:"nickname"`
+	AccountNumber string `json:"account_number"`
+	OwnerName     string `json:"owner_name"`
+}
+
+// Confirmation of payee: the account is looked up by its number and only saved when the given name matches the name
+// of its owner, ignoring case and surrounding spaces. That name, snapshotted as owner_name, is the full name of the
+// owner when they are a user, the owner itself otherwise. No row is returned when the account doesn't exist or the
+// name doesn't match, so the two can't be told apart. The accounts of the bank itself can't be saved
+func (q *Queries) CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, createPayee,
+		arg.Owner,
+		arg.Nickname,
+		arg.AccountNumber,
+		arg.OwnerName,
+	)
+	var i Payee
+	err := row.Scan(
+		&i.PayeeID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.OwnerName,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deletePayee = `-- name: DeletePayee :one
+DELETE FROM payees
+WHERE payee_id = $1
+  AND owner = $2
+RETURNING payee_id, owner, nickname, account_id, owner_name, created_at
+`
+
+type DeletePayeeParams struct {
+	PayeeID int64  `json:"payee_id"`
+	Owner   string `json:"owner"`
+}
+
+func (q *Queries) DeletePayee(ctx context.Context, arg DeletePayeeParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, deletePayee, arg.PayeeID, arg.Owner)
+	var i Payee
+	err := row.Scan(
+		&i.PayeeID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.OwnerName,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPayee = `-- name: GetPayee :one
+SELECT payee_id, owner, nickname, account_id, owner_name, created_at FROM payees
+WHERE payee_id = $1
+  AND owner = $2
+`
+
+type GetPayeeParams struct {
+	PayeeID int64  `json:"payee_id"`
+	Owner   string `json:"owner"`
+}
+
+func (q *Queries) GetPayee(ctx context.Context, arg GetPayeeParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, getPayee, arg.PayeeID, arg.Owner)
+	var i Payee
+	err := row.Scan(
+		&i.PayeeID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.OwnerName,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPayeeByAccount = `-- name: GetPayeeByAccount :one
+SELECT payee_id, owner, nickname, account_id, owner_name, created_at FROM payees
+WHERE owner = $1
+  AND account_id = $2
+`
+
+type GetPayeeByAccountParams struct {
+	Owner     string `json:"owner"`
+	AccountID int64  `json:"account_id"`
+}
+
+func (q *Queries) GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, getPayeeByAccount, arg.Owner, arg.AccountID)
+	var i Payee
+	err := row.Scan(
+		&i.PayeeID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.OwnerName,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listPayees = `-- name: ListPayees :many
+SELECT payee_id, owner, nickname, account_id, owner_name, created_at FROM payees
+WHERE owner = $1
+ORDER BY nickname, payee_id
+`
+
+func (q *Queries) ListPayees(ctx context.Context, owner string) ([]Payee, error) {
+	rows, err := q.db.Query(ctx, listPayees, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payee{}
+	for rows.Next() {
+		var i Payee
+		if err := rows.Scan(
+			&i.PayeeID,
+			&i.Owner,
+			&i.Nickname,
+			&i.AccountID,
+			&i.OwnerName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePayeeNickname = `-- name: UpdatePayeeNickname :one
+UPDATE payees
+SET nickname = $3
+WHERE payee_id = $1
+  AND owner = $2
+RETURNING payee_id, owner, nickname, account_id, owner_name, created_at
+`
+
+type UpdatePayeeNicknameParams struct {
+	PayeeID  int64  `json:"payee_id"`
+	Owner    string `json:"owner"`
+	Nickname string `json:"nickname"`
+}
+
+// Only the nickname can change: pointing a payee to another account would skip the cooling-off period
+func (q *Queries) UpdatePayeeNickname(ctx context.Context, arg UpdatePayeeNicknameParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, updatePayeeNickname, arg.PayeeID, arg.Owner, arg.Nickname)
+	var i Payee
+	err := row.Scan(
+		&i.PayeeID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.OwnerName,
+		&i.CreatedAt,
+	)
+	return i, err
+}
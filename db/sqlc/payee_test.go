@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"gobank/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayees(t *testing.T) {
+	user := createUserMock(t)
+	owner := util.RandomString(8)
+
+	// The owner name is the full name of the account owner when they are a user
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       user.Username,
+		Balance:     0,
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(t, err)
+
+	payee, err := testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:         owner,
+		Nickname:      "Landlord",
+		AccountNumber: account.AccountNumber,
+		OwnerName:     " " + strings.ToUpper(user.FullName) + " ",
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.AccountID, payee.AccountID)
+	require.Equal(t, user.FullName, payee.OwnerName)
+	require.NotZero(t, payee.CreatedAt)
+
+	// Otherwise it is the owner itself
+	other := createAccountMock(t)
+	otherPayee, err := testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:         owner,
+		Nickname:      "Gym",
+		AccountNumber: other.AccountNumber,
+		OwnerName:     other.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, other.Owner, otherPayee.OwnerName)
+
+	// An account can only be saved once
+	_, err = testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:         owner,
+		Nickname:      "Again",
+		AccountNumber: account.AccountNumber,
+		OwnerName:     user.FullName,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// The account must exist and the name must match its owner
+	_, err = testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:         owner,
+		Nickname:      "Nobody",
+		AccountNumber: "0",
+		OwnerName:     user.FullName,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	stranger := createAccountMock(t)
+	_, err = testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:         owner,
+		Nickname:      "Guess",
+		AccountNumber: stranger.AccountNumber,
+		OwnerName:     user.FullName,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// The accounts of the bank can't be saved
+	clearing := getCashClearingMock(t, util.USD)
+	_, err = testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:         owner,
+		Nickname:      "Bank",
+		AccountNumber: clearing.AccountNumber,
+		OwnerName:     BankOwner,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// Listed by nickname
+	payees, err := testQueries.ListPayees(context.Background(), owner)
+	require.NoError(t, err)
+	require.Len(t, payees, 2)
+	require.Equal(t, otherPayee.PayeeID, payees[0].PayeeID)
+	require.Equal(t, payee.PayeeID, payees[1].PayeeID)
+
+	// Payees are private to their owner
+	_, err = testQueries.GetPayee(context.Background(), GetPayeeParams{PayeeID: payee.PayeeID, Owner: user.Username})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	renamed, err := testQueries.UpdatePayeeNickname(context.Background(), UpdatePayeeNicknameParams{
+		PayeeID:  payee.PayeeID,
+		Owner:    owner,
+		Nickname: "Rent",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Rent", renamed.Nickname)
+	require.Equal(t, payee.AccountID, renamed.AccountID)
+	require.Equal(t, payee.CreatedAt, renamed.CreatedAt)
+
+	_, err = testQueries.DeletePayee(context.Background(), DeletePayeeParams{PayeeID: payee.PayeeID, Owner: owner})
+	require.NoError(t, err)
+	_, err = testQueries.GetPayee(context.Background(), GetPayeeParams{PayeeID: payee.PayeeID, Owner: owner})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
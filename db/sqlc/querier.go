@@ -6,26 +6,128 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	AccrueAccountInterest(ctx context.Context, arg AccrueAccountInterestParams) (Account, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	ApproveAccountOwner(ctx context.Context, arg ApproveAccountOwnerParams) (AccountOwner, error)
+	// Lease a batch of due deliveries by moving their next attempt to leased_until: other workers skip them until then,
+	// and they are picked up again if the worker dies before recording the outcome
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]ClaimDueWebhookDeliveriesRow, error)
+	// Lease a batch of unpublished events, oldest first. The events of an aggregate with an older event leased by
+	// another relay are left out, so the events of an aggregate are never published out of order
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error)
+	ClaimTasks(ctx context.Context, arg ClaimTasksParams) ([]Task, error)
+	// Completing, retrying and killing a task only apply while the lease it was claimed with is still held. Once it
+	// expires, another worker may have claimed the task again, and no row is updated
+	CompleteTask(ctx context.Context, arg CompleteTaskParams) (int64, error)
+	// Bulk insert with COPY, used to seed databases. It bypasses the outbox, so no AccountCreated event is recorded
+	CopyAccounts(ctx context.Context, arg []CopyAccountsParams) (int64, error)
+	// Bulk insert with COPY, used to seed databases
+	CopyUsers(ctx context.Context, arg []CopyUsersParams) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountOwner(ctx context.Context, arg CreateAccountOwnerParams) (AccountOwner, error)
+	// Same as CreateEntry, but all the entries are sent to the database in a single round trip
+	CreateEntries(ctx context.Context, arg []CreateEntriesParams) *CreateEntriesBatchResults
+	// The counterparty name is snapshotted from the optional counterparty account
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	// Delivering twice is harmless here, the row only has to exist
+	CreateNotificationDelivery(ctx context.Context, arg CreateNotificationDeliveryParams) error
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error)
+	// Confirmation of payee: the account is looked up by its number and only saved when the given name matches the name
+	// of its owner, ignoring case and surrounding spaces. That name, snapshotted as owner_name, is the full name of the
+	// owner when they are a user, the owner itself otherwise. No row is returned when the account doesn't exist or the
+	// name doesn't match, so the two can't be told apart. The accounts of the bank itself can't be saved
+	CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error)
+	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transfer, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error)
 	DeleteAccount(ctx context.Context, accountID int64) error
+	DeleteAccountOwner(ctx context.Context, arg DeleteAccountOwnerParams) (AccountOwner, error)
 	DeleteEntry(ctx context.Context, entryID int64) error
+	DeleteNotificationPreference(ctx context.Context, owner string) error
+	DeletePayee(ctx context.Context, arg DeletePayeeParams) (Payee, error)
 	DeleteTransaction(ctx context.Context, transferID int64) error
+	DeleteWebhookSubscription(ctx context.Context, arg DeleteWebhookSubscriptionParams) (int64, error)
 	GetAccount(ctx context.Context, accountID int64) (Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error)
 	GetAccountForUpdate(ctx context.Context, accountID int64) (Account, error)
+	// The permission of a user on an account: manage for the primary owner, the permission of an active co-owner
+	// otherwise. No row is returned when the user can't access the account. Nobody has access to the accounts of the
+	// bank itself, they are only moved by the bank's own transactions
+	GetAccountPermission(ctx context.Context, arg GetAccountPermissionParams) (string, error)
+	GetBankAccount(ctx context.Context, arg GetBankAccountParams) (int64, error)
 	GetEntry(ctx context.Context, entryID int64) (Entry, error)
+	GetNotificationPreference(ctx context.Context, owner string) (NotificationPreference, error)
+	GetOutboxEvent(ctx context.Context, eventID int64) (OutboxEvent, error)
+	GetPayee(ctx context.Context, arg GetPayeeParams) (Payee, error)
+	GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error)
+	GetTask(ctx context.Context, taskID int64) (Task, error)
 	GetTransaction(ctx context.Context, transferID int64) (Transfer, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	GetWebhookDelivery(ctx context.Context, arg GetWebhookDeliveryParams) (WebhookDelivery, error)
+	GetWebhookSubscription(ctx context.Context, arg GetWebhookSubscriptionParams) (WebhookSubscription, error)
+	KillTask(ctx context.Context, arg KillTaskParams) (int64, error)
+	// Every filter is optional. The accounts must hold every given label (key and value) and every given label key, and
+	// be owned or co-owned (actively) by the given member
 	ListAccount(ctx context.Context, arg ListAccountParams) ([]Account, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error)
+	ListAccountOwners(ctx context.Context, accountID int64) ([]AccountOwner, error)
+	// Savings and overdrawable accounts whose interest hasn't been accrued through the given day yet, or accrued through
+	// the end of a month but not posted. The accounts of the bank itself go negative by design and are never charged
+	ListAccountsDueForInterest(ctx context.Context, arg ListAccountsDueForInterestParams) ([]int64, error)
+	ListDeadTasks(ctx context.Context, arg ListDeadTasksParams) ([]Task, error)
 	ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error)
+	ListNotificationDeliveries(ctx context.Context, transferID int64) ([]NotificationDelivery, error)
+	ListPayees(ctx context.Context, owner string) ([]Payee, error)
 	ListTransaction(ctx context.Context, arg ListTransactionParams) ([]Transfer, error)
+	// Transfers with the given end-to-end reference from or to an account the member owns or actively co-owns
+	ListTransfersByReference(ctx context.Context, arg ListTransfersByReferenceParams) ([]Transfer, error)
+	// A transfer is balanced when it has exactly one debit entry on the from account and one credit entry on the
+	// to account, both for the transfer amount
+	ListUnbalancedTransfers(ctx context.Context, since time.Time) ([]ListUnbalancedTransfersRow, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookSubscriptions(ctx context.Context, owner string) ([]WebhookSubscription, error)
+	ListWebhookSubscriptionsForEvent(ctx context.Context, arg ListWebhookSubscriptionsForEventParams) ([]WebhookSubscription, error)
+	// Serialize the claims until the end of the transaction, so two relays never take parts of the same aggregate
+	LockOutboxClaims(ctx context.Context) error
+	MarkOutboxEventPublished(ctx context.Context, eventID int64) error
+	// Only the remainder that couldn't be posted is kept, it is carried over to the next month
+	PostAccountInterest(ctx context.Context, arg PostAccountInterestParams) (Account, error)
+	// Give back the events of a lease that weren't published, unless the lease already ended and another relay took them
+	ReleaseOutboxEvents(ctx context.Context, arg ReleaseOutboxEventsParams) error
+	// Give back leased deliveries that weren't attempted, e.g. on shutdown, without counting an attempt
+	ReleaseWebhookDeliveries(ctx context.Context, arg ReleaseWebhookDeliveriesParams) error
+	ReplayWebhookDelivery(ctx context.Context, arg ReplayWebhookDeliveryParams) (WebhookDelivery, error)
+	RequeueDeadTask(ctx context.Context, taskID int64) (Task, error)
+	RetryTask(ctx context.Context, arg RetryTaskParams) (int64, error)
+	// Full-text search of the entries of the accounts the member owns or actively co-owns, best matches first. The
+	// query uses the web search syntax ("quoted phrases", or, -excluded). Matched terms are wrapped in \x02 and \x03 in
+	// the highlights, which are the whole texts
+	SearchEntries(ctx context.Context, arg SearchEntriesParams) ([]SearchEntriesRow, error)
+	SumAccountEntriesSince(ctx context.Context, arg SumAccountEntriesSinceParams) (int64, error)
+	// Only updates the account if it is still at the expected version, otherwise no row is returned
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	// Only the given fields are changed
+	UpdateAccountDetails(ctx context.Context, arg UpdateAccountDetailsParams) (Account, error)
+	UpdateAccountInterestRate(ctx context.Context, arg UpdateAccountInterestRateParams) (Account, error)
+	// Accounts other than savings never accrued interest before, they start accruing from today instead of the day they
+	// were opened
+	UpdateAccountOverdraft(ctx context.Context, arg UpdateAccountOverdraftParams) (Account, error)
+	// Only updates the account if it is still at the expected version, otherwise no row is returned
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
 	UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error)
+	// Only the nickname can change: pointing a payee to another account would skip the cooling-off period
+	UpdatePayeeNickname(ctx context.Context, arg UpdatePayeeNicknameParams) (Payee, error)
 	UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) (Transfer, error)
+	// Only applies while the lease the delivery was claimed with is still held. Once it expires, another worker may have
+	// claimed the delivery again, and no row is updated
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (int64, error)
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (NotificationPreference, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReplicaStore routes the read-only queries that can tolerate slightly stale data to a read replica, while writes
+// and transactions go to the primary. When the replica lags behind the primary by more than maxLag, or can't be
+// reached, reads fall back to the primary until it catches up.
+//
+// GetAccount stays on the primary: its version drives the ETag checked by If-Match and the access checks, so a
+// stale row would fail conditional updates or hide an account right after it was created
+type ReplicaStore struct {
+	Store
+	replica *Queries
+	logger  *slog.Logger
+	maxLag  time.Duration
+
+	// Whether reads currently go to the primary. Starts true, until the first lag check succeeds
+	fallback atomic.Bool
+}
+
+// Constructor method for ReplicaStore. The lag is only checked once Start runs, reads go to the primary until then
+func NewReplicaStore(primary Store, replica *pgxpool.Pool, maxLag time.Duration, logger *slog.Logger) *ReplicaStore {
+	store := &ReplicaStore{
+		Store:   primary,
+		replica: New(newTracedDBTX(replica)),
+		logger:  logger,
+		maxLag:  maxLag,
+	}
+	store.fallback.Store(true)
+	return store
+}
+
+// The lag is 0 when the replica has replayed everything it received, otherwise the age of the last replayed
+// transaction. On a primary, the functions return NULL and the lag is 0
+const getReplicationLag = `-- name: GetReplicationLag :one
+SELECT COALESCE(
+    CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+         ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+    END, 0)::float8
+`
+
+// Method to measure the replication lag and decide whether reads go to the replica
+func (store *ReplicaStore) CheckLag(ctx context.Context) (time.Duration, error) {
+	var seconds float64
+	err := store.replica.db.QueryRow(ctx, getReplicationLag).Scan(&seconds)
+	lag := time.Duration(seconds * float64(time.Second))
+
+	fallback := err != nil || lag > store.maxLag
+	if previous := store.fallback.Swap(fallback); previous != fallback {
+		if fallback {
+			store.logger.Warn("Read replica unavailable or lagging, reading from primary", "lag", lag, "error", err)
+		} else {
+			store.logger.Info("Read replica caught up, reading from replica", "lag", lag)
+		}
+	}
+
+	return lag, err
+}
+
+// Method to check the replication lag every interval until the context is cancelled
+func (store *ReplicaStore) Start(ctx context.Context, interval time.Duration) {
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		store.CheckLag(checkCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Helper method: get the queries to run reads on
+func (store *ReplicaStore) reader() Querier {
+	if store.fallback.Load() {
+		return store.Store
+	}
+	return store.replica
+}
+
+func (store *ReplicaStore) ListAccount(ctx context.Context, arg ListAccountParams) ([]Account, error) {
+	return store.reader().ListAccount(ctx, arg)
+}
+
+func (store *ReplicaStore) ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error) {
+	return store.reader().ListEntry(ctx, arg)
+}
+
+func (store *ReplicaStore) ListTransaction(ctx context.Context, arg ListTransactionParams) ([]Transfer, error) {
+	return store.reader().ListTransaction(ctx, arg)
+}
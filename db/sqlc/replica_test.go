@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaStore(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	primary := NewStore(conn)
+
+	// The test database isn't a replica, so its lag is 0
+	store := NewReplicaStore(primary, conn, time.Second, logger)
+	require.Equal(t, primary, store.reader())
+
+	lag, err := store.CheckLag(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, lag)
+	require.Equal(t, store.replica, store.reader())
+
+	account := createAccountMock(t)
+	accounts, err := store.ListAccount(context.Background(), ListAccountParams{
+		Member: sql.NullString{String: account.Owner, Valid: true},
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+
+	// Writes and transactions always go to the primary
+	_, err = store.DepositTx(context.Background(), DepositTxParams{AccountID: account.AccountID, Amount: 10})
+	require.NoError(t, err)
+
+	// So do account reads, whose version must reflect the write at once
+	res, err := store.GetAccount(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+10, res.Balance)
+	require.Equal(t, account.Version+1, res.Version)
+}
+
+func TestReplicaStoreFallback(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	primary := NewStore(conn)
+
+	// A replica that can't be queried makes reads fall back to the primary
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	store := NewReplicaStore(primary, conn, time.Second, logger)
+	store.fallback.Store(false)
+
+	_, err := store.CheckLag(ctx)
+	require.Error(t, err)
+	require.Equal(t, primary, store.reader())
+}
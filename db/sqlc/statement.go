@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Number of entries fetched per round trip while streaming a statement
+const statementPageSize = 500
+
+// Parameter struct for generating an account statement. The period is [From, To)
+type StatementTxParams struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// Result struct passed to the statement header callback
+type StatementTxResult struct {
+	Account        Account   `json:"account"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+}
+
+// Method to stream an account statement. The header callback receives the account with its opening and closing
+// balances for the period, then the line callback is called once for each entry in the period, in booking order.
+//
+// Everything runs in a single read-only, repeatable read transaction, so the balances always agree with the
+// entries even when transfers are committed while the statement is being streamed. The transaction and its
+// connection are held until the last line is written, so callers should limit how many run at once.
+func (store *SQLStore) StatementTx(
+	ctx context.Context,
+	arg StatementTxParams,
+	header func(StatementTxResult) error,
+	line func(ListAccountEntriesRow) error,
+) error {
+	opts := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+
+	return store.execTxOptions(ctx, opts, func(q *Queries) error {
+		var err error
+		result := StatementTxResult{From: arg.From, To: arg.To}
+
+		result.Account, err = q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		// The account balance is the sum of every entry, so the balance at any point in time is
+		// the current balance minus whatever has been booked since then
+		sinceFrom, err := q.SumAccountEntriesSince(ctx, SumAccountEntriesSinceParams{
+			AccountID: arg.AccountID,
+			Since:     sql.NullTime{Time: arg.From, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		sinceTo, err := q.SumAccountEntriesSince(ctx, SumAccountEntriesSinceParams{
+			AccountID: arg.AccountID,
+			Since:     sql.NullTime{Time: arg.To, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.OpeningBalance = result.Account.Balance - sinceFrom
+		result.ClosingBalance = result.Account.Balance - sinceTo
+
+		if err := header(result); err != nil {
+			return err
+		}
+
+		// Page through the entries with keyset pagination so large periods are never held in memory
+		var afterEntryID int64
+		for {
+			entries, err := q.ListAccountEntries(ctx, ListAccountEntriesParams{
+				AccountID:    arg.AccountID,
+				FromTime:     sql.NullTime{Time: arg.From, Valid: true},
+				ToTime:       sql.NullTime{Time: arg.To, Valid: true},
+				AfterEntryID: afterEntryID,
+				PageSize:     statementPageSize,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range entries {
+				if err := line(entry); err != nil {
+					return err
+				}
+			}
+
+			if len(entries) < statementPageSize {
+				return nil
+			}
+			afterEntryID = entries[len(entries)-1].EntryID
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"gobank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementTx(t *testing.T) {
+	// Create a store
+	store := NewStore(conn)
+
+	// Create 2 mock account for testing
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+
+	// Transfer some money back and forth
+	n := 5
+	amount := util.RandomInt(1, 200)
+	fundAccountMock(t, &acc1, int64(n)*amount)
+
+	from := time.Now().Add(-time.Minute)
+	for i := range n {
+		arg := TransferTxParams{FromAccountID: acc1.AccountID, ToAccountID: acc2.AccountID, Amount: amount}
+		if i%2 == 1 {
+			arg = TransferTxParams{FromAccountID: acc2.AccountID, ToAccountID: acc1.AccountID, Amount: amount}
+		}
+
+		_, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+	to := time.Now().Add(time.Minute)
+
+	// Stream the statement of account 1
+	var header StatementTxResult
+	var lines []ListAccountEntriesRow
+	err := store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: acc1.AccountID,
+		From:      from,
+		To:        to,
+	}, func(result StatementTxResult) error {
+		header = result
+		return nil
+	}, func(line ListAccountEntriesRow) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Account 1 sent 3 transfers and received 2, so it should be down by one transfer
+	require.Equal(t, acc1.AccountID, header.Account.AccountID)
+	require.Equal(t, acc1.Balance, header.OpeningBalance)
+	require.Equal(t, acc1.Balance-amount, header.ClosingBalance)
+	require.Len(t, lines, n)
+
+	balance := header.OpeningBalance
+	for _, line := range lines {
+		// Every entry comes from a transfer with account 2
+		require.True(t, line.TransferID.Valid)
+		require.ElementsMatch(t,
+			[]int64{acc1.AccountID, acc2.AccountID},
+			[]int64{line.FromAccountID.Int64, line.ToAccountID.Int64})
+		balance += line.Amount
+	}
+	require.Equal(t, header.ClosingBalance, balance)
+
+	// A period entirely after the transfers has no entries, and both balances equal the current balance
+	lines = nil
+	err = store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: acc1.AccountID,
+		From:      to,
+		To:        to.Add(time.Hour),
+	}, func(result StatementTxResult) error {
+		header = result
+		return nil
+	}, func(line ListAccountEntriesRow) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Empty(t, lines)
+	require.Equal(t, acc1.Balance-amount, header.OpeningBalance)
+	require.Equal(t, acc1.Balance-amount, header.ClosingBalance)
+}
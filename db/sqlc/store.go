@@ -3,50 +3,210 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrAccountFrozen     = errors.New("account is frozen")
+	ErrVersionConflict   = errors.New("account was modified concurrently")
+)
+
+// Account statuses. A frozen account can't send or receive money
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+)
+
+// Account types, chosen by the customer. They don't change how the account works
+const (
+	AccountTypeChecking = "checking"
+	AccountTypeSavings  = "savings"
+	AccountTypeBusiness = "business"
 )
 
 type Store interface {
 	Querier
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (SchemaMigration, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	DepositTx(ctx context.Context, arg DepositTxParams) (DepositTxResult, error)
+	WithdrawTx(ctx context.Context, arg WithdrawTxParams) (WithdrawTxResult, error)
+	UpdateAccountDetailsTx(ctx context.Context, arg UpdateAccountDetailsTxParams) (Account, error)
+	AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error)
+	PostInterestTx(ctx context.Context, accountID int64) (PostInterestTxResult, error)
+	PublishOutbox(
+		ctx context.Context,
+		batchSize int32,
+		lease time.Duration,
+		publish func(ctx context.Context, event OutboxEvent) error,
+	) (int, error)
+	ProcessWebhookDeliveries(
+		ctx context.Context,
+		batchSize int32,
+		lease time.Duration,
+		deliver func(ClaimDueWebhookDeliveriesRow) UpdateWebhookDeliveryAttemptParams,
+	) (int, error)
+	StatementTx(ctx context.Context, arg StatementTxParams, header func(StatementTxResult) error, line func(ListAccountEntriesRow) error) error
+}
+
+// Retry policy of the transactions failing with a serialization failure or a deadlock. Postgres aborts one of the
+// conflicting transactions, which succeeds once run again
+type TxRetry struct {
+	// Maximum number of times a transaction runs, including the first attempt. 1 disables retries
+	MaxAttempts int
+
+	// Backoff before the first retry, doubled after each one. The actual sleep is jittered between half and the
+	// full backoff, so the conflicting transactions don't retry in lockstep
+	Backoff time.Duration
+
+	// Optional hook called before each retry with the SQLSTATE of the failure, e.g. to count retries
+	OnRetry func(code string)
 }
 
+// Retry policy used by NewStore
+var DefaultTxRetry = TxRetry{MaxAttempts: 5, Backoff: 10 * time.Millisecond}
+
 // Store provides all functions to execute SQL queries and transactions
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	pool  *pgxpool.Pool
+	retry TxRetry
+}
+
+// Constructor method for Store struct, retrying transactions with DefaultTxRetry
+func NewStore(pool *pgxpool.Pool) Store {
+	return NewStoreWithRetry(pool, DefaultTxRetry)
 }
 
-// Constructor method for Store struct
-func NewStore(db *sql.DB) Store {
+// Constructor method for Store struct, retrying transactions with the given policy
+func NewStoreWithRetry(pool *pgxpool.Pool, retry TxRetry) Store {
+	retry.MaxAttempts = max(retry.MaxAttempts, 1)
 	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+		pool:    pool,
+		Queries: New(newTracedDBTX(pool)),
+		retry:   retry,
 	}
 }
 
 // Method to execute a database transaction
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	return store.execTxOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// Helper method: whether a transaction failed because of a concurrent one, and succeeds if run again
+func isRetryable(err error) bool {
+	code := ErrorCode(err)
+	return code == SerializationFailure || code == DeadlockDetected
+}
+
+// Helper method: get the jittered backoff before the given retry, starting at 1
+func (retry TxRetry) backoff(attempt int) time.Duration {
+	backoff := retry.Backoff << (attempt - 1)
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// Method to execute a database transaction with the given isolation level and access mode. The transaction is run
+// again when it fails with a serialization failure or a deadlock, so fn must not have side effects outside of it
+func (store *SQLStore) execTxOptions(ctx context.Context, opts pgx.TxOptions, fn func(*Queries) error) error {
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, opts, attempt, fn)
+		if err == nil || !isRetryable(err) || attempt >= store.retry.MaxAttempts {
+			return err
+		}
+
+		if store.retry.OnRetry != nil {
+			store.retry.OnRetry(ErrorCode(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(store.retry.backoff(attempt)):
+		}
+	}
+}
+
+// Helper method: run a single attempt of a transaction
+func (store *SQLStore) runTx(ctx context.Context, opts pgx.TxOptions, attempt int, fn func(*Queries) error) (err error) {
+	// Trace the whole transaction, the queries it runs are nested under this span
+	ctx, span := tracer().Start(ctx, "db.tx",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("db.tx.attempt", attempt)),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	// Create transaction object
-	tx, err := store.db.BeginTx(ctx, nil)
+	tx, err := store.pool.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
 	// Create queries object and run transaction
-	q := New(tx)
+	q := New(newTracedTx(tx, span))
 	err = fn(q)
 
 	// If queries fail, then we try to rollback
 	if err != nil {
-		if rollbacnErr := tx.Rollback(); rollbacnErr != nil {
-			return fmt.Errorf("transaction error: %v\nrollback error: %v", err, rollbacnErr)
+		span.SetAttributes(attribute.String("db.tx.outcome", "rollback"))
+		if rollbacnErr := tx.Rollback(ctx); rollbacnErr != nil {
+			return fmt.Errorf("transaction error: %w\nrollback error: %v", err, rollbacnErr)
 		}
 		return err
 	}
 
 	// If success, we commit the transaction
-	return tx.Commit()
+	span.SetAttributes(attribute.String("db.tx.outcome", "commit"))
+	return tx.Commit(ctx)
+}
+
+// Number of times an account is created before giving up on account number collisions. The numbers are random
+// among 10^14, so even one collision is unlikely
+const accountNumberAttempts = 3
+
+// Method to create an account and record an AccountCreated event. The database generates the account number, the
+// account is created again with another one if it is already taken
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+
+	var err error
+	for range accountNumberAttempts {
+		err = store.execTx(ctx, func(q *Queries) error {
+			var err error
+
+			account, err = q.CreateAccount(ctx, arg)
+			if err != nil {
+				return err
+			}
+
+			return recordEvent(ctx, q, AggregateAccount, account.AccountID, EventAccountCreated, account)
+		})
+		if ErrorConstraint(err) != "account_account_number_key" {
+			break
+		}
+	}
+
+	return account, err
 }
 
 // Parameter struct for transfer money action
@@ -54,6 +214,18 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+
+	// Optional details, copied to both entries. Metadata must be a JSON object, {} when empty
+	Description string          `json:"description"`
+	Reference   string          `json:"reference"`
+	Metadata    json.RawMessage `json:"metadata"`
+
+	// Isolation level and access mode of the transaction, the database default when empty
+	TxOptions pgx.TxOptions `json:"-"`
+
+	// Optional hook run inside the transaction once the transfer is done, e.g. to enqueue background tasks
+	// atomically with the transfer. Returning an error rolls the transfer back
+	AfterTransfer func(q *Queries, result TransferTxResult) error `json:"-"`
 }
 
 // Result struct return after transferring money
@@ -70,28 +242,47 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 	var result TransferTxResult
 
 	// Execute database transaction
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTxOptions(ctx, arg.TxOptions, func(q *Queries) error {
 		var err error
 
 		// Create a transfer record in database
-		result.Transfer, err = q.CreateTransaction(ctx, CreateTransactionParams(arg))
-		if err != nil {
-			return err
-		}
-
-		// Add an entry for the from account
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount, // Since the money go out, it should be minus
+		result.Transfer, err = q.CreateTransaction(ctx, CreateTransactionParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			Description:   arg.Description,
+			Reference:     arg.Reference,
+			Metadata:      arg.Metadata,
 		})
 		if err != nil {
 			return err
 		}
 
-		// Add an entry for the to account
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+		// Add an entry for the from account and one for the to account, in a single round trip
+		transferID := sql.NullInt64{Int64: result.Transfer.TransferID, Valid: true}
+		entries := []*Entry{&result.FromEntry, &result.ToEntry}
+		q.CreateEntries(ctx, []CreateEntriesParams{
+			{
+				AccountID:             arg.FromAccountID,
+				Amount:                -arg.Amount, // Since the money go out, it should be minus
+				TransferID:            transferID,
+				Description:           arg.Description,
+				Reference:             arg.Reference,
+				Metadata:              arg.Metadata,
+				CounterpartyAccountID: sql.NullInt64{Int64: arg.ToAccountID, Valid: true},
+			},
+			{
+				AccountID:             arg.ToAccountID,
+				Amount:                arg.Amount,
+				TransferID:            transferID,
+				Description:           arg.Description,
+				Reference:             arg.Reference,
+				Metadata:              arg.Metadata,
+				CounterpartyAccountID: sql.NullInt64{Int64: arg.FromAccountID, Valid: true},
+			},
+		}).QueryRow(func(i int, entry Entry, entryErr error) {
+			*entries[i] = entry
+			err = errors.Join(err, entryErr)
 		})
 		if err != nil {
 			return err
@@ -110,13 +301,37 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 		}
 
 		if arg.FromAccountID < arg.ToAccountID {
-			updateFromAccountBalance(q, &result, fromArg)
-			updateToAccountBalance(q, &result, toArg)
+			err = updateFromAccountBalance(ctx, q, &result, fromArg)
+			if err == nil {
+				err = updateToAccountBalance(ctx, q, &result, toArg)
+			}
 		} else {
-			updateToAccountBalance(q, &result, toArg)
-			updateFromAccountBalance(q, &result, fromArg)
+			err = updateToAccountBalance(ctx, q, &result, toArg)
+			if err == nil {
+				err = updateFromAccountBalance(ctx, q, &result, fromArg)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		// The from account row is locked by the update, so its new balance can be trusted.
+		// If it went past the overdraft limit, rolling back cancels the whole transfer
+		if result.FromAccount.Balance < -result.FromAccount.OverdraftLimit {
+			return ErrInsufficientFunds
+		}
+
+		if result.FromAccount.Status == AccountStatusFrozen || result.ToAccount.Status == AccountStatusFrozen {
+			return ErrAccountFrozen
 		}
 
+		if err := recordEvent(ctx, q, AggregateTransfer, result.Transfer.TransferID, EventTransferCompleted, result); err != nil {
+			return err
+		}
+
+		if arg.AfterTransfer != nil {
+			return arg.AfterTransfer(q, result)
+		}
 		return nil
 	})
 
@@ -124,15 +339,209 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 }
 
 // Helper method: update the from_account balance
-func updateFromAccountBalance(q *Queries, result *TransferTxResult, arg AddAccountBalanceParams) error {
+func updateFromAccountBalance(ctx context.Context, q *Queries, result *TransferTxResult, arg AddAccountBalanceParams) error {
 	var err error
-	result.FromAccount, err = q.AddAccountBalance(context.Background(), arg)
+	result.FromAccount, err = q.AddAccountBalance(ctx, arg)
 	return err
 }
 
 // Helper method: update the to_account balance
-func updateToAccountBalance(q *Queries, result *TransferTxResult, arg AddAccountBalanceParams) error {
+func updateToAccountBalance(ctx context.Context, q *Queries, result *TransferTxResult, arg AddAccountBalanceParams) error {
 	var err error
-	result.ToAccount, err = q.AddAccountBalance(context.Background(), arg)
+	result.ToAccount, err = q.AddAccountBalance(ctx, arg)
 	return err
 }
+
+// Parameter struct for deposit money action
+type DepositTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+
+	// Optional version the account must be at, or ErrVersionConflict is returned. 0 skips the check
+	ExpectedVersion int64 `json:"-"`
+
+	// Isolation level and access mode of the transaction, the database default when empty
+	TxOptions pgx.TxOptions `json:"-"`
+}
+
+// Result struct return after depositing money. Entry is the entry of the account, the transfer comes from the cash
+// clearing account of the bank
+type DepositTxResult struct {
+	Account  Account  `json:"account"`
+	Entry    Entry    `json:"entry"`
+	Transfer Transfer `json:"transfer"`
+}
+
+// Method to perform deposit money action. The money comes from the cash clearing account of the currency, so the
+// ledger stays balanced
+func (store *SQLStore) DepositTx(ctx context.Context, arg DepositTxParams) (DepositTxResult, error) {
+	var result DepositTxResult
+
+	err := store.execTxOptions(ctx, arg.TxOptions, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if arg.ExpectedVersion != 0 && account.Version != arg.ExpectedVersion {
+			return ErrVersionConflict
+		}
+		if account.Status == AccountStatusFrozen {
+			return ErrAccountFrozen
+		}
+
+		clearingID, err := q.GetBankAccount(ctx, GetBankAccountParams{
+			Purpose:  BankAccountCashClearing,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, _, result.Entry, err = createBankTransfer(ctx, q, clearingID, arg.AccountID, arg.Amount, "Deposit")
+		if err != nil {
+			return err
+		}
+
+		// Update the balances, the clearing account goes negative by the cash taken in
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: clearingID, Amount: -arg.Amount}); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, q, AggregateAccount, arg.AccountID, EventDepositMade, result)
+	})
+
+	return result, err
+}
+
+// Helper method: lock the account and check it is at the expected version. 0 skips the check
+func checkAccountVersion(ctx context.Context, q *Queries, accountID, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		return nil
+	}
+
+	account, err := q.GetAccountForUpdate(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if account.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Parameter struct for withdraw money action
+type WithdrawTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+
+	// Optional version the account must be at, or ErrVersionConflict is returned. 0 skips the check
+	ExpectedVersion int64 `json:"-"`
+
+	// Isolation level and access mode of the transaction, the database default when empty
+	TxOptions pgx.TxOptions `json:"-"`
+
+	// Optional hook run inside the transaction once the withdrawal is done. Returning an error rolls it back
+	AfterWithdraw func(q *Queries, result WithdrawTxResult) error `json:"-"`
+}
+
+// Result struct return after withdrawing money. Entry is the entry of the account, the transfer goes to the cash
+// clearing account of the bank
+type WithdrawTxResult struct {
+	Account  Account  `json:"account"`
+	Entry    Entry    `json:"entry"`
+	Transfer Transfer `json:"transfer"`
+}
+
+// Method to perform withdraw money action. The money goes to the cash clearing account of the currency, so the
+// ledger stays balanced. It fails with ErrInsufficientFunds if the balance and the overdraft limit can't cover the
+// amount
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (WithdrawTxResult, error) {
+	var result WithdrawTxResult
+
+	err := store.execTxOptions(ctx, arg.TxOptions, func(q *Queries) error {
+		// Lock the account so the balance can't change between the check and the update
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if arg.ExpectedVersion != 0 && account.Version != arg.ExpectedVersion {
+			return ErrVersionConflict
+		}
+		if account.Status == AccountStatusFrozen {
+			return ErrAccountFrozen
+		}
+		if account.Balance+account.OverdraftLimit < arg.Amount {
+			return ErrInsufficientFunds
+		}
+
+		clearingID, err := q.GetBankAccount(ctx, GetBankAccountParams{
+			Purpose:  BankAccountCashClearing,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, result.Entry, _, err = createBankTransfer(ctx, q, arg.AccountID, clearingID, arg.Amount, "Withdrawal")
+		if err != nil {
+			return err
+		}
+
+		// Update the balances
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: -arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: clearingID, Amount: arg.Amount}); err != nil {
+			return err
+		}
+
+		if err := recordEvent(ctx, q, AggregateAccount, arg.AccountID, EventWithdrawalMade, result); err != nil {
+			return err
+		}
+
+		if arg.AfterWithdraw != nil {
+			return arg.AfterWithdraw(q, result)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// Parameter struct for updating the type, nickname or labels of an account
+type UpdateAccountDetailsTxParams struct {
+	UpdateAccountDetailsParams
+
+	// Optional version the account must be at, or ErrVersionConflict is returned. 0 skips the check
+	ExpectedVersion int64 `json:"-"`
+}
+
+// Method to update the type, nickname or labels of an account. Fields left empty are not changed
+func (store *SQLStore) UpdateAccountDetailsTx(ctx context.Context, arg UpdateAccountDetailsTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if err := checkAccountVersion(ctx, q, arg.AccountID, arg.ExpectedVersion); err != nil {
+			return err
+		}
+
+		var err error
+		account, err = q.UpdateAccountDetails(ctx, arg.UpdateAccountDetailsParams)
+		return err
+	})
+
+	return account, err
+}
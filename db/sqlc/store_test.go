@@ -2,13 +2,29 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"gobank/util"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 )
 
+// Helper method: add money to a mock account, and keep the local copy in sync
+func fundAccountMock(t *testing.T, account *Account, amount int64) {
+	funded, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.AccountID,
+		Amount: amount,
+	})
+	require.NoError(t, err)
+	*account = funded
+}
+
 func TestTransferTx(t *testing.T) {
 	// Create a store
 	store := NewStore(conn)
@@ -20,6 +36,10 @@ func TestTransferTx(t *testing.T) {
 	// Run the test in concurrency
 	n := 10
 	amount := util.RandomInt(1, 200)
+
+	// Make sure the accounts can cover every transfer, so none fails with insufficient funds
+	fundAccountMock(t, &acc1, int64(n)*amount)
+	fundAccountMock(t, &acc2, int64(n)*amount)
 	errs := make(chan error)
 	results := make(chan TransferTxResult)
 
@@ -113,6 +133,10 @@ func TestTransferTxDeadlock(t *testing.T) {
 	// Run the test in concurrency
 	n := 10
 	amount := util.RandomInt(1, 200)
+
+	// Make sure the accounts can cover every transfer, so none fails with insufficient funds
+	fundAccountMock(t, &acc1, int64(n)*amount)
+	fundAccountMock(t, &acc2, int64(n)*amount)
 	errs := make(chan error)
 
 	for i := range n {
@@ -155,3 +179,448 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, acc1.Balance, res1.Balance)
 	require.Equal(t, acc2.Balance, res2.Balance)
 }
+
+// Helper method: get the cash clearing account of a currency
+func getCashClearingMock(t *testing.T, currency string) Account {
+	clearingID, err := testQueries.GetBankAccount(context.Background(), GetBankAccountParams{
+		Purpose:  BankAccountCashClearing,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+
+	clearing, err := testQueries.GetAccount(context.Background(), clearingID)
+	require.NoError(t, err)
+	return clearing
+}
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(conn)
+	account := createAccountMock(t)
+	clearing := getCashClearingMock(t, account.Currency)
+	amount := util.RandomInt(1, 200)
+
+	result, err := store.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account.AccountID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.AccountID, result.Entry.AccountID)
+	require.Equal(t, amount, result.Entry.Amount)
+	require.Equal(t, account.Balance+amount, result.Account.Balance)
+
+	// The money comes from the cash clearing account
+	require.Equal(t, clearing.AccountID, result.Transfer.FromAccountID)
+	require.Equal(t, account.AccountID, result.Transfer.ToAccountID)
+	require.Equal(t, sql.NullInt64{Int64: result.Transfer.TransferID, Valid: true}, result.Entry.TransferID)
+	require.Equal(t, clearing.Balance-amount, getCashClearingMock(t, account.Currency).Balance)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(conn)
+	account := createAccountMock(t)
+
+	result, err := store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.AccountID,
+		Amount:    account.Balance,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.AccountID, result.Entry.AccountID)
+	require.Equal(t, -account.Balance, result.Entry.Amount)
+	require.Zero(t, result.Account.Balance)
+
+	// The money goes to the cash clearing account
+	clearing := getCashClearingMock(t, account.Currency)
+	require.Equal(t, account.AccountID, result.Transfer.FromAccountID)
+	require.Equal(t, clearing.AccountID, result.Transfer.ToAccountID)
+
+	// The balance is now empty, so any further withdrawal must fail and leave the balance unchanged
+	_, err = store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.AccountID,
+		Amount:    1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	res, err := store.GetAccount(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Zero(t, res.Balance)
+}
+
+func TestTransferTxDetails(t *testing.T) {
+	store := NewStore(conn)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+	reference := "INV-" + util.RandomString(10)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        10,
+		Description:   "Rent",
+		Reference:     reference,
+		Metadata:      json.RawMessage(`{"unit": 4}`),
+	})
+	require.NoError(t, err)
+
+	// The details are copied to both entries
+	require.Equal(t, "Rent", result.Transfer.Description)
+	require.Equal(t, reference, result.Transfer.Reference)
+	require.JSONEq(t, `{"unit": 4}`, string(result.Transfer.Metadata))
+	for _, entry := range []Entry{result.FromEntry, result.ToEntry} {
+		require.Equal(t, "Rent", entry.Description)
+		require.Equal(t, reference, entry.Reference)
+		require.JSONEq(t, `{"unit": 4}`, string(entry.Metadata))
+	}
+
+	// Without details, the metadata is an empty object
+	plain, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, plain.Transfer.Reference)
+	require.JSONEq(t, `{}`, string(plain.FromEntry.Metadata))
+
+	// Either side finds the transfer by its reference, nobody else does
+	for _, member := range []string{acc1.Owner, acc2.Owner} {
+		transfers, err := store.ListTransfersByReference(context.Background(), ListTransfersByReferenceParams{
+			Reference: reference,
+			Member:    member,
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, result.Transfer.TransferID, transfers[0].TransferID)
+	}
+
+	transfers, err := store.ListTransfersByReference(context.Background(), ListTransfersByReferenceParams{
+		Reference: reference,
+		Member:    util.RandomString(8),
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	// Metadata must be an object
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        10,
+		Metadata:      json.RawMessage(`[1, 2]`),
+	})
+	require.Equal(t, CheckViolation, ErrorCode(err))
+}
+
+func TestOverdraftLimit(t *testing.T) {
+	store := NewStore(conn)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+	acc1, err := store.UpdateAccountOverdraft(context.Background(), UpdateAccountOverdraftParams{
+		AccountID:      acc1.AccountID,
+		OverdraftLimit: 1000,
+	})
+	require.NoError(t, err)
+
+	// A transfer may use the overdraft, down to exactly the limit
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        acc1.Balance + 600,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-600), result.FromAccount.Balance)
+
+	withdrawal, err := store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: acc1.AccountID,
+		Amount:    400,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-1000), withdrawal.Account.Balance)
+
+	// Past the limit, both fail and leave the balance unchanged
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: acc1.AccountID,
+		Amount:    1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	res1, err := store.GetAccount(context.Background(), acc1.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(-1000), res1.Balance)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(conn)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+
+	// Transfer more than the balance, the whole transfer must be rolled back
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        acc1.Balance + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	res1, err := store.GetAccount(context.Background(), acc1.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance, res1.Balance)
+
+	res2, err := store.GetAccount(context.Background(), acc2.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, acc2.Balance, res2.Balance)
+}
+
+func TestTransferTxAfterTransfer(t *testing.T) {
+	store := NewStore(conn)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+
+	// An error in the hook rolls back the transfer
+	hookErr := errors.New("hook failed")
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        1,
+		AfterTransfer: func(q *Queries, result TransferTxResult) error {
+			require.NotZero(t, result.Transfer.TransferID)
+			return hookErr
+		},
+	})
+	require.ErrorIs(t, err, hookErr)
+
+	res1, err := store.GetAccount(context.Background(), acc1.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance, res1.Balance)
+}
+
+func TestFrozenAccount(t *testing.T) {
+	store := NewStore(conn)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+
+	frozen, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		AccountID: acc2.AccountID,
+		Status:    AccountStatusFrozen,
+		Version:   acc2.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+
+	// A frozen account can neither receive, send, be topped up nor withdrawn from
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc2.AccountID,
+		ToAccountID:   acc1.AccountID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = store.DepositTx(context.Background(), DepositTxParams{AccountID: acc2.AccountID, Amount: 1})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = store.WithdrawTx(context.Background(), WithdrawTxParams{AccountID: acc2.AccountID, Amount: 1})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// Nothing was applied
+	res2, err := store.GetAccount(context.Background(), acc2.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, acc2.Balance, res2.Balance)
+
+	// Once unfrozen, the account works again
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		AccountID: acc2.AccountID,
+		Status:    AccountStatusActive,
+		Version:   frozen.Version,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DepositTx(context.Background(), DepositTxParams{AccountID: acc2.AccountID, Amount: 1})
+	require.NoError(t, err)
+}
+
+func TestExpectedVersion(t *testing.T) {
+	store := NewStore(conn)
+	account := createAccountMock(t)
+
+	// A deposit at the current version goes through and bumps the version
+	deposit, err := store.DepositTx(context.Background(), DepositTxParams{
+		AccountID:       account.AccountID,
+		Amount:          10,
+		ExpectedVersion: account.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Version+1, deposit.Account.Version)
+
+	// The version read before the deposit is stale, nothing is applied
+	_, err = store.DepositTx(context.Background(), DepositTxParams{
+		AccountID:       account.AccountID,
+		Amount:          10,
+		ExpectedVersion: account.Version,
+	})
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	_, err = store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID:       account.AccountID,
+		Amount:          1,
+		ExpectedVersion: account.Version,
+	})
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	res, err := store.GetAccount(context.Background(), account.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, deposit.Account.Balance, res.Balance)
+	require.Equal(t, deposit.Account.Version, res.Version)
+
+	// Without an expected version, any version is accepted
+	withdrawal, err := store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.AccountID,
+		Amount:    1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, res.Version+1, withdrawal.Account.Version)
+}
+
+func TestTransferTxRetry(t *testing.T) {
+	var retries []string
+	store := NewStoreWithRetry(conn, TxRetry{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		OnRetry:     func(code string) { retries = append(retries, code) },
+	})
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+
+	// The first attempts fail as if Postgres aborted them, the transfer goes through once retried
+	attempts := 0
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        1,
+		AfterTransfer: func(q *Queries, result TransferTxResult) error {
+			attempts++
+			switch attempts {
+			case 1:
+				return &pgconn.PgError{Code: SerializationFailure}
+			case 2:
+				return &pgconn.PgError{Code: DeadlockDetected}
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, []string{SerializationFailure, DeadlockDetected}, retries)
+	require.Equal(t, acc1.Balance-1, result.FromAccount.Balance)
+
+	// Only the last attempt was committed
+	res1, err := store.GetAccount(context.Background(), acc1.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance-1, res1.Balance)
+
+	// Once the attempts are exhausted, the last error is returned
+	attempts = 0
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        1,
+		AfterTransfer: func(q *Queries, result TransferTxResult) error {
+			attempts++
+			return &pgconn.PgError{Code: SerializationFailure}
+		},
+	})
+	require.Equal(t, SerializationFailure, ErrorCode(err))
+	require.Equal(t, 3, attempts)
+
+	// Other errors are not retried
+	attempts = 0
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        acc1.Balance + 1,
+		AfterTransfer: func(q *Queries, result TransferTxResult) error {
+			attempts++
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Zero(t, attempts)
+}
+
+func TestTransferTxSerializableStress(t *testing.T) {
+	var retries atomic.Int64
+	store := NewStoreWithRetry(conn, TxRetry{
+		MaxAttempts: 50,
+		Backoff:     time.Millisecond,
+		OnRetry:     func(string) { retries.Add(1) },
+	})
+
+	// Transfers in every direction between a few accounts, at the serializable isolation level, conflict with
+	// each other all the time. Every one of them must go through thanks to the retries
+	accounts := make([]Account, 4)
+	n := 40
+	amount := int64(10)
+	for i := range accounts {
+		accounts[i] = createAccountMock(t)
+		fundAccountMock(t, &accounts[i], int64(n)*amount)
+	}
+
+	expected := make(map[int64]int64)
+	for _, account := range accounts {
+		expected[account.AccountID] = account.Balance
+	}
+
+	errs := make(chan error)
+	for i := range n {
+		from := accounts[i%len(accounts)]
+		to := accounts[(i+1+i/len(accounts))%len(accounts)]
+		if from.AccountID == to.AccountID {
+			to = accounts[(i+1)%len(accounts)]
+		}
+		expected[from.AccountID] -= amount
+		expected[to.AccountID] += amount
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: from.AccountID,
+				ToAccountID:   to.AccountID,
+				Amount:        amount,
+				TxOptions:     pgx.TxOptions{IsoLevel: pgx.Serializable},
+			})
+			errs <- err
+		}()
+	}
+
+	for range n {
+		require.NoError(t, <-errs)
+	}
+	t.Logf("%d transfers needed %d retries", n, retries.Load())
+
+	// Each transfer was applied exactly once
+	for _, account := range accounts {
+		res, err := store.GetAccount(context.Background(), account.AccountID)
+		require.NoError(t, err)
+		require.Equal(t, expected[account.AccountID], res.Balance)
+	}
+}
@@ -0,0 +1,293 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: task.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const claimTasks = `-- name: ClaimTasks :many
+WITH due AS (
+    SELECT task_id FROM task
+    WHERE (status = 'pending' AND run_at <= now())
+        OR (status = 'running' AND locked_until < now())
+    ORDER BY run_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE task
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_until = $1,
+    updated_at = now()
+FROM due
+WHERE task.task_id = due.task_id
+RETURNING task.task_id, task.type, task.payload, task.status, task.attempts, task.max_attempts, task.run_at, task.locked_until, task.last_error, task.created_at, task.updated_at
+`
+
+type ClaimTasksParams struct {
+	LockedUntil sql.NullTime `json:"locked_until"`
+	BatchSize   int32        `json:"batch_size"`
+}
+
+func (q *Queries) ClaimTasks(ctx context.Context, arg ClaimTasksParams) ([]Task, error) {
+	rows, err := q.db.Query(ctx, claimTasks, arg.LockedUntil, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Task{}
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.TaskID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeTask = `-- name: CompleteTask :execrows
+UPDATE task
+SET status = 'completed',
+    locked_until = NULL,
+    last_error = NULL,
+    updated_at = now()
+WHERE task_id = $1
+  AND status = 'running'
+  AND locked_until = $2
+`
+
+type CompleteTaskParams struct {
+	TaskID      int64        `json:"task_id"`
+	LockedUntil sql.NullTime `json:"locked_until"`
+}
+
+// Completing, retrying and killing a task only apply while the lease it was claimed with is still held. Once it
+// expires, another worker may have claimed the task again, and no row is updated
+func (q *Queries) CompleteTask(ctx context.Context, arg CompleteTaskParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeTask, arg.TaskID, arg.LockedUntil)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createTask = `-- name: CreateTask :one
+INSERT INTO task (
+    type,
+    payload,
+    max_attempts,
+    run_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING task_id, type, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+type CreateTaskParams struct {
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+}
+
+func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
+	row := q.db.QueryRow(ctx, createTask,
+		arg.Type,
+		arg.Payload,
+		arg.MaxAttempts,
+		arg.RunAt,
+	)
+	var i Task
+	err := row.Scan(
+		&i.TaskID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTask = `-- name: GetTask :one
+SELECT task_id, type, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at FROM task
+WHERE task_id = $1
+`
+
+func (q *Queries) GetTask(ctx context.Context, taskID int64) (Task, error) {
+	row := q.db.QueryRow(ctx, getTask, taskID)
+	var i Task
+	err := row.Scan(
+		&i.TaskID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const killTask = `-- name: KillTask :execrows
+UPDATE task
+SET status = 'dead',
+    locked_until = NULL,
+    last_error = $1,
+    updated_at = now()
+WHERE task_id = $2
+  AND status = 'running'
+  AND locked_until = $3
+`
+
+type KillTaskParams struct {
+	LastError   sql.NullString `json:"last_error"`
+	TaskID      int64          `json:"task_id"`
+	LockedUntil sql.NullTime   `json:"locked_until"`
+}
+
+func (q *Queries) KillTask(ctx context.Context, arg KillTaskParams) (int64, error) {
+	result, err := q.db.Exec(ctx, killTask, arg.LastError, arg.TaskID, arg.LockedUntil)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listDeadTasks = `-- name: ListDeadTasks :many
+SELECT task_id, type, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at FROM task
+WHERE status = 'dead'
+ORDER BY task_id
+LIMIT $1
+OFFSET $2
+`
+
+type ListDeadTasksParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListDeadTasks(ctx context.Context, arg ListDeadTasksParams) ([]Task, error) {
+	rows, err := q.db.Query(ctx, listDeadTasks, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Task{}
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.TaskID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const requeueDeadTask = `-- name: RequeueDeadTask :one
+UPDATE task
+SET status = 'pending',
+    attempts = 0,
+    run_at = now(),
+    updated_at = now()
+WHERE task_id = $1
+    AND status = 'dead'
+RETURNING task_id, type, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at
+`
+
+func (q *Queries) RequeueDeadTask(ctx context.Context, taskID int64) (Task, error) {
+	row := q.db.QueryRow(ctx, requeueDeadTask, taskID)
+	var i Task
+	err := row.Scan(
+		&i.TaskID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const retryTask = `-- name: RetryTask :execrows
+UPDATE task
+SET status = 'pending',
+    run_at = $1,
+    locked_until = NULL,
+    last_error = $2,
+    updated_at = now()
+WHERE task_id = $3
+  AND status = 'running'
+  AND locked_until = $4
+`
+
+type RetryTaskParams struct {
+	RunAt       time.Time      `json:"run_at"`
+	LastError   sql.NullString `json:"last_error"`
+	TaskID      int64          `json:"task_id"`
+	LockedUntil sql.NullTime   `json:"locked_until"`
+}
+
+func (q *Queries) RetryTask(ctx context.Context, arg RetryTaskParams) (int64, error) {
+	result, err := q.db.Exec(ctx, retryTask,
+		arg.RunAt,
+		arg.LastError,
+		arg.TaskID,
+		arg.LockedUntil,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gobank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTaskMock(t *testing.T, runAt time.Time) Task {
+	arg := CreateTaskParams{
+		Type:        "test:" + util.RandomString(6),
+		Payload:     json.RawMessage(`{"value":1}`),
+		MaxAttempts: 3,
+		RunAt:       runAt,
+	}
+
+	task, err := testQueries.CreateTask(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, task.TaskID)
+	require.Equal(t, arg.Type, task.Type)
+	require.JSONEq(t, string(arg.Payload), string(task.Payload))
+	require.Equal(t, "pending", task.Status)
+	require.Zero(t, task.Attempts)
+
+	return task
+}
+
+// Helper method: claim due tasks until the given one is claimed, and release the others right away
+func claimTaskMock(t *testing.T, taskID int64) (Task, bool) {
+	for {
+		tasks, err := testQueries.ClaimTasks(context.Background(), ClaimTasksParams{
+			LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+			BatchSize:   100,
+		})
+		require.NoError(t, err)
+		if len(tasks) == 0 {
+			return Task{}, false
+		}
+
+		var claimed Task
+		found := false
+		for _, task := range tasks {
+			if task.TaskID == taskID {
+				claimed, found = task, true
+				continue
+			}
+			_, err := testQueries.CompleteTask(context.Background(), CompleteTaskParams{
+				TaskID:      task.TaskID,
+				LockedUntil: task.LockedUntil,
+			})
+			require.NoError(t, err)
+		}
+		if found {
+			return claimed, true
+		}
+	}
+}
+
+func TestClaimTasks(t *testing.T) {
+	task := createTaskMock(t, time.Now().Add(-time.Second))
+
+	claimed, ok := claimTaskMock(t, task.TaskID)
+	require.True(t, ok)
+	require.Equal(t, "running", claimed.Status)
+	require.Equal(t, int32(1), claimed.Attempts)
+	require.True(t, claimed.LockedUntil.Valid)
+
+	// A running task with a valid lease can't be claimed again
+	_, ok = claimTaskMock(t, task.TaskID)
+	require.False(t, ok)
+}
+
+func TestClaimTasksNotDue(t *testing.T) {
+	task := createTaskMock(t, time.Now().Add(time.Hour))
+
+	_, ok := claimTaskMock(t, task.TaskID)
+	require.False(t, ok)
+}
+
+func TestRetryAndKillTask(t *testing.T) {
+	task := createTaskMock(t, time.Now().Add(-time.Second))
+
+	claimed, ok := claimTaskMock(t, task.TaskID)
+	require.True(t, ok)
+
+	// Retry right away, the task can be claimed again
+	rows, err := testQueries.RetryTask(context.Background(), RetryTaskParams{
+		TaskID:      task.TaskID,
+		RunAt:       time.Now().Add(-time.Second),
+		LastError:   sql.NullString{String: "temporary failure", Valid: true},
+		LockedUntil: claimed.LockedUntil,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	claimed, ok = claimTaskMock(t, task.TaskID)
+	require.True(t, ok)
+	require.Equal(t, int32(2), claimed.Attempts)
+	require.Equal(t, "temporary failure", claimed.LastError.String)
+
+	// Move it to the dead letter queue
+	rows, err = testQueries.KillTask(context.Background(), KillTaskParams{
+		TaskID:      task.TaskID,
+		LastError:   sql.NullString{String: "permanent failure", Valid: true},
+		LockedUntil: claimed.LockedUntil,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	dead, err := testQueries.GetTask(context.Background(), task.TaskID)
+	require.NoError(t, err)
+	require.Equal(t, "dead", dead.Status)
+
+	_, ok = claimTaskMock(t, task.TaskID)
+	require.False(t, ok)
+
+	// Requeue it with a fresh set of attempts
+	requeued, err := testQueries.RequeueDeadTask(context.Background(), task.TaskID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", requeued.Status)
+	require.Zero(t, requeued.Attempts)
+}
+
+func TestTaskLeaseLost(t *testing.T) {
+	task := createTaskMock(t, time.Now().Add(-time.Second))
+
+	first, ok := claimTaskMock(t, task.TaskID)
+	require.True(t, ok)
+
+	// The lease of the first worker runs out, and another worker claims the task
+	_, err := conn.Exec(context.Background(), `UPDATE task SET locked_until = now() - interval '1 second'
+		WHERE task_id = $1`, task.TaskID)
+	require.NoError(t, err)
+	second, ok := claimTaskMock(t, task.TaskID)
+	require.True(t, ok)
+
+	// The first worker can no longer complete, retry or kill it
+	rows, err := testQueries.CompleteTask(context.Background(), CompleteTaskParams{
+		TaskID:      task.TaskID,
+		LockedUntil: first.LockedUntil,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.RetryTask(context.Background(), RetryTaskParams{
+		TaskID:      task.TaskID,
+		RunAt:       time.Now(),
+		LockedUntil: first.LockedUntil,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.KillTask(context.Background(), KillTaskParams{
+		TaskID:      task.TaskID,
+		LockedUntil: first.LockedUntil,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	// The second one still can
+	rows, err = testQueries.CompleteTask(context.Background(), CompleteTaskParams{
+		TaskID:      task.TaskID,
+		LockedUntil: second.LockedUntil,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	completed, err := testQueries.GetTask(context.Background(), task.TaskID)
+	require.NoError(t, err)
+	require.Equal(t, "completed", completed.Status)
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the tracer used for database spans
+const tracerName = "gobank/db"
+
+// Spans are created from the global tracer provider, which is a no-op until main installs a real one
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// DBTX decorator creating one span per query, named after the sqlc query (CreateEntry, AddAccountBalance...)
+type tracedDBTX struct {
+	DBTX
+
+	// Span of the transaction the queries run in, if any. The query spans are nested under it
+	txSpan trace.Span
+}
+
+// Wrap a connection so every query run through it is traced
+func newTracedDBTX(db DBTX) DBTX {
+	return tracedDBTX{DBTX: db}
+}
+
+// Wrap a transaction so every query run through it is traced as a child of the transaction span
+func newTracedTx(tx pgx.Tx, span trace.Span) DBTX {
+	return tracedDBTX{DBTX: tx, txSpan: span}
+}
+
+// Helper method: get the query name from the "-- name: CreateEntry :one" comment sqlc puts on every query
+func queryName(query string) string {
+	const prefix = "-- name: "
+
+	query = strings.TrimSpace(query)
+	if !strings.HasPrefix(query, prefix) {
+		return "query"
+	}
+
+	line, _, _ := strings.Cut(query[len(prefix):], "\n")
+	name, _, _ := strings.Cut(line, " ")
+	return name
+}
+
+// Helper method: start a span for the given query
+func (db tracedDBTX) startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	// Transaction callbacks use the context of the caller, which doesn't hold the transaction span
+	if db.txSpan != nil {
+		ctx = trace.ContextWithSpan(ctx, db.txSpan)
+	}
+
+	name := queryName(query)
+	return tracer().Start(ctx, "db."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", name),
+		),
+	)
+}
+
+// Helper method: end a query span, recording the error if any. ErrRecordNotFound is an expected result, not a failure
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (db tracedDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := db.startQuerySpan(ctx, query)
+	tag, err := db.DBTX.Exec(ctx, query, args...)
+	endQuerySpan(span, err)
+	return tag, err
+}
+
+// The span covers running the query, not iterating the rows
+func (db tracedDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := db.startQuerySpan(ctx, query)
+	rows, err := db.DBTX.Query(ctx, query, args...)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+// Errors of a single row query only show up when scanning the row, so the span can't record them
+func (db tracedDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	ctx, span := db.startQuerySpan(ctx, query)
+	row := db.DBTX.QueryRow(ctx, query, args...)
+	span.End()
+	return row
+}
+
+func (db tracedDBTX) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	if db.txSpan != nil {
+		ctx = trace.ContextWithSpan(ctx, db.txSpan)
+	}
+
+	ctx, span := tracer().Start(ctx, "db.copy "+tableName.Sanitize(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "postgresql")),
+	)
+	n, err := db.DBTX.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	span.SetAttributes(attribute.Int64("db.rows", n))
+	endQuerySpan(span, err)
+	return n, err
+}
+
+// The span covers sending the batch, named after its first query. The results are read afterwards
+func (db tracedDBTX) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	query := ""
+	if len(batch.QueuedQueries) > 0 {
+		query = batch.QueuedQueries[0].SQL
+	}
+
+	ctx, span := db.startQuerySpan(ctx, query)
+	span.SetAttributes(attribute.Int("db.batch.size", batch.Len()))
+	results := db.DBTX.SendBatch(ctx, batch)
+	span.End()
+	return results
+}
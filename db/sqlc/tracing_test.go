@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"gobank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "CreateEntry", queryName(createEntry))
+	require.Equal(t, "AddAccountBalance", queryName(addAccountBalance))
+	require.Equal(t, "query", queryName("SELECT 1"))
+}
+
+func TestTracing(t *testing.T) {
+	// Record the spans in memory, restoring the no-op provider afterwards
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	store := NewStore(conn)
+	_, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       util.RandomString(7),
+		Balance:     0,
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	// The queries are nested under the transaction span
+	tx := spans["db.tx"]
+	require.NotNil(t, tx)
+	require.Contains(t, tx.Attributes(), attribute.String("db.tx.outcome", "commit"))
+
+	for _, name := range []string{"db.CreateAccount", "db.CreateOutboxEvent"} {
+		require.Contains(t, spans, name)
+		require.Equal(t, tx.SpanContext().SpanID(), spans[name].Parent().SpanID())
+	}
+}
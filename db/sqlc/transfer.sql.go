@@ -7,26 +7,41 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 )
 
 const createTransaction = `-- name: CreateTransaction :one
 INSERT INTO transfer (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    description,
+    reference,
+    metadata
 ) VALUES (
-    $1, $2, $3
-) RETURNING transfer_id, from_account_id, to_account_id, amount, created_at
+    $1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}')
+) RETURNING transfer_id, from_account_id, to_account_id, amount, created_at, description, reference, metadata
 `
 
 type CreateTransactionParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransaction, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransaction,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Description,
+		arg.Reference,
+		arg.Metadata,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.TransferID,
@@ -34,6 +49,9 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -44,17 +62,17 @@ WHERE transfer_id = $1
 `
 
 func (q *Queries) DeleteTransaction(ctx context.Context, transferID int64) error {
-	_, err := q.db.ExecContext(ctx, deleteTransaction, transferID)
+	_, err := q.db.Exec(ctx, deleteTransaction, transferID)
 	return err
 }
 
 const getTransaction = `-- name: GetTransaction :one
-SELECT transfer_id, from_account_id, to_account_id, amount, created_at FROM transfer
+SELECT transfer_id, from_account_id, to_account_id, amount, created_at, description, reference, metadata FROM transfer
 WHERE transfer_id = $1
 `
 
 func (q *Queries) GetTransaction(ctx context.Context, transferID int64) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, getTransaction, transferID)
+	row := q.db.QueryRow(ctx, getTransaction, transferID)
 	var i Transfer
 	err := row.Scan(
 		&i.TransferID,
@@ -62,12 +80,15 @@ func (q *Queries) GetTransaction(ctx context.Context, transferID int64) (Transfe
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const listTransaction = `-- name: ListTransaction :many
-SELECT transfer_id, from_account_id, to_account_id, amount, created_at FROM transfer
+SELECT transfer_id, from_account_id, to_account_id, amount, created_at, description, reference, metadata FROM transfer
 ORDER BY transfer_id
 LIMIT $1
 OFFSET $2
@@ -79,7 +100,7 @@ type ListTransactionParams struct {
 }
 
 func (q *Queries) ListTransaction(ctx context.Context, arg ListTransactionParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransaction, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listTransaction, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -93,14 +114,127 @@ func (q *Queries) ListTransaction(ctx context.Context, arg ListTransactionParams
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersByReference = `-- name: ListTransfersByReference :many
+SELECT transfer.transfer_id, transfer.from_account_id, transfer.to_account_id, transfer.amount, transfer.created_at, transfer.description, transfer.reference, transfer.metadata FROM transfer
+WHERE transfer.reference = $1
+  AND EXISTS (
+    SELECT 1 FROM account
+    LEFT JOIN account_owners ON account_owners.account_id = account.account_id
+      AND account_owners.owner = $2
+      AND account_owners.status = 'active'
+    WHERE account.account_id IN (transfer.from_account_id, transfer.to_account_id)
+      AND (account.owner = $2 OR account_owners.owner IS NOT NULL)
+  )
+ORDER BY transfer.transfer_id
+LIMIT $4
+OFFSET $3
+`
+
+type ListTransfersByReferenceParams struct {
+	Reference string `json:"reference"`
+	Member    string `json:"member"`
+	Offset    int32  `json:"offset"`
+	Limit     int32  `json:"limit"`
+}
+
+// Transfers with the given end-to-end reference from or to an account the member owns or actively co-owns
+func (q *Queries) ListTransfersByReference(ctx context.Context, arg ListTransfersByReferenceParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listTransfersByReference,
+		arg.Reference,
+		arg.Member,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.TransferID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnbalancedTransfers = `-- name: ListUnbalancedTransfers :many
+SELECT t.transfer_id,
+       t.from_account_id,
+       t.to_account_id,
+       t.amount,
+       count(e.entry_id) AS entry_count,
+       COALESCE(sum(e.amount), 0)::bigint AS entry_sum
+FROM transfer t
+LEFT JOIN entry e ON e.transfer_id = t.transfer_id
+WHERE t.created_at >= $1::timestamptz
+GROUP BY t.transfer_id
+HAVING count(e.entry_id) <> 2
+    OR count(e.entry_id) FILTER (WHERE e.account_id = t.from_account_id AND e.amount = -t.amount) <> 1
+    OR count(e.entry_id) FILTER (WHERE e.account_id = t.to_account_id AND e.amount = t.amount) <> 1
+ORDER BY t.transfer_id
+`
+
+type ListUnbalancedTransfersRow struct {
+	TransferID    int64 `json:"transfer_id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	EntryCount    int64 `json:"entry_count"`
+	EntrySum      int64 `json:"entry_sum"`
+}
+
+// A transfer is balanced when it has exactly one debit entry on the from account and one credit entry on the
+// to account, both for the transfer amount
+func (q *Queries) ListUnbalancedTransfers(ctx context.Context, since time.Time) ([]ListUnbalancedTransfersRow, error) {
+	rows, err := q.db.Query(ctx, listUnbalancedTransfers, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnbalancedTransfersRow{}
+	for rows.Next() {
+		var i ListUnbalancedTransfersRow
+		if err := rows.Scan(
+			&i.TransferID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.EntryCount,
+			&i.EntrySum,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
@@ -111,7 +245,7 @@ const updateTransaction = `-- name: UpdateTransaction :one
 UPDATE transfer
 SET amount = $2
 WHERE transfer_id = $1
-RETURNING transfer_id, from_account_id, to_account_id, amount, created_at
+RETURNING transfer_id, from_account_id, to_account_id, amount, created_at, description, reference, metadata
 `
 
 type UpdateTransactionParams struct {
@@ -120,7 +254,7 @@ type UpdateTransactionParams struct {
 }
 
 func (q *Queries) UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, updateTransaction, arg.TransferID, arg.Amount)
+	row := q.db.QueryRow(ctx, updateTransaction, arg.TransferID, arg.Amount)
 	var i Transfer
 	err := row.Scan(
 		&i.TransferID,
@@ -128,6 +262,9 @@ func (q *Queries) UpdateTransaction(ctx context.Context, arg UpdateTransactionPa
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"gobank/util"
 	"testing"
 	"time"
@@ -79,7 +78,7 @@ func TestDeleteTransfer(t *testing.T) {
 
 	transfer, err := testQueries.GetTransaction(context.Background(), mock.TransferID)
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err, ErrRecordNotFound)
 	require.Empty(t, transfer)
 }
 
@@ -101,3 +100,37 @@ func TestListTransfer(t *testing.T) {
 		require.NotEmpty(t, transfer)
 	}
 }
+
+func TestListUnbalancedTransfers(t *testing.T) {
+	store := NewStore(conn)
+	since := time.Now().Add(-time.Second)
+
+	acc1 := createAccountMock(t)
+	acc2 := createAccountMock(t)
+
+	// A transfer made through TransferTx is balanced
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+
+	// A transfer without entries is not
+	orphan, err := testQueries.CreateTransaction(context.Background(), CreateTransactionParams{
+		FromAccountID: acc1.AccountID,
+		ToAccountID:   acc2.AccountID,
+		Amount:        5,
+	})
+	require.NoError(t, err)
+
+	transfers, err := testQueries.ListUnbalancedTransfers(context.Background(), since)
+	require.NoError(t, err)
+
+	ids := make([]int64, len(transfers))
+	for i, transfer := range transfers {
+		ids[i] = transfer.TransferID
+	}
+	require.Contains(t, ids, orphan.TransferID)
+	require.NotContains(t, ids, result.Transfer.TransferID)
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"gobank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createUserMock(t *testing.T) User {
+	arg := CreateUserParams{
+		Username: util.RandomString(8),
+		FullName: util.RandomString(10),
+		Email:    util.RandomString(8) + "@example.com",
+	}
+
+	user, err := testQueries.CreateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+	require.Equal(t, arg.FullName, user.FullName)
+	require.Equal(t, arg.Email, user.Email)
+	require.NotZero(t, user.CreatedAt)
+
+	return user
+}
+
+func TestCreateUser(t *testing.T) {
+	createUserMock(t)
+}
+
+func TestGetUser(t *testing.T) {
+	user := createUserMock(t)
+
+	res, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, res.Username)
+	require.Equal(t, user.Email, res.Email)
+
+	// The email is unique
+	_, err = testQueries.CreateUser(context.Background(), CreateUserParams{
+		Username: util.RandomString(8),
+		FullName: util.RandomString(10),
+		Email:    user.Email,
+	})
+	require.Error(t, err)
+}
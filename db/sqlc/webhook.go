@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Status of a webhook delivery
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Method to process a batch of webhook deliveries that are due. The batch is claimed with a lease in a single
+// statement, so several workers can process deliveries in parallel without sending the same one twice, and no
+// transaction is held while deliver performs the HTTP calls. Each outcome is stored as soon as deliver returns it,
+// unless the lease ran out meanwhile and the delivery may belong to another worker.
+// A delivery whose outcome isn't stored before the lease ends, because the worker died or was too slow, is sent
+// again: deliveries are at least once, receivers deduplicate with the delivery ID.
+// When ctx is cancelled, e.g. on shutdown, the interrupted delivery and the rest of the batch are released without
+// counting an attempt, so restarts don't use up the retries of a subscriber.
+// It returns the number of deliveries attempted
+func (store *SQLStore) ProcessWebhookDeliveries(
+	ctx context.Context,
+	batchSize int32,
+	lease time.Duration,
+	deliver func(ClaimDueWebhookDeliveriesRow) UpdateWebhookDeliveryAttemptParams,
+) (int, error) {
+	deliveries, err := store.ClaimDueWebhookDeliveries(ctx, ClaimDueWebhookDeliveriesParams{
+		LeasedUntil: time.Now().Add(lease),
+		Limit:       batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for i, delivery := range deliveries {
+		arg := deliver(delivery)
+		if ctx.Err() != nil {
+			if err := store.releaseWebhookDeliveries(ctx, deliveries[i:]); err != nil {
+				return attempted, err
+			}
+			return attempted, ctx.Err()
+		}
+
+		arg.DeliveryID = delivery.DeliveryID
+		arg.LeasedUntil = delivery.NextAttemptAt
+		if _, err := store.UpdateWebhookDeliveryAttempt(ctx, arg); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+
+	return attempted, nil
+}
+
+// Helper method: release claimed deliveries, even though ctx is cancelled. They share the lease of their claim
+func (store *SQLStore) releaseWebhookDeliveries(ctx context.Context, deliveries []ClaimDueWebhookDeliveriesRow) error {
+	ids := make([]int64, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.DeliveryID
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	return store.ReleaseWebhookDeliveries(ctx, ReleaseWebhookDeliveriesParams{
+		DeliveryIds: ids,
+		LeasedUntil: deliveries[0].NextAttemptAt,
+	})
+}
@@ -0,0 +1,456 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: webhook.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_delivery
+SET next_attempt_at = $1
+FROM webhook_subscription
+WHERE webhook_subscription.subscription_id = webhook_delivery.subscription_id
+    AND webhook_delivery.delivery_id IN (
+        SELECT delivery_id FROM webhook_delivery
+        WHERE status = 'pending'
+            AND next_attempt_at <= now()
+        ORDER BY next_attempt_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING
+    webhook_delivery.delivery_id, webhook_delivery.subscription_id, webhook_delivery.event_id, webhook_delivery.event_type, webhook_delivery.payload, webhook_delivery.status, webhook_delivery.attempts, webhook_delivery.next_attempt_at, webhook_delivery.last_status_code, webhook_delivery.last_error, webhook_delivery.created_at, webhook_delivery.delivered_at,
+    webhook_subscription.url,
+    webhook_subscription.secret
+`
+
+type ClaimDueWebhookDeliveriesParams struct {
+	LeasedUntil time.Time `json:"leased_until"`
+	Limit       int32     `json:"limit"`
+}
+
+type ClaimDueWebhookDeliveriesRow struct {
+	DeliveryID     int64           `json:"delivery_id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode sql.NullInt32   `json:"last_status_code"`
+	LastError      sql.NullString  `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at"`
+	Url            string          `json:"url"`
+	Secret         string          `json:"secret"`
+}
+
+// Lease a batch of due deliveries by moving their next attempt to leased_until: other workers skip them until then,
+// and they are picked up again if the worker dies before recording the outcome
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]ClaimDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimDueWebhookDeliveries, arg.LeasedUntil, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimDueWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i ClaimDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.DeliveryID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_delivery (
+    subscription_id,
+    event_id,
+    event_type,
+    payload
+) VALUES (
+    $1, $2, $3, $4
+) ON CONFLICT (subscription_id, event_id, event_type) DO NOTHING
+`
+
+type CreateWebhookDeliveryParams struct {
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, createWebhookDelivery,
+		arg.SubscriptionID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+	)
+	return err
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscription (
+    owner,
+    url,
+    secret,
+    event_types
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING subscription_id, owner, url, secret, event_types, created_at
+`
+
+type CreateWebhookSubscriptionParams struct {
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, createWebhookSubscription,
+		arg.Owner,
+		arg.Url,
+		arg.Secret,
+		arg.EventTypes,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.SubscriptionID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscription
+WHERE subscription_id = $1
+    AND owner = $2
+`
+
+type DeleteWebhookSubscriptionParams struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	Owner          string `json:"owner"`
+}
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, arg DeleteWebhookSubscriptionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookSubscription, arg.SubscriptionID, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT webhook_delivery.delivery_id, webhook_delivery.subscription_id, webhook_delivery.event_id, webhook_delivery.event_type, webhook_delivery.payload, webhook_delivery.status, webhook_delivery.attempts, webhook_delivery.next_attempt_at, webhook_delivery.last_status_code, webhook_delivery.last_error, webhook_delivery.created_at, webhook_delivery.delivered_at FROM webhook_delivery
+JOIN webhook_subscription ON webhook_subscription.subscription_id = webhook_delivery.subscription_id
+WHERE webhook_delivery.delivery_id = $1
+    AND webhook_delivery.subscription_id = $2
+    AND webhook_subscription.owner = $3
+`
+
+type GetWebhookDeliveryParams struct {
+	DeliveryID     int64  `json:"delivery_id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	Owner          string `json:"owner"`
+}
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, arg GetWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, arg.DeliveryID, arg.SubscriptionID, arg.Owner)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.DeliveryID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+SELECT subscription_id, owner, url, secret, event_types, created_at FROM webhook_subscription
+WHERE subscription_id = $1
+    AND owner = $2
+`
+
+type GetWebhookSubscriptionParams struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	Owner          string `json:"owner"`
+}
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, arg GetWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, getWebhookSubscription, arg.SubscriptionID, arg.Owner)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.SubscriptionID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT webhook_delivery.delivery_id, webhook_delivery.subscription_id, webhook_delivery.event_id, webhook_delivery.event_type, webhook_delivery.payload, webhook_delivery.status, webhook_delivery.attempts, webhook_delivery.next_attempt_at, webhook_delivery.last_status_code, webhook_delivery.last_error, webhook_delivery.created_at, webhook_delivery.delivered_at FROM webhook_delivery
+JOIN webhook_subscription ON webhook_subscription.subscription_id = webhook_delivery.subscription_id
+WHERE webhook_delivery.subscription_id = $1
+    AND webhook_subscription.owner = $2
+ORDER BY webhook_delivery.delivery_id DESC
+LIMIT $4
+OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	Owner          string `json:"owner"`
+	Offset         int32  `json:"offset"`
+	Limit          int32  `json:"limit"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries,
+		arg.SubscriptionID,
+		arg.Owner,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.DeliveryID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT subscription_id, owner, url, secret, event_types, created_at FROM webhook_subscription
+WHERE owner = $1
+ORDER BY subscription_id
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context, owner string) ([]WebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptions, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookSubscription{}
+	for rows.Next() {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.SubscriptionID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptionsForEvent = `-- name: ListWebhookSubscriptionsForEvent :many
+SELECT subscription_id, owner, url, secret, event_types, created_at FROM webhook_subscription
+WHERE owner = $1
+    AND $2::varchar = ANY(event_types)
+ORDER BY subscription_id
+`
+
+type ListWebhookSubscriptionsForEventParams struct {
+	Owner     string `json:"owner"`
+	EventType string `json:"event_type"`
+}
+
+func (q *Queries) ListWebhookSubscriptionsForEvent(ctx context.Context, arg ListWebhookSubscriptionsForEventParams) ([]WebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptionsForEvent, arg.Owner, arg.EventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookSubscription{}
+	for rows.Next() {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.SubscriptionID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseWebhookDeliveries = `-- name: ReleaseWebhookDeliveries :exec
+UPDATE webhook_delivery
+SET next_attempt_at = now()
+WHERE delivery_id = ANY($1::bigint[])
+    AND status = 'pending'
+    AND next_attempt_at = $2
+`
+
+type ReleaseWebhookDeliveriesParams struct {
+	DeliveryIds []int64   `json:"delivery_ids"`
+	LeasedUntil time.Time `json:"leased_until"`
+}
+
+// Give back leased deliveries that weren't attempted, e.g. on shutdown, without counting an attempt
+func (q *Queries) ReleaseWebhookDeliveries(ctx context.Context, arg ReleaseWebhookDeliveriesParams) error {
+	_, err := q.db.Exec(ctx, releaseWebhookDeliveries, arg.DeliveryIds, arg.LeasedUntil)
+	return err
+}
+
+const replayWebhookDelivery = `-- name: ReplayWebhookDelivery :one
+UPDATE webhook_delivery
+SET status = 'pending',
+    next_attempt_at = now()
+WHERE delivery_id = $1
+    AND subscription_id = $2
+    AND status = 'failed'
+RETURNING delivery_id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at
+`
+
+type ReplayWebhookDeliveryParams struct {
+	DeliveryID     int64 `json:"delivery_id"`
+	SubscriptionID int64 `json:"subscription_id"`
+}
+
+func (q *Queries) ReplayWebhookDelivery(ctx context.Context, arg ReplayWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, replayWebhookDelivery, arg.DeliveryID, arg.SubscriptionID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.DeliveryID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const updateWebhookDeliveryAttempt = `-- name: UpdateWebhookDeliveryAttempt :execrows
+UPDATE webhook_delivery
+SET status = $1,
+    attempts = attempts + 1,
+    next_attempt_at = $2,
+    last_status_code = $3,
+    last_error = $4,
+    delivered_at = $5
+WHERE delivery_id = $6
+    AND status = 'pending'
+    AND next_attempt_at = $7
+`
+
+type UpdateWebhookDeliveryAttemptParams struct {
+	Status         string         `json:"status"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at"`
+	LastStatusCode sql.NullInt32  `json:"last_status_code"`
+	LastError      sql.NullString `json:"last_error"`
+	DeliveredAt    sql.NullTime   `json:"delivered_at"`
+	DeliveryID     int64          `json:"delivery_id"`
+	LeasedUntil    time.Time      `json:"leased_until"`
+}
+
+// Only applies while the lease the delivery was claimed with is still held. Once it expires, another worker may have
+// claimed the delivery again, and no row is updated
+func (q *Queries) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateWebhookDeliveryAttempt,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.DeliveredAt,
+		arg.DeliveryID,
+		arg.LeasedUntil,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
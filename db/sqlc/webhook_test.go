@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"gobank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createWebhookSubscriptionMock(t *testing.T, owner string) WebhookSubscription {
+	arg := CreateWebhookSubscriptionParams{
+		Owner:      owner,
+		Url:        "https://example.com/" + util.RandomString(8),
+		Secret:     util.RandomString(32),
+		EventTypes: []string{"TransferReceived", "DepositMade"},
+	}
+
+	subscription, err := testQueries.CreateWebhookSubscription(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, subscription.SubscriptionID)
+	require.Equal(t, arg.Owner, subscription.Owner)
+	require.Equal(t, arg.Url, subscription.Url)
+	require.Equal(t, arg.EventTypes, subscription.EventTypes)
+
+	return subscription
+}
+
+func TestListWebhookSubscriptionsForEvent(t *testing.T) {
+	owner := util.RandomString(7)
+	subscription := createWebhookSubscriptionMock(t, owner)
+
+	subscriptions, err := testQueries.ListWebhookSubscriptionsForEvent(context.Background(), ListWebhookSubscriptionsForEventParams{
+		Owner:     owner,
+		EventType: "TransferReceived",
+	})
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	require.Equal(t, subscription.SubscriptionID, subscriptions[0].SubscriptionID)
+
+	// Not subscribed to this event type
+	subscriptions, err = testQueries.ListWebhookSubscriptionsForEvent(context.Background(), ListWebhookSubscriptionsForEventParams{
+		Owner:     owner,
+		EventType: "TransferSent",
+	})
+	require.NoError(t, err)
+	require.Empty(t, subscriptions)
+}
+
+func TestProcessWebhookDeliveries(t *testing.T) {
+	store := NewStore(conn)
+	subscription := createWebhookSubscriptionMock(t, util.RandomString(7))
+
+	// Any outbox event will do as the source of the delivery
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       subscription.Owner,
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(t, err)
+	events := drainOutbox(t, store, AggregateAccount, account.AccountID, func(OutboxEvent) error { return nil })
+	require.Len(t, events, 1)
+
+	// Creating the same delivery twice only stores it once
+	arg := CreateWebhookDeliveryParams{
+		SubscriptionID: subscription.SubscriptionID,
+		EventID:        events[0].EventID,
+		EventType:      events[0].EventType,
+		Payload:        events[0].Payload,
+	}
+	require.NoError(t, testQueries.CreateWebhookDelivery(context.Background(), arg))
+	require.NoError(t, testQueries.CreateWebhookDelivery(context.Background(), arg))
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.SubscriptionID,
+		Owner:          subscription.Owner,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	// Other users don't see the deliveries
+	others, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.SubscriptionID,
+		Owner:          util.RandomString(7),
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, others)
+	require.Equal(t, WebhookDeliveryPending, deliveries[0].Status)
+
+	// Fail the delivery permanently
+	var attempted bool
+	for {
+		n, err := store.ProcessWebhookDeliveries(context.Background(), 100, time.Minute, func(delivery ClaimDueWebhookDeliveriesRow) UpdateWebhookDeliveryAttemptParams {
+			if delivery.DeliveryID != deliveries[0].DeliveryID {
+				return UpdateWebhookDeliveryAttemptParams{Status: delivery.Status, NextAttemptAt: delivery.NextAttemptAt}
+			}
+
+			attempted = true
+			require.Equal(t, subscription.Url, delivery.Url)
+			require.Equal(t, subscription.Secret, delivery.Secret)
+			return UpdateWebhookDeliveryAttemptParams{
+				Status:         WebhookDeliveryFailed,
+				NextAttemptAt:  time.Now(),
+				LastStatusCode: sql.NullInt32{Int32: 500, Valid: true},
+			}
+		})
+		require.NoError(t, err)
+		if n == 0 || attempted {
+			break
+		}
+	}
+	require.True(t, attempted)
+
+	getArg := GetWebhookDeliveryParams{
+		DeliveryID:     deliveries[0].DeliveryID,
+		SubscriptionID: subscription.SubscriptionID,
+		Owner:          subscription.Owner,
+	}
+	delivery, err := testQueries.GetWebhookDelivery(context.Background(), getArg)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryFailed, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+
+	_, err = testQueries.GetWebhookDelivery(context.Background(), GetWebhookDeliveryParams{
+		DeliveryID:     getArg.DeliveryID,
+		SubscriptionID: getArg.SubscriptionID,
+		Owner:          util.RandomString(7),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// Replay puts it back in the queue
+	replayArg := ReplayWebhookDeliveryParams{DeliveryID: delivery.DeliveryID, SubscriptionID: subscription.SubscriptionID}
+	delivery, err = testQueries.ReplayWebhookDelivery(context.Background(), replayArg)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryPending, delivery.Status)
+
+	// A pending delivery can't be replayed
+	_, err = testQueries.ReplayWebhookDelivery(context.Background(), replayArg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// Once claimed, the delivery is leased: claiming again doesn't return it until the lease ends
+	claim := ClaimDueWebhookDeliveriesParams{LeasedUntil: time.Now().Add(time.Hour), Limit: 100}
+	for claimed := false; !claimed; {
+		rows, err := testQueries.ClaimDueWebhookDeliveries(context.Background(), claim)
+		require.NoError(t, err)
+		require.NotEmpty(t, rows)
+
+		for _, row := range rows {
+			claimed = claimed || row.DeliveryID == delivery.DeliveryID
+		}
+	}
+
+	rows, err := testQueries.ClaimDueWebhookDeliveries(context.Background(), claim)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NotEqual(t, delivery.DeliveryID, row.DeliveryID)
+	}
+}
+
+// Helper method: create a pending delivery of an AccountCreated event to a new subscription
+func createWebhookDeliveryMock(t *testing.T, store Store) WebhookDelivery {
+	subscription := createWebhookSubscriptionMock(t, util.RandomString(7))
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       subscription.Owner,
+		Currency:    util.USD,
+		AccountType: AccountTypeChecking,
+	})
+	require.NoError(t, err)
+	events := drainOutbox(t, store, AggregateAccount, account.AccountID, func(OutboxEvent) error { return nil })
+	require.Len(t, events, 1)
+
+	require.NoError(t, testQueries.CreateWebhookDelivery(context.Background(), CreateWebhookDeliveryParams{
+		SubscriptionID: subscription.SubscriptionID,
+		EventID:        events[0].EventID,
+		EventType:      events[0].EventType,
+		Payload:        events[0].Payload,
+	}))
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.SubscriptionID,
+		Owner:          subscription.Owner,
+		Limit:          1,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	return deliveries[0]
+}
+
+func TestProcessWebhookDeliveriesShutdown(t *testing.T) {
+	store := NewStore(conn)
+	delivery := createWebhookDeliveryMock(t, store)
+
+	// The worker is stopped while sending the delivery
+	for seen := false; !seen; {
+		ctx, cancel := context.WithCancel(context.Background())
+		n, err := store.ProcessWebhookDeliveries(ctx, 100, time.Minute, func(d ClaimDueWebhookDeliveriesRow) UpdateWebhookDeliveryAttemptParams {
+			if d.DeliveryID == delivery.DeliveryID {
+				seen = true
+				cancel()
+				return UpdateWebhookDeliveryAttemptParams{Status: WebhookDeliveryFailed, NextAttemptAt: time.Now()}
+			}
+			return UpdateWebhookDeliveryAttemptParams{Status: d.Status, NextAttemptAt: d.NextAttemptAt}
+		})
+		cancel()
+
+		if seen {
+			require.ErrorIs(t, err, context.Canceled)
+			break
+		}
+		require.NoError(t, err)
+		require.NotZero(t, n, "delivery never claimed")
+	}
+
+	// The attempt isn't counted and the delivery is due again right away
+	var attempts int32
+	var status string
+	var nextAttemptAt time.Time
+	err := conn.QueryRow(context.Background(), `SELECT attempts, status, next_attempt_at FROM webhook_delivery
+		WHERE delivery_id = $1`, delivery.DeliveryID).Scan(&attempts, &status, &nextAttemptAt)
+	require.NoError(t, err)
+	require.Zero(t, attempts)
+	require.Equal(t, WebhookDeliveryPending, status)
+	require.False(t, nextAttemptAt.After(time.Now()))
+}
+
+func TestUpdateWebhookDeliveryAttemptLeaseLost(t *testing.T) {
+	store := NewStore(conn)
+	delivery := createWebhookDeliveryMock(t, store)
+
+	// The delivery was claimed again since, with another lease
+	rows, err := store.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		DeliveryID:    delivery.DeliveryID,
+		Status:        WebhookDeliverySucceeded,
+		NextAttemptAt: time.Now(),
+		LeasedUntil:   delivery.NextAttemptAt.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		DeliveryID:    delivery.DeliveryID,
+		Status:        WebhookDeliverySucceeded,
+		NextAttemptAt: time.Now(),
+		LeasedUntil:   delivery.NextAttemptAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
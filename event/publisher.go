@@ -0,0 +1,50 @@
+package event
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+	"log/slog"
+)
+
+// Publisher delivers domain events to downstream services. Publish may be called more than once for the same event
+// (at-least-once delivery), so consumers should deduplicate on the event ID
+type Publisher interface {
+	Publish(ctx context.Context, event db.OutboxEvent) error
+}
+
+// LogPublisher logs every event and keeps nothing, so events reach no consumer. For development only
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+// Constructor method for LogPublisher
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (publisher *LogPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	publisher.logger.InfoContext(ctx, "Event published to the log",
+		"event_id", event.EventID, "event_type", event.EventType,
+		"aggregate_type", event.AggregateType, "aggregate_id", event.AggregateID)
+	return nil
+}
+
+// MultiPublisher publishes every event to several publishers in turn. It fails as soon as one of them fails, so the
+// event is retried on all of them; publishers must tolerate duplicates anyway
+type MultiPublisher struct {
+	publishers []Publisher
+}
+
+// Constructor method for MultiPublisher
+func NewMultiPublisher(publishers ...Publisher) *MultiPublisher {
+	return &MultiPublisher{publishers: publishers}
+}
+
+func (publisher *MultiPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	for _, p := range publisher.publishers {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
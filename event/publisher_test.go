@@ -0,0 +1,69 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := NewLogPublisher(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	err := publisher.Publish(context.Background(), db.OutboxEvent{EventID: 42, EventType: db.EventTransferCompleted})
+	require.NoError(t, err)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, float64(42), line["event_id"])
+	require.Equal(t, db.EventTransferCompleted, line["event_type"])
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var received db.OutboxEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "42", r.Header.Get("X-Event-ID"))
+		require.Equal(t, db.EventTransferCompleted, r.Header.Get("X-Event-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := db.OutboxEvent{
+		EventID:       42,
+		AggregateType: db.AggregateTransfer,
+		AggregateID:   7,
+		EventType:     db.EventTransferCompleted,
+		Payload:       json.RawMessage(`{"amount":10}`),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	err := NewWebhookPublisher(server.URL, time.Second).Publish(context.Background(), event)
+	require.NoError(t, err)
+	require.Equal(t, event.EventID, received.EventID)
+	require.JSONEq(t, string(event.Payload), string(received.Payload))
+}
+
+func TestWebhookPublisherFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewWebhookPublisher(server.URL, time.Second).Publish(context.Background(), db.OutboxEvent{EventID: 1})
+	require.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package event
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPublisher appends every event to a Redis stream named "<prefix>.<aggregate type>", e.g. "gobank.transfer".
+// Streams keep the insertion order, so consumers see the events of an aggregate in the order they happened
+type RedisPublisher struct {
+	client *redis.Client
+	prefix string
+}
+
+// Constructor method for RedisPublisher
+func NewRedisPublisher(address, prefix string) *RedisPublisher {
+	return &RedisPublisher{
+		client: redis.NewClient(&redis.Options{Addr: address}),
+		prefix: prefix,
+	}
+}
+
+func (publisher *RedisPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	return publisher.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: publisher.prefix + "." + event.AggregateType,
+		Values: map[string]any{
+			"event_id":     event.EventID,
+			"aggregate_id": event.AggregateID,
+			"event_type":   event.EventType,
+			"payload":      string(event.Payload),
+			"created_at":   event.CreatedAt,
+		},
+	}).Err()
+}
+
+// Close the connection to Redis
+func (publisher *RedisPublisher) Close() error {
+	return publisher.client.Close()
+}
@@ -0,0 +1,68 @@
+package event
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+	"log/slog"
+	"time"
+)
+
+// How long a batch of events is leased. Publishing a batch must fit in it: the events still pending when it ends are
+// left to the next batch, so a slow broker delays events rather than publishing them twice
+const relayLease = 5 * time.Minute
+
+// Relay moves domain events from the outbox table to a Publisher
+type Relay struct {
+	store     db.Store
+	publisher Publisher
+	logger    *slog.Logger
+	interval  time.Duration
+	batchSize int32
+}
+
+// Constructor method for Relay
+func NewRelay(store db.Store, publisher Publisher, logger *slog.Logger, interval time.Duration, batchSize int32) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		logger:    logger,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Method to publish one batch of pending events. It returns the number of events published
+func (relay *Relay) RunOnce(ctx context.Context) (int, error) {
+	return relay.store.PublishOutbox(ctx, relay.batchSize, relayLease, func(ctx context.Context, event db.OutboxEvent) error {
+		err := relay.publisher.Publish(ctx, event)
+		if err != nil {
+			relay.logger.Warn("Failed to publish event, will retry",
+				"event_id", event.EventID, "event_type", event.EventType, "error", err)
+		}
+		return err
+	})
+}
+
+// Method to run the relay until the context is cancelled. Full batches are followed immediately by the next one,
+// otherwise the relay waits for the configured interval
+func (relay *Relay) Start(ctx context.Context) {
+	relay.logger.Info("Outbox relay started")
+
+	for {
+		published, err := relay.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			relay.logger.Error("Failed to relay outbox events", "error", err)
+		}
+
+		if err == nil && published == int(relay.batchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			relay.logger.Info("Outbox relay stopped")
+			return
+		case <-time.After(relay.interval):
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	db "gobank/db/sqlc"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WebhookPublisher POSTs every event as JSON to a single URL. Any non 2xx response is treated as a failure,
+// so the event stays in the outbox and is retried later
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// Constructor method for WebhookPublisher
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (publisher *WebhookPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, publisher.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.EventID, 10))
+	req.Header.Set("X-Event-Type", event.EventType)
+
+	resp, err := publisher.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with status %d", publisher.url, resp.StatusCode)
+	}
+
+	return nil
+}
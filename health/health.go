@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"sync"
+	"time"
+)
+
+// Status reported for the whole service and for each check
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// A dependency check. It must return before the context expires
+type Check func(ctx context.Context) error
+
+// Result of a readiness run: the overall status and the outcome of each check, "ok" or the error message
+type Result struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the registered checks concurrently, each bounded by the timeout
+type Checker struct {
+	checks  []namedCheck
+	timeout time.Duration
+}
+
+// Constructor method for Checker
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Method to register a check under the given name
+func (checker *Checker) Add(name string, check Check) {
+	checker.checks = append(checker.checks, namedCheck{name: name, check: check})
+}
+
+// Method to run every check. The service is ready only if all of them pass
+func (checker *Checker) Run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout)
+	defer cancel()
+
+	result := Result{Status: StatusOK, Checks: make(map[string]string, len(checker.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checker.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status := StatusOK
+			if err := c.check(ctx); err != nil {
+				status = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Checks[c.name] = status
+			if status != StatusOK {
+				result.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
+
+// Check that the database answers
+func DatabaseCheck(store db.Store) Check {
+	return store.Ping
+}
+
+// Check that every migration this binary expects has been applied. A newer schema is accepted, so instances of
+// the previous release stay ready while a deployment with new migrations rolls out
+func SchemaCheck(store db.Store, expected int64) Check {
+	return func(ctx context.Context) error {
+		migration, err := store.GetSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		if migration.Dirty {
+			return fmt.Errorf("migration %d failed and must be fixed manually", migration.Version)
+		}
+		if migration.Version < expected {
+			return fmt.Errorf("schema version %d is behind the expected version %d", migration.Version, expected)
+		}
+		return nil
+	}
+}
+
+// Check that a background worker reported a heartbeat within maxAge
+func HeartbeatCheck(lastHeartbeat func() time.Time, maxAge time.Duration) Check {
+	return func(ctx context.Context) error {
+		last := lastHeartbeat()
+		if last.IsZero() {
+			return errors.New("no heartbeat yet")
+		}
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("last heartbeat %s ago", age.Round(time.Second))
+		}
+		return nil
+	}
+}
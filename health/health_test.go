@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"errors"
+	db "gobank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Store answering GetSchemaVersion with a fixed row, any other call panics
+type schemaStore struct {
+	db.Store
+	migration db.SchemaMigration
+}
+
+func (store schemaStore) GetSchemaVersion(ctx context.Context) (db.SchemaMigration, error) {
+	return store.migration, nil
+}
+
+func TestChecker(t *testing.T) {
+	checker := NewChecker(50 * time.Millisecond)
+	checker.Add("ok", func(ctx context.Context) error { return nil })
+	require.Equal(t, Result{Status: StatusOK, Checks: map[string]string{"ok": StatusOK}}, checker.Run(context.Background()))
+
+	// A failing check, and one stuck until the timeout, both make the service unready
+	checker.Add("broken", func(ctx context.Context) error { return errors.New("connection refused") })
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	result := checker.Run(context.Background())
+	require.Equal(t, StatusFail, result.Status)
+	require.Equal(t, StatusOK, result.Checks["ok"])
+	require.Equal(t, "connection refused", result.Checks["broken"])
+	require.Equal(t, context.DeadlineExceeded.Error(), result.Checks["slow"])
+}
+
+func TestSchemaCheck(t *testing.T) {
+	check := func(version int64, dirty bool) error {
+		store := schemaStore{migration: db.SchemaMigration{Version: version, Dirty: dirty}}
+		return SchemaCheck(store, 6)(context.Background())
+	}
+
+	require.NoError(t, check(6, false))
+	require.NoError(t, check(7, false))
+	require.Error(t, check(5, false))
+	require.Error(t, check(6, true))
+}
+
+func TestHeartbeatCheck(t *testing.T) {
+	check := func(last time.Time) error {
+		return HeartbeatCheck(func() time.Time { return last }, time.Minute)(context.Background())
+	}
+
+	require.NoError(t, check(time.Now()))
+	require.Error(t, check(time.Now().Add(-2*time.Minute)))
+	require.Error(t, check(time.Time{}))
+}
@@ -0,0 +1,112 @@
+package interest
+
+import (
+	"context"
+	db "gobank/db/sqlc"
+	"log/slog"
+	"time"
+)
+
+// Worker keeps the interest of savings accounts, and the overdraft interest of overdrawable accounts, up to date: it
+// accrues every day once it is over (UTC), and posts the interest of every month once its last day has accrued
+type Worker struct {
+	store     db.Store
+	logger    *slog.Logger
+	interval  time.Duration
+	batchSize int32
+}
+
+// Constructor method for Worker
+func NewWorker(store db.Store, logger *slog.Logger, interval time.Duration, batchSize int32) *Worker {
+	return &Worker{
+		store:     store,
+		logger:    logger,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Get the last day that is over, yesterday in UTC
+func LastCompleteDay(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.UTC)
+}
+
+// Method to accrue the interest of an account through the given day, posting every month it completes on the way.
+// Months are accrued and posted one at a time, so a month is always posted before the next one accrues
+func (worker *Worker) ProcessAccount(ctx context.Context, accountID int64, through time.Time) error {
+	for {
+		// Posting first also completes a month whose posting failed during a previous run
+		posting, err := worker.store.PostInterestTx(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		if posting.Posted > 0 {
+			worker.logger.Info("Interest posted", "account_id", accountID, "amount", posting.Posted,
+				"month", posting.Account.InterestPostedThrough.Time.Format("2006-01"))
+		}
+
+		accrual, err := worker.store.AccrueInterestTx(ctx, db.AccrueInterestTxParams{
+			AccountID: accountID,
+			Through:   through,
+		})
+		if err != nil {
+			return err
+		}
+		if accrual.Days == 0 {
+			return nil
+		}
+	}
+}
+
+// Method to bring every savings and overdrawable account up to date through the given day. An account that fails is logged and
+// skipped, it is retried on the next run. It returns the number of accounts processed successfully
+func (worker *Worker) RunOnce(ctx context.Context, through time.Time) (int, error) {
+	processed := 0
+
+	var afterAccountID int64
+	for {
+		accountIDs, err := worker.store.ListAccountsDueForInterest(ctx, db.ListAccountsDueForInterestParams{
+			Through:        through,
+			AfterAccountID: afterAccountID,
+			BatchSize:      worker.batchSize,
+		})
+		if err != nil {
+			return processed, err
+		}
+
+		for _, accountID := range accountIDs {
+			if err := worker.ProcessAccount(ctx, accountID, through); err != nil {
+				if ctx.Err() != nil {
+					return processed, ctx.Err()
+				}
+				worker.logger.Error("Failed to process interest", "account_id", accountID, "error", err)
+				continue
+			}
+			processed++
+		}
+
+		if len(accountIDs) < int(worker.batchSize) {
+			return processed, nil
+		}
+		afterAccountID = accountIDs[len(accountIDs)-1]
+	}
+}
+
+// Method to run the worker until the context is cancelled
+func (worker *Worker) Start(ctx context.Context) {
+	worker.logger.Info("Interest worker started")
+
+	for {
+		if _, err := worker.RunOnce(ctx, LastCompleteDay(time.Now())); err != nil && ctx.Err() == nil {
+			worker.logger.Error("Failed to process interest", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			worker.logger.Info("Interest worker stopped")
+			return
+		case <-time.After(worker.interval):
+		}
+	}
+}
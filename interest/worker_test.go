@@ -0,0 +1,19 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastCompleteDay(t *testing.T) {
+	// Just after midnight UTC, yesterday is over
+	require.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		LastCompleteDay(time.Date(2024, time.March, 1, 0, 5, 0, 0, time.UTC)))
+
+	// Days are in UTC, whatever the time zone of the clock
+	newYork := time.FixedZone("EST", -5*60*60)
+	require.Equal(t, time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC),
+		LastCompleteDay(time.Date(2023, time.December, 31, 20, 0, 0, 0, newYork)))
+}
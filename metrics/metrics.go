@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	db "gobank/db/sqlc"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metric namespace, prefixed to every application metric
+const namespace = "gobank"
+
+// Reasons for retrying a database transaction
+const (
+	RetrySerializationFailure = "serialization_failure"
+	RetryDeadlock             = "deadlock"
+)
+
+// Outcomes of a TransferTx call
+const (
+	OutcomeSuccess           = "success"
+	OutcomeInsufficientFunds = "insufficient_funds"
+	OutcomeAccountFrozen     = "account_frozen"
+	OutcomeDeadlock          = "deadlock"
+	OutcomeError             = "error"
+)
+
+// Metrics holds every application metric and the registry they are exposed from
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	transferTx         *prometheus.CounterVec
+	transferTxDuration *prometheus.HistogramVec
+
+	txRetries *prometheus.CounterVec
+}
+
+// Constructor method for Metrics. Go runtime and process metrics are registered too
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests handled, by route pattern, method and status code.",
+		}, []string{"route", "method", "status"}),
+
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests, by route pattern, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+
+		transferTx: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfer_tx_total",
+			Help:      "Number of transfer transactions, by outcome.",
+		}, []string{"outcome"}),
+
+		transferTxDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "transfer_tx_duration_seconds",
+			Help:      "Duration of transfer transactions, by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+
+		txRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_tx_retries_total",
+			Help:      "Number of database transactions run again after a conflict, by reason.",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.transferTx,
+		m.transferTxDuration,
+		m.txRetries,
+	)
+
+	return m
+}
+
+// Method to expose the connection pool statistics of a database (total, in use, idle connections, acquires...),
+// read from pool.Stat() on every scrape
+func (m *Metrics) RegisterDB(pool *pgxpool.Pool, name string) {
+	m.registry.MustRegister(newPoolCollector(pool, name))
+}
+
+// Get the handler serving the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Method to record a handled HTTP request
+func (m *Metrics) ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"route": route, "method": method, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// Method to record a TransferTx call
+func (m *Metrics) ObserveTransferTx(outcome string, duration time.Duration) {
+	m.transferTx.WithLabelValues(outcome).Inc()
+	m.transferTxDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+// Method to record the retry of a database transaction, given the SQLSTATE it failed with
+func (m *Metrics) ObserveTxRetry(code string) {
+	reason := RetrySerializationFailure
+	if code == db.DeadlockDetected {
+		reason = RetryDeadlock
+	}
+	m.txRetries.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	db "gobank/db/sqlc"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper method: scrape the metrics endpoint
+func scrape(t *testing.T, m *Metrics) string {
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestTransferOutcome(t *testing.T) {
+	require.Equal(t, OutcomeSuccess, transferOutcome(nil))
+	require.Equal(t, OutcomeInsufficientFunds, transferOutcome(db.ErrInsufficientFunds))
+	require.Equal(t, OutcomeAccountFrozen, transferOutcome(db.ErrAccountFrozen))
+	require.Equal(t, OutcomeDeadlock, transferOutcome(fmt.Errorf("transfer: %w", &pgconn.PgError{Code: db.DeadlockDetected})))
+	require.Equal(t, OutcomeError, transferOutcome(errors.New("connection refused")))
+}
+
+func TestObserve(t *testing.T) {
+	m := New()
+	m.ObserveHTTPRequest("GET /account/{id}", http.MethodGet, http.StatusOK, 10*time.Millisecond)
+	m.ObserveHTTPRequest("GET /account/{id}", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+	m.ObserveTransferTx(OutcomeInsufficientFunds, time.Millisecond)
+	m.ObserveTxRetry(db.DeadlockDetected)
+	m.ObserveTxRetry(db.SerializationFailure)
+	m.ObserveTxRetry(db.SerializationFailure)
+
+	body := scrape(t, m)
+	require.Contains(t, body, `gobank_http_requests_total{method="GET",route="GET /account/{id}",status="200"} 2`)
+	require.Contains(t, body, `gobank_http_request_duration_seconds_count{method="GET",route="GET /account/{id}",status="200"} 2`)
+	require.Contains(t, body, `gobank_transfer_tx_total{outcome="insufficient_funds"} 1`)
+	require.Contains(t, body, `gobank_transfer_tx_duration_seconds_bucket{outcome="insufficient_funds",le="0.005"} 1`)
+	require.Contains(t, body, `gobank_db_tx_retries_total{reason="deadlock"} 1`)
+	require.Contains(t, body, `gobank_db_tx_retries_total{reason="serialization_failure"} 2`)
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector exposing the statistics of a pgx connection pool, read on every scrape
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns         *prometheus.Desc
+	totalConns       *prometheus.Desc
+	acquiredConns    *prometheus.Desc
+	idleConns        *prometheus.Desc
+	acquires         *prometheus.Desc
+	acquireDuration  *prometheus.Desc
+	emptyAcquires    *prometheus.Desc
+	canceledAcquires *prometheus.Desc
+}
+
+// Constructor method for poolCollector. The name tells the pools apart, e.g. primary and replica
+func newPoolCollector(pool *pgxpool.Pool, name string) *poolCollector {
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_pool", metric),
+			help,
+			nil,
+			prometheus.Labels{"db_name": name},
+		)
+	}
+
+	return &poolCollector{
+		pool:             pool,
+		maxConns:         desc("max_conns", "Maximum size of the pool."),
+		totalConns:       desc("total_conns", "Number of connections currently in the pool."),
+		acquiredConns:    desc("acquired_conns", "Number of connections currently in use."),
+		idleConns:        desc("idle_conns", "Number of idle connections in the pool."),
+		acquires:         desc("acquires_total", "Number of connections acquired from the pool."),
+		acquireDuration:  desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquires:    desc("empty_acquires_total", "Number of acquires that had to wait because the pool was empty."),
+		canceledAcquires: desc("canceled_acquires_total", "Number of acquires cancelled by their context."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	db "gobank/db/sqlc"
+	"time"
+)
+
+// Store decorates a db.Store to record metrics about its transactions
+type Store struct {
+	db.Store
+	metrics *Metrics
+}
+
+// Constructor method for Store
+func NewStore(store db.Store, metrics *Metrics) db.Store {
+	return &Store{Store: store, metrics: metrics}
+}
+
+// Helper method: classify the result of a TransferTx call
+func transferOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return OutcomeInsufficientFunds
+	case errors.Is(err, db.ErrAccountFrozen):
+		return OutcomeAccountFrozen
+	case db.ErrorCode(err) == db.DeadlockDetected:
+		return OutcomeDeadlock
+	default:
+		return OutcomeError
+	}
+}
+
+func (store *Store) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	start := time.Now()
+	result, err := store.Store.TransferTx(ctx, arg)
+	store.metrics.ObserveTransferTx(transferOutcome(err), time.Since(start))
+	return result, err
+}
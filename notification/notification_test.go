@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "gobank/db/sqlc"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	pref := db.NotificationPreference{
+		LowBalanceThreshold: sql.NullInt64{Int64: 10000, Valid: true},
+		LargeDebitThreshold: sql.NullInt64{Int64: 50000, Valid: true},
+		ThresholdCurrency:   sql.NullString{String: "USD", Valid: true},
+	}
+
+	testCases := []struct {
+		name     string
+		balance  int64 // balance after the debit
+		amount   int64
+		expected []string
+	}{
+		{"small debit", 90000, 1000, nil},
+		{"large debit", 90000, 60000, []string{KindLargeDebit}},
+		{"debit equal to threshold", 90000, 50000, nil},
+		{"balance crosses threshold", 9000, 2000, []string{KindLowBalance}},
+		{"balance already below threshold", 5000, 2000, nil},
+		{"large debit crossing threshold", 0, 60000, []string{KindLargeDebit, KindLowBalance}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			account := db.Account{AccountID: 1, Owner: "alice", Balance: tc.balance, Currency: "USD"}
+
+			var kinds []string
+			for _, trigger := range evaluate(pref, account, tc.amount) {
+				kinds = append(kinds, trigger.kind)
+			}
+			require.Equal(t, tc.expected, kinds)
+		})
+	}
+
+	// Without thresholds, nothing is ever triggered
+	account := db.Account{AccountID: 1, Owner: "alice", Balance: 0, Currency: "USD"}
+	require.Empty(t, evaluate(db.NotificationPreference{}, account, 1000000))
+
+	// Neither is it by accounts in another currency, whose minor units aren't comparable
+	account.Currency = "VND"
+	require.Empty(t, evaluate(pref, account, 1000000))
+}
+
+func TestRender(t *testing.T) {
+	subject, body, err := render(KindLargeDebit, templateData{
+		Owner:     "alice",
+		AccountID: 42,
+		Currency:  "USD",
+		Amount:    "600.00",
+		Balance:   "300.00",
+		Threshold: "500.00",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "600.00 USD debited from account 42", subject)
+	require.Contains(t, body, "Hi alice,")
+	require.Contains(t, body, "over your alert threshold of 500.00 USD")
+	require.Contains(t, body, "Your new balance is 300.00 USD.")
+}
+
+// Store with the preferences of alice and the deliveries recorded in memory, every other method panics
+type deliveryStore struct {
+	db.Store
+	pref       db.NotificationPreference
+	deliveries []db.NotificationDelivery
+}
+
+func (store *deliveryStore) GetNotificationPreference(ctx context.Context, owner string) (db.NotificationPreference, error) {
+	return store.pref, nil
+}
+
+func (store *deliveryStore) ListNotificationDeliveries(ctx context.Context, transferID int64) ([]db.NotificationDelivery, error) {
+	return store.deliveries, nil
+}
+
+func (store *deliveryStore) CreateNotificationDelivery(ctx context.Context, arg db.CreateNotificationDeliveryParams) error {
+	store.deliveries = append(store.deliveries, db.NotificationDelivery{TransferID: arg.TransferID, Kind: arg.Kind, Channel: arg.Channel})
+	return nil
+}
+
+// Notifier counting the messages sent, failing while err is set
+type countingNotifier struct {
+	sent int
+	err  error
+}
+
+func (notifier *countingNotifier) Notify(ctx context.Context, msg Message) error {
+	if notifier.err != nil {
+		return notifier.err
+	}
+	notifier.sent++
+	return nil
+}
+
+func TestRetryOnlyFailedChannels(t *testing.T) {
+	store := &deliveryStore{pref: db.NotificationPreference{
+		Owner:               "alice",
+		Email:               sql.NullString{String: "alice@example.com", Valid: true},
+		Phone:               sql.NullString{String: "+15550100", Valid: true},
+		EmailEnabled:        true,
+		SmsEnabled:          true,
+		LargeDebitThreshold: sql.NullInt64{Int64: 50000, Valid: true},
+		ThresholdCurrency:   sql.NullString{String: "USD", Valid: true},
+	}}
+	email, sms := &countingNotifier{}, &countingNotifier{err: errors.New("provider down")}
+	service := NewService(store, email, sms)
+
+	result := db.TransferTxResult{
+		Transfer:    db.Transfer{TransferID: 7, Amount: 60000},
+		FromAccount: db.Account{AccountID: 1, Owner: "alice", Balance: 1000, Currency: "USD"},
+	}
+
+	// The SMS fails, the email goes out and is recorded
+	require.Error(t, service.OnTransfer(context.Background(), result))
+	require.Equal(t, 1, email.sent)
+	require.Len(t, store.deliveries, 1)
+
+	// The retry only sends the SMS
+	sms.err = nil
+	require.NoError(t, service.OnTransfer(context.Background(), result))
+	require.Equal(t, 1, email.sent)
+	require.Equal(t, 1, sms.sent)
+
+	// Nothing is sent again once every channel got it
+	require.NoError(t, service.OnTransfer(context.Background(), result))
+	require.Equal(t, 1, email.sent)
+	require.Equal(t, 1, sms.sent)
+}
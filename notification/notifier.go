@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strings"
+)
+
+// Delivery channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// A rendered message ready to be sent
+type Message struct {
+	Channel string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier sends messages over a single channel
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// LogNotifier writes messages to the logger instead of sending them. Used in development and for channels
+// without a real provider
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+// Constructor method for LogNotifier
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (notifier *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	notifier.logger.Info("Notification",
+		"channel", msg.Channel, "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// SMTPNotifier sends emails through an SMTP server without authentication, such as a local mail sink
+// (MailHog, Mailpit) in development or a relay on the same host in production
+type SMTPNotifier struct {
+	address string
+	from    string
+}
+
+// Constructor method for SMTPNotifier
+func NewSMTPNotifier(address, from string) *SMTPNotifier {
+	return &SMTPNotifier{address: address, from: from}
+}
+
+func (notifier *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	// Header values must not contain line breaks, or they could inject extra headers
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid email header in message to %q", msg.To)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", notifier.from)
+	fmt.Fprintf(&sb, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", msg.Subject)
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return smtp.SendMail(notifier.address, nil, notifier.from, []string{msg.To}, []byte(sb.String()))
+}
@@ -0,0 +1,153 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	db "gobank/db/sqlc"
+	"gobank/util"
+)
+
+// Service decides which notifications an account change triggers, based on the owner's preferences,
+// and sends them through the notifier of each channel
+type Service struct {
+	store     db.Store
+	notifiers map[string]Notifier
+}
+
+// Constructor method for Service
+func NewService(store db.Store, email, sms Notifier) *Service {
+	return &Service{
+		store: store,
+		notifiers: map[string]Notifier{
+			ChannelEmail: email,
+			ChannelSMS:   sms,
+		},
+	}
+}
+
+// A notification triggered by an account change, before rendering
+type trigger struct {
+	kind      string
+	account   db.Account
+	amount    int64
+	threshold int64
+}
+
+// Helper method: evaluate the notification rules for a debit of amount from account, whose balance is already updated.
+// Thresholds are in the minor units of their currency, so accounts in any other currency never trigger them
+func evaluate(pref db.NotificationPreference, account db.Account, amount int64) []trigger {
+	if !pref.ThresholdCurrency.Valid || pref.ThresholdCurrency.String != account.Currency {
+		return nil
+	}
+
+	var triggers []trigger
+
+	if pref.LargeDebitThreshold.Valid && amount > pref.LargeDebitThreshold.Int64 {
+		triggers = append(triggers, trigger{KindLargeDebit, account, amount, pref.LargeDebitThreshold.Int64})
+	}
+
+	// Only notify when the balance crosses the threshold, not on every debit while it stays below
+	if pref.LowBalanceThreshold.Valid &&
+		account.Balance < pref.LowBalanceThreshold.Int64 &&
+		account.Balance+amount >= pref.LowBalanceThreshold.Int64 {
+		triggers = append(triggers, trigger{KindLowBalance, account, amount, pref.LowBalanceThreshold.Int64})
+	}
+
+	return triggers
+}
+
+// Method to send the notifications triggered by a transfer. Only the sender is debited
+func (service *Service) OnTransfer(ctx context.Context, result db.TransferTxResult) error {
+	return service.onDebit(ctx, result.Transfer.TransferID, result.FromAccount, result.Transfer.Amount)
+}
+
+// Method to send the notifications triggered by a withdrawal
+func (service *Service) OnWithdraw(ctx context.Context, result db.WithdrawTxResult) error {
+	return service.onDebit(ctx, result.Transfer.TransferID, result.Account, -result.Entry.Amount)
+}
+
+// Helper method: load the owner's preferences and send every notification the debit triggers. The notifications
+// already delivered for the transfer, by a previous attempt of the task, are not sent again
+func (service *Service) onDebit(ctx context.Context, transferID int64, account db.Account, amount int64) error {
+	pref, err := service.store.GetNotificationPreference(ctx, account.Owner)
+	if err != nil {
+		// Owners without preferences don't get notified
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	triggers := evaluate(pref, account, amount)
+	if len(triggers) == 0 {
+		return nil
+	}
+
+	deliveries, err := service.store.ListNotificationDeliveries(ctx, transferID)
+	if err != nil {
+		return err
+	}
+	delivered := make(map[delivery]bool, len(deliveries))
+	for _, d := range deliveries {
+		delivered[delivery{d.Kind, d.Channel}] = true
+	}
+
+	var errs []error
+	for _, t := range triggers {
+		errs = append(errs, service.send(ctx, transferID, pref, t, delivered))
+	}
+
+	return errors.Join(errs...)
+}
+
+// A notification kind sent on a channel
+type delivery struct {
+	kind    string
+	channel string
+}
+
+// Helper method: render a triggered notification and send it on every channel the owner enabled and that didn't
+// get it yet
+func (service *Service) send(ctx context.Context, transferID int64, pref db.NotificationPreference, t trigger, delivered map[delivery]bool) error {
+	currency := t.account.Currency
+	subject, body, err := render(t.kind, templateData{
+		Owner:     t.account.Owner,
+		AccountID: t.account.AccountID,
+		Currency:  currency,
+		Amount:    util.FormatAmount(t.amount, currency),
+		Balance:   util.FormatAmount(t.account.Balance, currency),
+		Threshold: util.FormatAmount(t.threshold, currency),
+	})
+	if err != nil {
+		return err
+	}
+
+	var messages []Message
+	if pref.EmailEnabled && pref.Email.Valid {
+		messages = append(messages, Message{Channel: ChannelEmail, To: pref.Email.String, Subject: subject, Body: body})
+	}
+	if pref.SmsEnabled && pref.Phone.Valid {
+		messages = append(messages, Message{Channel: ChannelSMS, To: pref.Phone.String, Subject: subject, Body: subject})
+	}
+
+	var errs []error
+	for _, msg := range messages {
+		if delivered[delivery{t.kind, msg.Channel}] {
+			continue
+		}
+
+		if err := service.notifiers[msg.Channel].Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Should recording fail, the retry sends this one again: at least once, never lost
+		errs = append(errs, service.store.CreateNotificationDelivery(ctx, db.CreateNotificationDeliveryParams{
+			TransferID: transferID,
+			Kind:       t.kind,
+			Channel:    msg.Channel,
+		}))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"strings"
+	"text/template"
+)
+
+// Notification kinds
+const (
+	KindLowBalance = "low_balance"
+	KindLargeDebit = "large_debit"
+)
+
+// Data available to the templates. Amounts are already formatted in the account currency
+type templateData struct {
+	Owner     string
+	AccountID int64
+	Currency  string
+	Amount    string
+	Balance   string
+	Threshold string
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var templates = map[string]messageTemplate{
+	KindLowBalance: {
+		subject: template.Must(template.New("subject").Parse(
+			`Low balance on account {{.AccountID}}`)),
+		body: template.Must(template.New("body").Parse(
+			`Hi {{.Owner}},
+
+The balance of your account {{.AccountID}} is now {{.Balance}} {{.Currency}}, below your alert threshold of {{.Threshold}} {{.Currency}}.
+`)),
+	},
+	KindLargeDebit: {
+		subject: template.Must(template.New("subject").Parse(
+			`{{.Amount}} {{.Currency}} debited from account {{.AccountID}}`)),
+		body: template.Must(template.New("body").Parse(
+			`Hi {{.Owner}},
+
+{{.Amount}} {{.Currency}} has been debited from your account {{.AccountID}}, which is over your alert threshold of {{.Threshold}} {{.Currency}}.
+Your new balance is {{.Balance}} {{.Currency}}.
+
+If you don't recognise this transaction, please contact us immediately.
+`)),
+	},
+}
+
+// Helper method: render the subject and the body of a notification
+func render(kind string, data templateData) (subject, body string, err error) {
+	tmpl := templates[kind]
+
+	var sb strings.Builder
+	if err := tmpl.subject.Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+	subject = sb.String()
+
+	sb.Reset()
+	if err := tmpl.body.Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+
+	return subject, sb.String(), nil
+}
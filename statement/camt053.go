@@ -0,0 +1,213 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/util"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// ISO 20022 camt.053 (bank to customer statement)
+type camt053Writer struct {
+	w      io.Writer
+	enc    *xml.Encoder
+	header db.StatementTxResult
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtDate struct {
+	DtTm string `xml:"DtTm"`
+}
+
+type camtAccount struct {
+	Id struct {
+		Othr struct {
+			Id string `xml:"Id"`
+		} `xml:"Othr"`
+	} `xml:"Id"`
+}
+
+// A single balance (<Bal>) element
+type camtBalance struct {
+	XMLName xml.Name `xml:"Bal"`
+	Tp      struct {
+		CdOrPrtry struct {
+			Cd string `xml:"Cd"`
+		} `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        camtDate   `xml:"Dt"`
+}
+
+// A single entry (<Ntry>) element
+type camtEntry struct {
+	XMLName     xml.Name   `xml:"Ntry"`
+	NtryRef     string     `xml:"NtryRef"`
+	Amt         camtAmount `xml:"Amt"`
+	CdtDbtInd   string     `xml:"CdtDbtInd"`
+	Sts         string     `xml:"Sts"`
+	BookgDt     camtDate   `xml:"BookgDt"`
+	ValDt       camtDate   `xml:"ValDt"`
+	AcctSvcrRef string     `xml:"AcctSvcrRef"`
+	BkTxCd      struct {
+		Prtry struct {
+			Cd string `xml:"Cd"`
+		} `xml:"Prtry"`
+	} `xml:"BkTxCd"`
+	NtryDtls *camtEntryDetails `xml:"NtryDtls,omitempty"`
+}
+
+type camtEntryDetails struct {
+	TxDtls struct {
+		Refs      *camtReferences `xml:"Refs,omitempty"`
+		RltdPties *camtParties    `xml:"RltdPties,omitempty"`
+		RmtInf    *camtRemittance `xml:"RmtInf,omitempty"`
+	} `xml:"TxDtls"`
+}
+
+// The end-to-end reference chosen by the sender
+type camtReferences struct {
+	EndToEndId string `xml:"EndToEndId"`
+}
+
+type camtParties struct {
+	DbtrAcct *camtAccount `xml:"DbtrAcct,omitempty"`
+	CdtrAcct *camtAccount `xml:"CdtrAcct,omitempty"`
+}
+
+// The description of the transfer, as unstructured remittance information
+type camtRemittance struct {
+	Ustrd string `xml:"Ustrd"`
+}
+
+// Format a time as an ISO 8601 date time in UTC
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// Helper method: split a signed amount into its absolute value and the credit/debit indicator
+func camtSignedAmount(amount int64, currency string) (camtAmount, string) {
+	if amount < 0 {
+		return camtAmount{Ccy: currency, Value: util.FormatAmount(-amount, currency)}, "DBIT"
+	}
+	return camtAmount{Ccy: currency, Value: util.FormatAmount(amount, currency)}, "CRDT"
+}
+
+func newCamtAccount(accountID int64) *camtAccount {
+	var account camtAccount
+	account.Id.Othr.Id = strconv.FormatInt(accountID, 10)
+	return &account
+}
+
+func newCamt053Writer(w io.Writer) *camt053Writer {
+	return &camt053Writer{w: w, enc: xml.NewEncoder(w)}
+}
+
+func (cw *camt053Writer) WriteHeader(header db.StatementTxResult) error {
+	cw.header = header
+	account := header.Account
+	now := time.Now()
+	statementID := fmt.Sprintf("%d-%s-%s", account.AccountID, header.From.UTC().Format("20060102"), header.To.UTC().Format("20060102"))
+
+	if _, err := io.WriteString(cw.w, xml.Header); err != nil {
+		return err
+	}
+
+	// Group header and statement header, written by hand since the elements stay open while entries are streamed
+	var owner strings.Builder
+	if err := xml.EscapeText(&owner, []byte(account.Owner)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(cw.w, `<Document xmlns="%s"><BkToCstmrStmt>`+
+		"<GrpHdr><MsgId>%s</MsgId><CreDtTm>%s</CreDtTm></GrpHdr>"+
+		"<Stmt><Id>%s</Id><CreDtTm>%s</CreDtTm><FrToDt><FrDtTm>%s</FrDtTm><ToDtTm>%s</ToDtTm></FrToDt>"+
+		"<Acct><Id><Othr><Id>%d</Id></Othr></Id><Ccy>%s</Ccy><Ownr><Nm>%s</Nm></Ownr></Acct>",
+		camt053Namespace,
+		statementID,
+		camtTime(now),
+		statementID,
+		camtTime(now),
+		camtTime(header.From),
+		camtTime(header.To),
+		account.AccountID,
+		account.Currency,
+		owner.String(),
+	); err != nil {
+		return err
+	}
+
+	// Opening booked and closing booked balances
+	if err := cw.enc.Encode(cw.balance("OPBD", header.OpeningBalance, header.From)); err != nil {
+		return err
+	}
+	return cw.enc.Encode(cw.balance("CLBD", header.ClosingBalance, header.To))
+}
+
+func (cw *camt053Writer) WriteLine(line db.ListAccountEntriesRow) error {
+	entryRef := strconv.FormatInt(line.EntryID, 10)
+	booked := camtDate{DtTm: camtTime(bookedAt(line))}
+
+	entry := camtEntry{
+		NtryRef:     entryRef,
+		Sts:         "BOOK",
+		BookgDt:     booked,
+		ValDt:       booked,
+		AcctSvcrRef: entryRef,
+	}
+	entry.Amt, entry.CdtDbtInd = camtSignedAmount(line.Amount, cw.header.Account.Currency)
+	entry.BkTxCd.Prtry.Cd = "ENTRY"
+
+	var details camtEntryDetails
+	if line.Reference != "" {
+		details.TxDtls.Refs = &camtReferences{EndToEndId: line.Reference}
+	}
+	if line.TransferID.Valid {
+		entry.AcctSvcrRef = strconv.FormatInt(line.TransferID.Int64, 10)
+		entry.BkTxCd.Prtry.Cd = "TRANSFER"
+
+		// The related party is the debtor for incoming money and the creditor for outgoing money
+		details.TxDtls.RltdPties = &camtParties{}
+		if line.Amount < 0 {
+			details.TxDtls.RltdPties.CdtrAcct = newCamtAccount(counterparty(line))
+		} else {
+			details.TxDtls.RltdPties.DbtrAcct = newCamtAccount(counterparty(line))
+		}
+	}
+	if line.Description != "" {
+		details.TxDtls.RmtInf = &camtRemittance{Ustrd: line.Description}
+	}
+	if details.TxDtls.Refs != nil || details.TxDtls.RltdPties != nil || details.TxDtls.RmtInf != nil {
+		entry.NtryDtls = &details
+	}
+
+	return cw.enc.Encode(entry)
+}
+
+func (cw *camt053Writer) Close() error {
+	if err := cw.enc.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(cw.w, "</Stmt></BkToCstmrStmt></Document>\n")
+	return err
+}
+
+// Helper method: build a balance element
+func (cw *camt053Writer) balance(code string, amount int64, at time.Time) camtBalance {
+	var bal camtBalance
+	bal.Tp.CdOrPrtry.Cd = code
+	bal.Amt, bal.CdtDbtInd = camtSignedAmount(amount, cw.header.Account.Currency)
+	bal.Dt.DtTm = camtTime(at)
+	return bal
+}
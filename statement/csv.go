@@ -0,0 +1,83 @@
+package statement
+
+import (
+	"encoding/csv"
+	db "gobank/db/sqlc"
+	"gobank/util"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSV statement: one row per entry with a running balance, framed by an opening and a closing balance row
+type csvWriter struct {
+	w        *csv.Writer
+	header   db.StatementTxResult
+	currency string
+	balance  int64
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) WriteHeader(header db.StatementTxResult) error {
+	cw.header = header
+	cw.currency = header.Account.Currency
+	cw.balance = header.OpeningBalance
+
+	if err := cw.w.Write([]string{
+		"type", "entry_id", "booked_at", "amount", "currency", "balance", "counterparty_account_id", "transfer_id",
+		"description", "reference", "metadata",
+	}); err != nil {
+		return err
+	}
+
+	return cw.writeBalance("opening_balance", header.From, header.OpeningBalance)
+}
+
+func (cw *csvWriter) WriteLine(line db.ListAccountEntriesRow) error {
+	cw.balance += line.Amount
+
+	kind := "credit"
+	if line.Amount < 0 {
+		kind = "debit"
+	}
+
+	var counterpartyID, transferID string
+	if line.TransferID.Valid {
+		counterpartyID = strconv.FormatInt(counterparty(line), 10)
+		transferID = strconv.FormatInt(line.TransferID.Int64, 10)
+	}
+
+	return cw.w.Write([]string{
+		kind,
+		strconv.FormatInt(line.EntryID, 10),
+		bookedAt(line).Format(time.RFC3339),
+		util.FormatAmount(line.Amount, cw.currency),
+		cw.currency,
+		util.FormatAmount(cw.balance, cw.currency),
+		counterpartyID,
+		transferID,
+		line.Description,
+		line.Reference,
+		metadata(line),
+	})
+}
+
+func (cw *csvWriter) Close() error {
+	if err := cw.writeBalance("closing_balance", cw.header.To, cw.header.ClosingBalance); err != nil {
+		return err
+	}
+
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// Helper method: write an opening or closing balance row
+func (cw *csvWriter) writeBalance(kind string, at time.Time, balance int64) error {
+	return cw.w.Write([]string{
+		kind, "", at.UTC().Format(time.RFC3339), "", cw.currency, util.FormatAmount(balance, cw.currency), "", "",
+		"", "", "",
+	})
+}
@@ -0,0 +1,105 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	db "gobank/db/sqlc"
+	"gobank/util"
+	"io"
+	"strconv"
+	"time"
+)
+
+// OFX 2.2 (XML) bank statement, as imported by most accounting software
+type ofxWriter struct {
+	w      io.Writer
+	enc    *xml.Encoder
+	header db.StatementTxResult
+}
+
+// A single <STMTTRN> element
+type ofxTransaction struct {
+	XMLName  xml.Name `xml:"STMTTRN"`
+	TrnType  string   `xml:"TRNTYPE"`
+	DtPosted string   `xml:"DTPOSTED"`
+	TrnAmt   string   `xml:"TRNAMT"`
+	FitID    string   `xml:"FITID"`
+	RefNum   string   `xml:"REFNUM,omitempty"`
+	Name     string   `xml:"NAME,omitempty"`
+	Memo     string   `xml:"MEMO,omitempty"`
+}
+
+// Format a time the way OFX expects it: YYYYMMDDHHMMSS.XXX[offset:TZ]
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	return &ofxWriter{w: w, enc: xml.NewEncoder(w)}
+}
+
+func (ow *ofxWriter) WriteHeader(header db.StatementTxResult) error {
+	ow.header = header
+	account := header.Account
+
+	if _, err := io.WriteString(ow.w, xml.Header+
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`+"\n"); err != nil {
+		return err
+	}
+
+	// Everything up to the opening of the transaction list
+	_, err := fmt.Fprintf(ow.w, "<OFX>"+
+		"<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>"+
+		"<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>"+
+		"<BANKMSGSRSV1><STMTTRNRS><TRNUID>%d</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>"+
+		"<STMTRS><CURDEF>%s</CURDEF>"+
+		"<BANKACCTFROM><BANKID>GOBANK</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>"+
+		"<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>",
+		ofxTime(time.Now()),
+		account.AccountID,
+		account.Currency,
+		account.AccountID,
+		ofxTime(header.From),
+		ofxTime(header.To),
+	)
+	return err
+}
+
+func (ow *ofxWriter) WriteLine(line db.ListAccountEntriesRow) error {
+	currency := ow.header.Account.Currency
+
+	trn := ofxTransaction{
+		TrnType:  "CREDIT",
+		DtPosted: ofxTime(bookedAt(line)),
+		TrnAmt:   util.FormatAmount(line.Amount, currency),
+		FitID:    strconv.FormatInt(line.EntryID, 10),
+		RefNum:   line.Reference,
+		Memo:     line.Description,
+	}
+	if line.Amount < 0 {
+		trn.TrnType = "DEBIT"
+	}
+	if line.TransferID.Valid {
+		trn.Name = fmt.Sprintf("Account %d", counterparty(line))
+		if trn.Memo == "" {
+			trn.Memo = fmt.Sprintf("Transfer %d", line.TransferID.Int64)
+		}
+	}
+
+	return ow.enc.Encode(trn)
+}
+
+func (ow *ofxWriter) Close() error {
+	if err := ow.enc.Flush(); err != nil {
+		return err
+	}
+
+	currency := ow.header.Account.Currency
+	_, err := fmt.Fprintf(ow.w, "</BANKTRANLIST>"+
+		"<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>"+
+		"</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n",
+		util.FormatAmount(ow.header.ClosingBalance, currency),
+		ofxTime(ow.header.To),
+	)
+	return err
+}
@@ -0,0 +1,89 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	db "gobank/db/sqlc"
+	"io"
+	"time"
+)
+
+// Supported statement formats
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported statement format")
+
+// Writer encodes an account statement while it is being streamed from the database.
+// WriteHeader is called exactly once before any WriteLine, and Close flushes whatever the format needs at the end
+type Writer interface {
+	WriteHeader(header db.StatementTxResult) error
+	WriteLine(line db.ListAccountEntriesRow) error
+	Close() error
+}
+
+// Constructor method for Writer based on the requested format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatOFX:
+		return newOFXWriter(w), nil
+	case FormatCamt053:
+		return newCamt053Writer(w), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Get the MIME type of a statement format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatOFX:
+		return "application/x-ofx"
+	default:
+		return "application/xml"
+	}
+}
+
+// Get the file extension of a statement format
+func Extension(format string) string {
+	switch format {
+	case FormatCamt053:
+		return "xml"
+	default:
+		return format
+	}
+}
+
+// Helper method: get the account on the other side of the transfer that produced the entry, or 0 for entries
+// that don't come from a transfer
+func counterparty(line db.ListAccountEntriesRow) int64 {
+	if !line.TransferID.Valid {
+		return 0
+	}
+	if line.FromAccountID.Int64 == line.AccountID {
+		return line.ToAccountID.Int64
+	}
+	return line.FromAccountID.Int64
+}
+
+// Helper method: get the metadata of an entry as compact JSON, or "" when it has none
+func metadata(line db.ListAccountEntriesRow) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, line.Metadata); err != nil || buf.String() == "{}" {
+		return ""
+	}
+	return buf.String()
+}
+
+// Helper method: get the booking time of an entry in UTC
+func bookedAt(line db.ListAccountEntriesRow) time.Time {
+	return line.CreatedAt.Time.UTC()
+}
@@ -0,0 +1,103 @@
+package statement
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	db "gobank/db/sqlc"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Helper method: write a small statement of account 1 with one outgoing transfer, with its details, and one incoming
+// transfer without
+func writeStatement(t *testing.T, format string) []byte {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WriteHeader(db.StatementTxResult{
+		Account:        db.Account{AccountID: 1, Owner: "Tom & Jerry", Currency: "USD"},
+		From:           from,
+		To:             to,
+		OpeningBalance: 10000,
+		ClosingBalance: 9750,
+	}))
+
+	lines := []db.ListAccountEntriesRow{
+		{EntryID: 1, AccountID: 1, Amount: -500, TransferID: sql.NullInt64{Int64: 7, Valid: true},
+			FromAccountID: sql.NullInt64{Int64: 1, Valid: true}, ToAccountID: sql.NullInt64{Int64: 2, Valid: true},
+			CreatedAt:   sql.NullTime{Time: from.Add(time.Hour), Valid: true},
+			Description: "Rent <January>", Reference: "INV-2025-001", Metadata: json.RawMessage(`{"unit": 4}`)},
+		{EntryID: 4, AccountID: 1, Amount: 250, TransferID: sql.NullInt64{Int64: 8, Valid: true},
+			FromAccountID: sql.NullInt64{Int64: 3, Valid: true}, ToAccountID: sql.NullInt64{Int64: 1, Valid: true},
+			CreatedAt: sql.NullTime{Time: from.Add(2 * time.Hour), Valid: true}, Metadata: json.RawMessage(`{}`)},
+	}
+	for _, line := range lines {
+		require.NoError(t, writer.WriteLine(line))
+	}
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+// Helper method: check that the output is well formed XML
+func requireWellFormedXML(t *testing.T, data []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestCSVStatement(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeStatement(t, FormatCSV))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	require.Equal(t, []string{"opening_balance", "", "2025-01-01T00:00:00Z", "", "USD", "100.00", "", "", "", "", ""}, records[1])
+	require.Equal(t, []string{"debit", "1", "2025-01-01T01:00:00Z", "-5.00", "USD", "95.00", "2", "7",
+		"Rent <January>", "INV-2025-001", `{"unit":4}`}, records[2])
+	require.Equal(t, []string{"credit", "4", "2025-01-01T02:00:00Z", "2.50", "USD", "97.50", "3", "8", "", "", ""}, records[3])
+	require.Equal(t, []string{"closing_balance", "", "2025-02-01T00:00:00Z", "", "USD", "97.50", "", "", "", "", ""}, records[4])
+}
+
+func TestOFXStatement(t *testing.T) {
+	data := writeStatement(t, FormatOFX)
+	requireWellFormedXML(t, data)
+
+	require.Contains(t, string(data), "<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250101010000.000[0:GMT]</DTPOSTED><TRNAMT>-5.00</TRNAMT>")
+	require.Contains(t, string(data), "<LEDGERBAL><BALAMT>97.50</BALAMT>")
+
+	// The description replaces the default memo, which is kept for transfers without one
+	require.Contains(t, string(data), "<REFNUM>INV-2025-001</REFNUM><NAME>Account 2</NAME><MEMO>Rent &lt;January&gt;</MEMO>")
+	require.Contains(t, string(data), "<NAME>Account 3</NAME><MEMO>Transfer 8</MEMO>")
+}
+
+func TestCamt053Statement(t *testing.T) {
+	data := writeStatement(t, FormatCamt053)
+	requireWellFormedXML(t, data)
+
+	require.Contains(t, string(data), "<Nm>Tom &amp; Jerry</Nm>")
+	require.Contains(t, string(data), `<Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="USD">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>`)
+	require.Contains(t, string(data), `<Amt Ccy="USD">5.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>`)
+	require.Contains(t, string(data), "<CdtrAcct><Id><Othr><Id>2</Id></Othr></Id></CdtrAcct>")
+	require.Contains(t, string(data), "<Refs><EndToEndId>INV-2025-001</EndToEndId></Refs>")
+	require.Contains(t, string(data), "<RmtInf><Ustrd>Rent &lt;January&gt;</Ustrd></RmtInf>")
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("pdf", io.Discard)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+	"gobank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExporter(t *testing.T) {
+	exporter, err := newExporter(context.Background(), util.Config{})
+	require.NoError(t, err)
+	require.Nil(t, exporter)
+
+	exporter, err = newExporter(context.Background(), util.Config{Tracing: util.TracingConfig{Exporter: ExporterStdout}})
+	require.NoError(t, err)
+	require.NotNil(t, exporter)
+
+	_, err = newExporter(context.Background(), util.Config{Tracing: util.TracingConfig{Exporter: "jaeger"}})
+	require.Error(t, err)
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), util.Config{Tracing: util.TracingConfig{Exporter: ExporterNone}})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
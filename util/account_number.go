@@ -0,0 +1,32 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Number of digits of an account number: 14 random digits followed by 2 check digits
+const AccountNumberLength = 16
+
+// Utility method: validate an account number typed by a customer, and get it without the spaces and hyphens used to
+// group its digits. The check digits make a valid number equal to 1 modulo 97, like an IBAN, which catches every
+// single digit typo and most swapped digits
+func NormalizeAccountNumber(raw string) (string, bool) {
+	number := strings.NewReplacer(" ", "", "-", "").Replace(raw)
+	if len(number) != AccountNumberLength {
+		return "", false
+	}
+
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return "", false
+		}
+	}
+
+	// 16 digits always fit in 64 bits
+	value, err := strconv.ParseUint(number, 10, 64)
+	if err != nil || value%97 != 1 {
+		return "", false
+	}
+	return number, true
+}
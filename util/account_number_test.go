@@ -0,0 +1,33 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAccountNumber(t *testing.T) {
+	testCases := []struct {
+		raw    string
+		number string
+	}{
+		{raw: "1234567890123428", number: "1234567890123428"},
+		{raw: "1234 5678 9012 3428", number: "1234567890123428"},
+		{raw: "1234-5678-9012-3428", number: "1234567890123428"},
+		{raw: "0000000000000195", number: "0000000000000195"}, // Leading zeros are kept
+		{raw: "9999999999999939", number: "9999999999999939"},
+		{raw: "1234567890123429"},  // Wrong check digits
+		{raw: "1234567809123428"},  // Swapped digits
+		{raw: "1234567890124428"},  // One digit mistyped
+		{raw: "123456789012342"},   // Too short
+		{raw: "12345678901234280"}, // Too long
+		{raw: "12345678901234+8"},
+		{raw: ""},
+	}
+
+	for _, tc := range testCases {
+		number, ok := NormalizeAccountNumber(tc.raw)
+		require.Equal(t, tc.number != "", ok, tc.raw)
+		require.Equal(t, tc.number, number, tc.raw)
+	}
+}
@@ -1,27 +1,287 @@
 package util
 
-import "github.com/spf13/viper"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
-// Holds all application configurations
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+)
+
+// Holds all application configurations. Every setting has a key in the config file (e.g. server.port), an
+// environment variable overriding it (e.g. PORT) and optionally a default value
 type Config struct {
-	DbDriver string `mapstructure:"DB_DRIVER"`
-	DbSource string `mapstructure:"DB_SOURCE"`
-	Domain   string `mapstructure:"DOMAIN"`
-	Port     string `mapstructure:"PORT"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Workers      WorkersConfig      `mapstructure:"workers"`
+	Health       HealthConfig       `mapstructure:"health"`
+	Outbox       OutboxConfig       `mapstructure:"outbox"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	Tracing      TracingConfig      `mapstructure:"tracing"`
+	Payee        PayeeConfig        `mapstructure:"payee"`
+}
+
+// HTTP server address and timeouts, and how long to wait for in-flight requests and workers when shutting down.
+// Streamed statements can take longer than WriteTimeout to send, so their deadline is StatementWriteTimeout instead.
+// Each one holds a database connection and transaction until it is sent, so at most StatementConcurrency run at once
+type ServerConfig struct {
+	Domain          string        `mapstructure:"domain" env:"DOMAIN"`
+	Port            string        `mapstructure:"port" env:"PORT" validate:"required,numeric"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout" env:"READ_TIMEOUT" default:"10s" validate:"gt=0"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout" env:"WRITE_TIMEOUT" default:"30s" validate:"gt=0"`
+	IdleTimeout     time.Duration `mapstructure:"idle_timeout" env:"IDLE_TIMEOUT" default:"120s" validate:"gt=0"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"20s" validate:"gt=0"`
+
+	StatementWriteTimeout time.Duration `mapstructure:"statement_write_timeout" env:"STATEMENT_WRITE_TIMEOUT" default:"10m" validate:"gt=0"`
+	StatementConcurrency  int           `mapstructure:"statement_concurrency" env:"STATEMENT_CONCURRENCY" default:"4" validate:"gte=1"`
+}
+
+// Database connection. At startup, the database is pinged up to ConnectAttempts times, doubling the backoff after
+// each failure
+type DatabaseConfig struct {
+	Source          string        `mapstructure:"source" env:"DB_SOURCE" validate:"required"`
+	ConnectAttempts int           `mapstructure:"connect_attempts" env:"DB_CONNECT_ATTEMPTS" default:"5" validate:"gte=1"`
+	ConnectBackoff  time.Duration `mapstructure:"connect_backoff" env:"DB_CONNECT_BACKOFF" default:"1s" validate:"gt=0"`
+	MigrateOnStart  bool          `mapstructure:"migrate_on_start" env:"MIGRATE_ON_START"`
+
+	// Connection pool, applied to the primary and the replica. MinConns connections are kept open even when idle
+	MaxConns        int32         `mapstructure:"max_conns" env:"DB_MAX_CONNS" default:"25" validate:"gte=1"`
+	MinConns        int32         `mapstructure:"min_conns" env:"DB_MIN_CONNS" default:"0" validate:"gte=0,ltefield=MaxConns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m" validate:"gt=0"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"5m" validate:"gt=0"`
+
+	// Transactions failing with a serialization failure or a deadlock run up to TxMaxAttempts times, the backoff
+	// doubling after each retry
+	TxMaxAttempts  int           `mapstructure:"tx_max_attempts" env:"DB_TX_MAX_ATTEMPTS" default:"5" validate:"gte=1"`
+	TxRetryBackoff time.Duration `mapstructure:"tx_retry_backoff" env:"DB_TX_RETRY_BACKOFF" default:"10ms" validate:"gt=0"`
+
+	// Optional read replica, used for reads as long as it lags less than ReplicaMaxLag
+	ReplicaSource        string        `mapstructure:"replica_source" env:"DB_REPLICA_SOURCE"`
+	ReplicaMaxLag        time.Duration `mapstructure:"replica_max_lag" env:"DB_REPLICA_MAX_LAG" default:"5s" validate:"gt=0"`
+	ReplicaCheckInterval time.Duration `mapstructure:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" default:"5s" validate:"gt=0"`
+}
+
+// Background workers: task processor, outbox relay, webhook delivery and interest accrual
+type WorkersConfig struct {
+	Concurrency      int           `mapstructure:"concurrency" env:"WORKER_CONCURRENCY" default:"4" validate:"gte=1"`
+	PollInterval     time.Duration `mapstructure:"poll_interval" env:"WORKER_POLL_INTERVAL" default:"1s" validate:"gt=0"`
+	TaskLease        time.Duration `mapstructure:"task_lease" env:"TASK_LEASE" default:"5m" validate:"gt=0"`
+	OutboxBatchSize  int32         `mapstructure:"outbox_batch_size" env:"OUTBOX_BATCH_SIZE" default:"100" validate:"gte=1"`
+	WebhookBatchSize int32         `mapstructure:"webhook_batch_size" env:"WEBHOOK_BATCH_SIZE" default:"50" validate:"gte=1"`
+
+	// Interest accrues once a day is over, checking more often only shortens the delay after midnight (UTC)
+	InterestInterval  time.Duration `mapstructure:"interest_interval" env:"INTEREST_INTERVAL" default:"1h" validate:"gt=0"`
+	InterestBatchSize int32         `mapstructure:"interest_batch_size" env:"INTEREST_BATCH_SIZE" default:"100" validate:"gte=1"`
+}
+
+// Readiness probe: timeout of the dependency checks, and how old the task processor heartbeat may get
+type HealthConfig struct {
+	CheckTimeout    time.Duration `mapstructure:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s" validate:"gt=0"`
+	HeartbeatMaxAge time.Duration `mapstructure:"heartbeat_max_age" env:"HEARTBEAT_MAX_AGE" default:"1m" validate:"gt=0"`
+}
+
+// Outbox relay: where domain events are published. There is no default, the server refuses to start until one is
+// chosen; log only logs the events and is meant for development
+type OutboxConfig struct {
+	Publisher    string `mapstructure:"publisher" env:"OUTBOX_PUBLISHER" validate:"omitempty,oneof=log webhook redis"`
+	WebhookURL   string `mapstructure:"webhook_url" env:"OUTBOX_WEBHOOK_URL" validate:"required_if=Publisher webhook,omitempty,url"`
+	RedisAddress string `mapstructure:"redis_address" env:"REDIS_ADDRESS" validate:"required_if=Publisher redis,omitempty,hostname_port"`
+}
+
+// Notifications: emails are logged instead of sent when no SMTP server is configured
+type NotificationConfig struct {
+	SMTPAddress string `mapstructure:"smtp_address" env:"SMTP_ADDRESS" validate:"omitempty,hostname_port"`
+	SMTPFrom    string `mapstructure:"smtp_from" env:"SMTP_FROM" validate:"required_with=SMTPAddress,omitempty,email"`
+}
+
+// Tracing: where spans are exported and the fraction of new traces sampled
+type TracingConfig struct {
+	Exporter     string  `mapstructure:"exporter" env:"TRACE_EXPORTER" default:"none" validate:"oneof=none stdout otlp"`
+	OTLPEndpoint string  `mapstructure:"otlp_endpoint" env:"OTLP_ENDPOINT" validate:"omitempty,url"`
+	SampleRatio  float64 `mapstructure:"sample_ratio" env:"TRACE_SAMPLE_RATIO" default:"1" validate:"gte=0,lte=1"`
+}
+
+// Payees: transfers of at least LargeTransferAmount (in minor units, whatever the currency) to an account of someone
+// else must go to a payee, and are refused until it was added CoolingOff ago, so a hijacked session can't empty an
+// account into a payee it just added. Each user may try to add CreateLimit payees per CreateWindow, so the
+// confirmation of the owner name can't be used to guess who holds which account
+type PayeeConfig struct {
+	CoolingOff          time.Duration `mapstructure:"cooling_off" env:"PAYEE_COOLING_OFF" default:"24h" validate:"gte=0"`
+	LargeTransferAmount int64         `mapstructure:"large_transfer_amount" env:"PAYEE_LARGE_TRANSFER_AMOUNT" default:"100000" validate:"gte=1"`
+	CreateLimit         int           `mapstructure:"create_limit" env:"PAYEE_CREATE_LIMIT" default:"10" validate:"gte=1"`
+	CreateWindow        time.Duration `mapstructure:"create_window" env:"PAYEE_CREATE_WINDOW" default:"1h" validate:"gt=0"`
 }
 
+// A setting of the Config struct: its key in the config file and its environment variable
+type setting struct {
+	key          string
+	env          string
+	defaultValue string
+	hasDefault   bool
+}
+
+// Helper method: list the settings of a config struct, recursing into the sections
+func settings(t reflect.Type, prefix string) []setting {
+	var result []setting
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("mapstructure")
+
+		if field.Type.Kind() == reflect.Struct {
+			result = append(result, settings(field.Type, key+".")...)
+			continue
+		}
+
+		defaultValue, hasDefault := field.Tag.Lookup("default")
+		result = append(result, setting{
+			key:          key,
+			env:          field.Tag.Get("env"),
+			defaultValue: defaultValue,
+			hasDefault:   hasDefault,
+		})
+	}
+	return result
+}
+
+// Load the configuration from the environment, and from the optional app.env, app.yaml or app.toml file in path.
+// Environment variables take precedence over the file, which takes precedence over the defaults. The result is
+// validated, and the error lists every invalid or missing setting
 func LoadConfig(path string) (config Config, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigName("app")
-	viper.SetConfigType("env")
+	all := settings(reflect.TypeOf(config), "")
 
-	viper.AutomaticEnv()
+	v := viper.New()
+	for _, s := range all {
+		if s.hasDefault {
+			v.SetDefault(s.key, s.defaultValue)
+		}
+		if err = v.BindEnv(s.key, s.env); err != nil {
+			return
+		}
+	}
 
-	err = viper.ReadInConfig()
+	// The file is optional, so the application can be configured through the environment only
+	values, err := readConfigFile(path, all)
 	if err != nil {
 		return
 	}
+	if err = v.MergeConfigMap(values); err != nil {
+		return
+	}
+
+	if err = v.Unmarshal(&config); err != nil {
+		return
+	}
 
-	err = viper.Unmarshal(&config)
+	err = validateConfig(config)
 	return
 }
+
+// Helper method: read the config file in path, if any. An env file uses the environment variable names, which
+// are mapped to the nested keys; YAML and TOML files use the nested keys directly
+func readConfigFile(path string, all []setting) (map[string]any, error) {
+	file := viper.New()
+	file.AddConfigPath(path)
+	file.SetConfigName("app")
+
+	if err := file.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			return map[string]any{}, nil
+		}
+		return nil, err
+	}
+
+	if !strings.HasSuffix(file.ConfigFileUsed(), ".env") {
+		return file.AllSettings(), nil
+	}
+
+	values := make(map[string]any)
+	for _, s := range all {
+		if !file.IsSet(s.env) {
+			continue
+		}
+
+		// Build the nested map, e.g. server.port -> {"server": {"port": ...}}
+		parts := strings.Split(s.key, ".")
+		section := values
+		for _, part := range parts[:len(parts)-1] {
+			if _, ok := section[part]; !ok {
+				section[part] = make(map[string]any)
+			}
+			section = section[part].(map[string]any)
+		}
+		section[parts[len(parts)-1]] = file.Get(s.env)
+	}
+	return values, nil
+}
+
+// Validate the configuration. The error lists every invalid setting, with its key and environment variable
+func validateConfig(config Config) error {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("mapstructure")
+	})
+
+	err := validate.Struct(config)
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	envs := make(map[string]string)
+	for _, s := range settings(reflect.TypeOf(config), "") {
+		envs[s.key] = s.env
+	}
+
+	var sb strings.Builder
+	sb.WriteString("invalid configuration:")
+	for _, fieldError := range fieldErrors {
+		// The namespace is prefixed with the struct name, e.g. Config.server.port
+		_, key, _ := strings.Cut(fieldError.Namespace(), ".")
+		fmt.Fprintf(&sb, "\n  %s (%s): %s", key, envs[key], describe(fieldError))
+	}
+	return errors.New(sb.String())
+}
+
+// Helper method: describe a failed validation rule in plain words
+func describe(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		param := strings.Fields(fieldError.Param())
+		return fmt.Sprintf("is required when %s is %s", snakeCase(param[0]), param[1])
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", snakeCase(fieldError.Param()))
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fieldError.Param(), fieldError.Value())
+	case "gt", "gte", "lt", "lte":
+		return fmt.Sprintf("must be %s %s, got %v", comparisons[fieldError.Tag()], fieldError.Param(), fieldError.Value())
+	case "ltefield":
+		return fmt.Sprintf("must be <= %s, got %v", snakeCase(fieldError.Param()), fieldError.Value())
+	default:
+		return fmt.Sprintf("must be a valid %s, got %q", fieldError.Tag(), fieldError.Value())
+	}
+}
+
+var comparisons = map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+
+// Helper method: turn the name of a struct field into its key, e.g. SMTPAddress -> smtp_address
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, c := range name {
+		upper := c >= 'A' && c <= 'Z'
+		if upper && i > 0 {
+			prevLower := name[i-1] >= 'a' && name[i-1] <= 'z'
+			nextLower := i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z'
+			if prevLower || nextLower {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(c)
+	}
+	return strings.ToLower(sb.String())
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Helper method: write a config file into a temporary directory and return the directory
+func writeConfigFile(t *testing.T, name, content string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	return dir
+}
+
+func TestLoadConfigEnvOnly(t *testing.T) {
+	t.Setenv("PORT", "8080")
+	t.Setenv("DB_SOURCE", "postgresql://root@localhost:5432/gobank")
+	t.Setenv("WORKER_CONCURRENCY", "8")
+
+	config, err := LoadConfig(t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, "8080", config.Server.Port)
+	require.Equal(t, "postgresql://root@localhost:5432/gobank", config.Database.Source)
+	require.Equal(t, 8, config.Workers.Concurrency)
+
+	// Unset settings get their default
+	require.Equal(t, 10*time.Second, config.Server.ReadTimeout)
+	require.Equal(t, 10*time.Minute, config.Server.StatementWriteTimeout)
+	require.Equal(t, 4, config.Server.StatementConcurrency)
+	require.Equal(t, int32(25), config.Database.MaxConns)
+	require.Empty(t, config.Outbox.Publisher)
+	require.Equal(t, 1.0, config.Tracing.SampleRatio)
+	require.Equal(t, 24*time.Hour, config.Payee.CoolingOff)
+	require.Equal(t, 10, config.Payee.CreateLimit)
+}
+
+func TestLoadConfigEnvFile(t *testing.T) {
+	dir := writeConfigFile(t, "app.env", "PORT=8080\nDB_SOURCE=postgresql://file\nREAD_TIMEOUT=3s\n")
+
+	// The environment takes precedence over the file
+	t.Setenv("DB_SOURCE", "postgresql://env")
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, "8080", config.Server.Port)
+	require.Equal(t, "postgresql://env", config.Database.Source)
+	require.Equal(t, 3*time.Second, config.Server.ReadTimeout)
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	dir := writeConfigFile(t, "app.yaml", `
+server:
+  port: "9090"
+  shutdown_timeout: 5s
+database:
+  source: postgresql://yaml
+outbox:
+  publisher: redis
+  redis_address: localhost:6379
+`)
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, "9090", config.Server.Port)
+	require.Equal(t, 5*time.Second, config.Server.ShutdownTimeout)
+	require.Equal(t, "postgresql://yaml", config.Database.Source)
+	require.Equal(t, "localhost:6379", config.Outbox.RedisAddress)
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	dir := writeConfigFile(t, "app.toml", `
+[server]
+port = "9090"
+
+[database]
+source = "postgresql://toml"
+connect_attempts = 2
+`)
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, "9090", config.Server.Port)
+	require.Equal(t, 2, config.Database.ConnectAttempts)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	t.Setenv("OUTBOX_PUBLISHER", "webhook")
+	t.Setenv("TRACE_SAMPLE_RATIO", "2")
+	t.Setenv("DB_MIN_CONNS", "50")
+
+	// Every invalid or missing setting is reported at once
+	_, err := LoadConfig(t.TempDir())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "server.port (PORT): is required")
+	require.Contains(t, err.Error(), "database.source (DB_SOURCE): is required")
+	require.Contains(t, err.Error(), "outbox.webhook_url (OUTBOX_WEBHOOK_URL): is required when publisher is webhook")
+	require.Contains(t, err.Error(), "tracing.sample_ratio (TRACE_SAMPLE_RATIO): must be <= 1, got 2")
+	require.Contains(t, err.Error(), "database.min_conns (DB_MIN_CONNS): must be <= max_conns, got 50")
+}
+
+func TestSnakeCase(t *testing.T) {
+	require.Equal(t, "smtp_address", snakeCase("SMTPAddress"))
+	require.Equal(t, "publisher", snakeCase("Publisher"))
+	require.Equal(t, "otlp_endpoint", snakeCase("OTLPEndpoint"))
+}
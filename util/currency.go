@@ -0,0 +1,45 @@
+package util
+
+import "fmt"
+
+// Supported currencies
+const (
+	USD = "USD"
+	EUR = "EUR"
+	VND = "VND"
+)
+
+// Number of digits after the decimal separator for each supported currency (ISO 4217 minor units).
+// Amounts are always stored in the smallest unit, e.g. cents for USD
+var currencyMinorUnits = map[string]int{
+	USD: 2,
+	EUR: 2,
+	VND: 0,
+}
+
+// Utility method: get the ISO 4217 minor units of a currency. Unknown currencies are treated as having 2 decimals
+func MinorUnits(currency string) int {
+	if units, ok := currencyMinorUnits[currency]; ok {
+		return units
+	}
+	return 2
+}
+
+// Utility method: format an amount stored in minor units as a decimal string, e.g. 123456 USD -> "1234.56"
+func FormatAmount(amount int64, currency string) string {
+	units := MinorUnits(currency)
+	if units == 0 {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	sign := ""
+	abs := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		abs = uint64(-amount)
+	}
+
+	digits := fmt.Sprintf("%0*d", units+1, abs)
+	split := len(digits) - units
+	return sign + digits[:split] + "." + digits[split:]
+}
@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		currency string
+		expected string
+	}{
+		{123456, USD, "1234.56"},
+		{-123456, EUR, "-1234.56"},
+		{5, USD, "0.05"},
+		{-5, USD, "-0.05"},
+		{0, EUR, "0.00"},
+		{123456, VND, "123456"},
+		{-7, VND, "-7"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, FormatAmount(tc.amount, tc.currency))
+	}
+}
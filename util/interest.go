@@ -0,0 +1,71 @@
+package util
+
+import (
+	"math/big"
+	"time"
+)
+
+// Accrued interest is kept in millionths of the minor unit, so the fractions of a cent earned every day aren't lost.
+// Only whole minor units are posted to the account, the remainder is carried over to the next month
+const InterestScale = 1_000_000
+
+// Utility method: get the number of days in a year, 366 for leap years
+func DaysInYear(year int) int {
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+// Utility method: get the interest earned in one day on an end-of-day balance, in millionths of the minor unit. The
+// annual rate is in basis points (250 = 2.50%) and is spread over the actual number of days of the year the day is
+// in, so the daily rate is a little lower in leap years. The result is rounded to the nearest unit, and negative
+// balances earn nothing
+func DailyInterest(balance int64, rateBps int32, day time.Time) int64 {
+	if balance <= 0 || rateBps <= 0 {
+		return 0
+	}
+	return dailyInterest(balance, rateBps, day)
+}
+
+// Utility method: get the overdraft interest charged in one day on an end-of-day balance, as a negative amount in
+// millionths of the minor unit. It is computed like DailyInterest on the overdrawn amount, positive balances are
+// charged nothing
+func DailyOverdraftInterest(balance int64, rateBps int32, day time.Time) int64 {
+	if balance >= 0 || rateBps <= 0 {
+		return 0
+	}
+	return -dailyInterest(-balance, rateBps, day)
+}
+
+// Utility method: get the overdraft fee charged for one day with an end-of-day balance, as a negative amount in
+// millionths of the minor unit like DailyOverdraftInterest. The fee is in minor units, charged only when overdrawn
+func DailyOverdraftFee(balance, fee int64) int64 {
+	if balance >= 0 || fee <= 0 {
+		return 0
+	}
+	return -fee * InterestScale
+}
+
+// Helper method: the interest of one day on a positive amount, rounded to the nearest millionth of the minor unit
+func dailyInterest(balance int64, rateBps int32, day time.Time) int64 {
+	// balance * rate / 10_000 / days, scaled by InterestScale. The product may not fit in 64 bits
+	numerator := new(big.Int).Mul(big.NewInt(balance), big.NewInt(int64(rateBps)))
+	numerator.Mul(numerator, big.NewInt(InterestScale))
+	denominator := big.NewInt(10_000 * int64(DaysInYear(day.Year())))
+
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(denominator) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient.Int64()
+}
+
+// Utility method: split the accrued interest into the whole minor units to post, and the remainder carried over.
+// Overdraft interest is negative and split the same way, so only whole minor units are charged
+func SplitInterest(accrued int64) (posted, remainder int64) {
+	// Go division truncates toward zero, so the remainder has the sign of the accrued interest
+	return accrued / InterestScale, accrued % InterestScale
+}
+
+// Utility method: get the last day of the month of the given day, at midnight UTC
+func EndOfMonth(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Helper method: a day at midnight UTC
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDaysInYear(t *testing.T) {
+	require.Equal(t, 365, DaysInYear(2023))
+	require.Equal(t, 366, DaysInYear(2024))
+	require.Equal(t, 365, DaysInYear(2100)) // Divisible by 100 but not by 400
+	require.Equal(t, 366, DaysInYear(2000))
+}
+
+func TestDailyInterest(t *testing.T) {
+	// 1000.00 at 3.65% is exactly 0.10 a day in a 365 day year
+	require.Equal(t, int64(10*InterestScale), DailyInterest(100_000, 365, date(2023, time.June, 1)))
+
+	// In a leap year, the same rate is spread over 366 days: 3650000000 / 366 = 9972677.59, rounded up
+	require.Equal(t, int64(9_972_678), DailyInterest(100_000, 365, date(2024, time.June, 1)))
+	require.Equal(t, int64(9_972_678), DailyInterest(100_000, 365, date(2024, time.February, 29)))
+
+	// Rounded to the nearest millionth of a cent: 0.01 at 1% earns 27.397 in 2023, 0.02 earns 54.645 in 2024
+	require.Equal(t, int64(27), DailyInterest(1, 100, date(2023, time.January, 1)))
+	require.Equal(t, int64(55), DailyInterest(2, 100, date(2024, time.January, 1)))
+
+	// Nothing is earned on an empty or overdrawn balance, or without a rate
+	require.Zero(t, DailyInterest(0, 365, date(2023, time.June, 1)))
+	require.Zero(t, DailyInterest(-100_000, 365, date(2023, time.June, 1)))
+	require.Zero(t, DailyInterest(100_000, 0, date(2023, time.June, 1)))
+
+	// Large balances don't overflow: 10 billion at 100% is 1e18 / 365 millionths of a cent a day
+	require.Equal(t, int64(2_739_726_027_397_260), DailyInterest(1_000_000_000_000, 10_000, date(2023, time.June, 1)))
+}
+
+func TestDailyOverdraftInterest(t *testing.T) {
+	// 1000.00 overdrawn at 18.25% is charged exactly 0.50 a day in a 365 day year
+	require.Equal(t, int64(-50*InterestScale), DailyOverdraftInterest(-100_000, 1825, date(2023, time.June, 1)))
+
+	// Rounded like DailyInterest, away from zero: 0.01 overdrawn at 1% is charged 27.397 in 2023
+	require.Equal(t, int64(-27), DailyOverdraftInterest(-1, 100, date(2023, time.January, 1)))
+	require.Equal(t, -DailyInterest(2, 100, date(2024, time.January, 1)), DailyOverdraftInterest(-2, 100, date(2024, time.January, 1)))
+
+	// Nothing is charged on an empty or positive balance, or without a rate
+	require.Zero(t, DailyOverdraftInterest(0, 1825, date(2023, time.June, 1)))
+	require.Zero(t, DailyOverdraftInterest(100_000, 1825, date(2023, time.June, 1)))
+	require.Zero(t, DailyOverdraftInterest(-100_000, 0, date(2023, time.June, 1)))
+}
+
+func TestDailyOverdraftFee(t *testing.T) {
+	// 2.50 a day, whatever the overdrawn amount
+	require.Equal(t, int64(-250*InterestScale), DailyOverdraftFee(-1, 250))
+	require.Equal(t, int64(-250*InterestScale), DailyOverdraftFee(-100_000, 250))
+
+	// Nothing is charged on an empty or positive balance, or without a fee
+	require.Zero(t, DailyOverdraftFee(0, 250))
+	require.Zero(t, DailyOverdraftFee(100_000, 250))
+	require.Zero(t, DailyOverdraftFee(-100_000, 0))
+}
+
+func TestSplitInterest(t *testing.T) {
+	posted, remainder := SplitInterest(12*InterestScale + 345)
+	require.Equal(t, int64(12), posted)
+	require.Equal(t, int64(345), remainder)
+
+	posted, remainder = SplitInterest(999_999)
+	require.Zero(t, posted)
+	require.Equal(t, int64(999_999), remainder)
+
+	// Overdraft interest only charges whole minor units, the fraction stays owed
+	posted, remainder = SplitInterest(-(12*InterestScale + 345))
+	require.Equal(t, int64(-12), posted)
+	require.Equal(t, int64(-345), remainder)
+
+	posted, remainder = SplitInterest(-999_999)
+	require.Zero(t, posted)
+	require.Equal(t, int64(-999_999), remainder)
+}
+
+func TestEndOfMonth(t *testing.T) {
+	require.Equal(t, date(2024, time.February, 29), EndOfMonth(date(2024, time.February, 10)))
+	require.Equal(t, date(2023, time.February, 28), EndOfMonth(date(2023, time.February, 28)))
+	require.Equal(t, date(2023, time.December, 31), EndOfMonth(date(2023, time.December, 1)))
+}
+
+// Accrue every day of a period on a constant balance, posting at each month end like the interest worker does
+func accrueAndPost(balance int64, rateBps int32, from, to time.Time) (postings []int64, carried int64) {
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		carried += DailyInterest(balance, rateBps, day)
+		if day.Equal(EndOfMonth(day)) {
+			var posted int64
+			posted, carried = SplitInterest(carried)
+			postings = append(postings, posted)
+		}
+	}
+	return postings, carried
+}
+
+func TestInterestAcrossMonthBoundaries(t *testing.T) {
+	// 1000.00 at 1% a year earns 0.0273972... a day in 2023. No month reaches a whole cent of fractions on its own,
+	// but the remainders carried over add up: the year pays 999 cents plus what is still carried
+	postings, carried := accrueAndPost(100_000, 100, date(2023, time.January, 1), date(2023, time.December, 31))
+	require.Len(t, postings, 12)
+
+	// January has 31 days: 31 * 2739726 = 84931506, posted as 84 cents, 931506 carried into February
+	require.Equal(t, int64(84), postings[0])
+
+	// February has 28 days: 931506 + 28 * 2739726 = 77643834, posted as 77 cents
+	require.Equal(t, int64(77), postings[1])
+
+	var total int64
+	for _, posted := range postings {
+		total += posted
+	}
+
+	// Nothing is lost to rounding: what is posted and carried is exactly the sum of the daily accruals
+	require.Equal(t, 365*DailyInterest(100_000, 100, date(2023, time.January, 1)), total*InterestScale+carried)
+	require.Equal(t, int64(999), total)
+}
+
+func TestInterestLeapYear(t *testing.T) {
+	// February 2024 has 29 days, each accruing at the 366 day rate
+	postings, carried := accrueAndPost(100_000, 365, date(2024, time.February, 1), date(2024, time.February, 29))
+	require.Equal(t, []int64{289}, postings) // 29 * 9972678 = 289207662
+	require.Equal(t, int64(207_662), carried)
+
+	// A year starting in a leap year and ending in a common one switches rate on January 1
+	dec31 := DailyInterest(100_000, 365, date(2024, time.December, 31))
+	jan1 := DailyInterest(100_000, 365, date(2025, time.January, 1))
+	require.Equal(t, int64(9_972_678), dec31)
+	require.Equal(t, int64(10*InterestScale), jan1)
+
+	// Over a whole leap year, the rate pays the annual 36.50, the rounding of the daily accruals stays carried
+	postings, carried = accrueAndPost(100_000, 365, date(2024, time.January, 1), date(2024, time.December, 31))
+	var total int64
+	for _, posted := range postings {
+		total += posted
+	}
+	require.Equal(t, int64(3650), total)
+	require.Equal(t, 366*int64(9_972_678)-3650*InterestScale, carried)
+}
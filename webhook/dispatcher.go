@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	db "gobank/db/sqlc"
+)
+
+// Dispatcher turns outbox events into webhook deliveries for every matching subscription. It implements
+// event.Publisher, so it is plugged into the outbox relay; the HTTP calls themselves are made by the Worker
+type Dispatcher struct {
+	store db.Store
+}
+
+// Constructor method for Dispatcher
+func NewDispatcher(store db.Store) *Dispatcher {
+	return &Dispatcher{store: store}
+}
+
+func (dispatcher *Dispatcher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	notifications, err := notificationsFor(event)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range notifications {
+		subscriptions, err := dispatcher.store.ListWebhookSubscriptionsForEvent(ctx, db.ListWebhookSubscriptionsForEventParams{
+			Owner:     n.owner,
+			EventType: n.eventType,
+		})
+		if err != nil {
+			return err
+		}
+		if len(subscriptions) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(Payload{
+			ID:        event.EventID,
+			Type:      n.eventType,
+			CreatedAt: event.CreatedAt,
+			Data:      event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Deliveries are unique per subscription and event, so publishing the same event twice is harmless
+		for _, subscription := range subscriptions {
+			err := dispatcher.store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+				SubscriptionID: subscription.SubscriptionID,
+				EventID:        event.EventID,
+				EventType:      n.eventType,
+				Payload:        payload,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"encoding/json"
+	db "gobank/db/sqlc"
+	"time"
+)
+
+// Event types customers can subscribe to. Transfers are split by direction, so a merchant can subscribe
+// to incoming transfers only
+const (
+	EventAccountCreated   = db.EventAccountCreated
+	EventTransferReceived = "TransferReceived"
+	EventTransferSent     = "TransferSent"
+	EventDepositMade      = db.EventDepositMade
+	EventWithdrawalMade   = db.EventWithdrawalMade
+	EventInterestPosted   = db.EventInterestPosted
+)
+
+// Body of every webhook request
+type Payload struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// A webhook event addressed to the owner of an account
+type notification struct {
+	owner     string
+	eventType string
+}
+
+// Helper method: find out which owners should be notified of an outbox event, and as which webhook event type
+func notificationsFor(event db.OutboxEvent) ([]notification, error) {
+	switch event.EventType {
+	case db.EventAccountCreated:
+		var account db.Account
+		if err := json.Unmarshal(event.Payload, &account); err != nil {
+			return nil, err
+		}
+		return []notification{{account.Owner, EventAccountCreated}}, nil
+
+	case db.EventTransferCompleted:
+		var result db.TransferTxResult
+		if err := json.Unmarshal(event.Payload, &result); err != nil {
+			return nil, err
+		}
+		return []notification{
+			{result.ToAccount.Owner, EventTransferReceived},
+			{result.FromAccount.Owner, EventTransferSent},
+		}, nil
+
+	case db.EventDepositMade, db.EventWithdrawalMade, db.EventInterestPosted:
+		// Deposit, withdrawal and interest posting results all hold the account
+		var result db.DepositTxResult
+		if err := json.Unmarshal(event.Payload, &result); err != nil {
+			return nil, err
+		}
+		return []notification{{result.Account.Owner, event.EventType}}, nil
+
+	default:
+		return nil, nil
+	}
+}
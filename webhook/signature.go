@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every webhook request
+const (
+	HeaderSignature = "Gobank-Signature"
+	HeaderEventType = "Gobank-Event"
+	HeaderDelivery  = "Gobank-Delivery"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Generate a new random secret for a subscription
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Compute the signature header of a webhook request: "t=<unix timestamp>,v1=<hex HMAC-SHA256>".
+// The HMAC covers "<timestamp>.<body>", so a captured request can't be replayed with another timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, computeMAC(secret, ts, body))
+}
+
+// Verify the signature header of a webhook request, rejecting timestamps older than tolerance.
+// This is what receivers are expected to do, and is used by the tests
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			mac = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || mac == "" {
+		return ErrInvalidSignature
+	}
+
+	if time.Since(time.Unix(unix, 0)) > tolerance {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(mac), []byte(computeMAC(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Helper method: compute the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+func computeMAC(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
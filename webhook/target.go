@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateTarget is returned for webhook URLs pointing to a loopback, link-local or private address. Customers
+// choose the URL, so without this check the worker could be used to reach internal services
+var ErrPrivateTarget = errors.New("webhook target is not a public address")
+
+// Shared address space used by carrier-grade NAT (RFC 6598), not covered by netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Utility method: check if an address can be used as a webhook target
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// Method to validate the URL of a webhook subscription: http or https, and a host that only resolves to public
+// addresses. The addresses are checked again when connecting (see NewClient), since DNS can change in between
+func ValidateURL(ctx context.Context, raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme: %s", target.Scheme)
+	}
+
+	host := strings.ToLower(target.Hostname())
+	if host == "" {
+		return errors.New("missing host")
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateTarget
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !isPublicAddr(addr) {
+			return ErrPrivateTarget
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve host %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return ErrPrivateTarget
+		}
+	}
+
+	return nil
+}
+
+// Constructor method for the HTTP client of the Worker. It refuses to connect to non-public addresses, whatever
+// the URL resolves to at the time of the delivery, and ignores the proxy settings of the environment
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(addr) {
+				return ErrPrivateTarget
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
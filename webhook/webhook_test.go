@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWorker() *Worker {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewWorker(nil, &http.Client{Timeout: time.Second}, logger, time.Second, 10)
+}
+
+func TestSignature(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+
+	body := []byte(`{"id":1}`)
+	header := Sign(secret, time.Now(), body)
+
+	require.NoError(t, Verify(secret, header, body, time.Minute))
+	require.ErrorIs(t, Verify("whsec_other", header, body, time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, header, []byte(`{"id":2}`), time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, "v1=abc", body, time.Minute), ErrInvalidSignature)
+
+	// Old signatures are rejected
+	old := Sign(secret, time.Now().Add(-time.Hour), body)
+	require.ErrorIs(t, Verify(secret, old, body, time.Minute), ErrInvalidSignature)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, retryBaseDelay, Backoff(1))
+	require.Equal(t, 2*retryBaseDelay, Backoff(2))
+	require.Equal(t, 4*retryBaseDelay, Backoff(3))
+	require.Equal(t, retryMaxDelay, Backoff(30))
+}
+
+func TestAttemptSuccess(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"id":7,"type":"TransferReceived"}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, body, received)
+		require.Equal(t, EventTransferReceived, r.Header.Get(HeaderEventType))
+		require.Equal(t, "3", r.Header.Get(HeaderDelivery))
+		require.NoError(t, Verify(secret, r.Header.Get(HeaderSignature), received, time.Minute))
+	}))
+	defer server.Close()
+
+	arg := newTestWorker().attempt(context.Background(), db.ClaimDueWebhookDeliveriesRow{
+		DeliveryID: 3,
+		EventType:  EventTransferReceived,
+		Payload:    body,
+		Url:        server.URL,
+		Secret:     secret,
+	})
+
+	require.Equal(t, db.WebhookDeliverySucceeded, arg.Status)
+	require.Equal(t, int32(http.StatusOK), arg.LastStatusCode.Int32)
+	require.True(t, arg.DeliveredAt.Valid)
+	require.False(t, arg.LastError.Valid)
+}
+
+func TestAttemptRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	worker := newTestWorker()
+	delivery := db.ClaimDueWebhookDeliveriesRow{DeliveryID: 1, Url: server.URL, Secret: "whsec_test"}
+
+	// A failed attempt is rescheduled with backoff
+	arg := worker.attempt(context.Background(), delivery)
+	require.Equal(t, db.WebhookDeliveryPending, arg.Status)
+	require.Equal(t, int32(http.StatusInternalServerError), arg.LastStatusCode.Int32)
+	require.True(t, arg.LastError.Valid)
+	require.WithinDuration(t, time.Now().Add(Backoff(1)), arg.NextAttemptAt, time.Second)
+
+	// The last allowed attempt marks the delivery as failed
+	delivery.Attempts = maxAttempts - 1
+	arg = worker.attempt(context.Background(), delivery)
+	require.Equal(t, db.WebhookDeliveryFailed, arg.Status)
+	require.False(t, arg.DeliveredAt.Valid)
+}
+
+func TestAttemptShutdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	// A delivery interrupted on its last attempt isn't marked as failed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	delivery := db.ClaimDueWebhookDeliveriesRow{DeliveryID: 1, Url: server.URL, Attempts: maxAttempts - 1}
+	arg := newTestWorker().attempt(ctx, delivery)
+	require.Empty(t, arg.Status)
+}
+
+func TestNotificationsForTransfer(t *testing.T) {
+	payload, err := json.Marshal(db.TransferTxResult{
+		FromAccount: db.Account{AccountID: 1, Owner: "alice"},
+		ToAccount:   db.Account{AccountID: 2, Owner: "merchant"},
+	})
+	require.NoError(t, err)
+
+	notifications, err := notificationsFor(db.OutboxEvent{EventType: db.EventTransferCompleted, Payload: payload})
+	require.NoError(t, err)
+	require.Equal(t, []notification{
+		{"merchant", EventTransferReceived},
+		{"alice", EventTransferSent},
+	}, notifications)
+}
+
+func TestNotificationsForInterestPosted(t *testing.T) {
+	payload, err := json.Marshal(db.PostInterestTxResult{
+		Account: db.Account{AccountID: 1, Owner: "alice"},
+		Posted:  42,
+	})
+	require.NoError(t, err)
+
+	notifications, err := notificationsFor(db.OutboxEvent{EventType: db.EventInterestPosted, Payload: payload})
+	require.NoError(t, err)
+	require.Equal(t, []notification{{"alice", EventInterestPosted}}, notifications)
+}
+
+func TestValidateURL(t *testing.T) {
+	require.NoError(t, ValidateURL(context.Background(), "https://93.184.216.34/hooks"))
+	require.NoError(t, ValidateURL(context.Background(), "http://[2606:2800:220:1::1]:8080/hooks"))
+
+	for _, raw := range []string{
+		"http://localhost/hooks",
+		"http://api.localhost/hooks",
+		"http://127.0.0.1:8080/hooks",
+		"http://[::1]/hooks",
+		"http://10.0.0.5/hooks",
+		"http://192.168.1.1/hooks",
+		"http://172.16.0.1/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.0.1/hooks",
+		"http://0.0.0.0/hooks",
+		"http://[::ffff:127.0.0.1]/hooks",
+		"http://[fe80::1]/hooks",
+	} {
+		require.ErrorIs(t, ValidateURL(context.Background(), raw), ErrPrivateTarget, raw)
+	}
+
+	require.Error(t, ValidateURL(context.Background(), "ftp://93.184.216.34/hooks"))
+	require.Error(t, ValidateURL(context.Background(), "http:///hooks"))
+}
+
+func TestClientRefusesPrivateTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Get(server.URL)
+	require.ErrorIs(t, err, ErrPrivateTarget)
+}
+
+func TestLease(t *testing.T) {
+	// The lease covers a whole batch timing out
+	worker := newTestWorker()
+	require.Equal(t, 10*time.Second+leaseMargin, worker.lease())
+
+	worker.client = &http.Client{}
+	require.Equal(t, defaultLease, worker.lease())
+}
@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// How long a batch is leased when the HTTP client has no timeout. Otherwise the lease covers the whole batch timing
+// out, plus leaseMargin
+const (
+	defaultLease = 15 * time.Minute
+	leaseMargin  = time.Minute
+)
+
+// Retry policy of failed deliveries: exponential backoff starting at retryBaseDelay, capped at retryMaxDelay,
+// until maxAttempts is reached and the delivery is marked as failed (it can still be replayed manually)
+const (
+	maxAttempts    = 8
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 6 * time.Hour
+)
+
+// Worker sends pending webhook deliveries
+type Worker struct {
+	store     db.Store
+	client    *http.Client
+	logger    *slog.Logger
+	interval  time.Duration
+	batchSize int32
+}
+
+// Constructor method for Worker
+func NewWorker(store db.Store, client *http.Client, logger *slog.Logger, interval time.Duration, batchSize int32) *Worker {
+	return &Worker{
+		store:     store,
+		client:    client,
+		logger:    logger,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Get the delay before the next attempt, given the number of attempts already made (including the failed one)
+func Backoff(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+// Method to send a single signed webhook request. It returns the response status code, if any
+func (worker *Worker) Send(ctx context.Context, url, secret string, deliveryID int64, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventType, eventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(deliveryID, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), body))
+
+	resp, err := worker.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Method to attempt one delivery and compute the state to store. Nothing is stored when ctx is cancelled meanwhile
+func (worker *Worker) attempt(ctx context.Context, delivery db.ClaimDueWebhookDeliveriesRow) db.UpdateWebhookDeliveryAttemptParams {
+	now := time.Now()
+	status, err := worker.Send(ctx, delivery.Url, delivery.Secret, delivery.DeliveryID, delivery.EventType, delivery.Payload)
+
+	arg := db.UpdateWebhookDeliveryAttemptParams{
+		LastStatusCode: sql.NullInt32{Int32: int32(status), Valid: status != 0},
+		NextAttemptAt:  now,
+	}
+
+	if err == nil {
+		arg.Status = db.WebhookDeliverySucceeded
+		arg.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+		return arg
+	}
+
+	// Interrupted by a shutdown, not a failure of the endpoint: the outcome isn't stored (see ProcessWebhookDeliveries)
+	if ctx.Err() != nil {
+		return arg
+	}
+
+	attempts := delivery.Attempts + 1
+	arg.LastError = sql.NullString{String: err.Error(), Valid: true}
+
+	if attempts >= maxAttempts {
+		arg.Status = db.WebhookDeliveryFailed
+		worker.logger.Warn("Webhook delivery failed permanently",
+			"delivery_id", delivery.DeliveryID, "attempts", attempts, "error", err)
+		return arg
+	}
+
+	arg.Status = db.WebhookDeliveryPending
+	arg.NextAttemptAt = now.Add(Backoff(attempts))
+	return arg
+}
+
+// Get how long a batch is leased: long enough to send every delivery of the batch one after the other, so another
+// worker doesn't send them again meanwhile
+func (worker *Worker) lease() time.Duration {
+	if worker.client.Timeout <= 0 {
+		return defaultLease
+	}
+	return time.Duration(worker.batchSize)*worker.client.Timeout + leaseMargin
+}
+
+// Method to send one batch of due deliveries. It returns the number of deliveries attempted
+func (worker *Worker) RunOnce(ctx context.Context) (int, error) {
+	return worker.store.ProcessWebhookDeliveries(ctx, worker.batchSize, worker.lease(),
+		func(delivery db.ClaimDueWebhookDeliveriesRow) db.UpdateWebhookDeliveryAttemptParams {
+			return worker.attempt(ctx, delivery)
+		})
+}
+
+// Method to run the worker until the context is cancelled
+func (worker *Worker) Start(ctx context.Context) {
+	worker.logger.Info("Webhook worker started")
+
+	for {
+		attempted, err := worker.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			worker.logger.Error("Failed to process webhook deliveries", "error", err)
+		}
+
+		if err == nil && attempted == int(worker.batchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			worker.logger.Info("Webhook worker stopped")
+			return
+		case <-time.After(worker.interval):
+		}
+	}
+}
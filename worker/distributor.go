@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	db "gobank/db/sqlc"
+	"time"
+)
+
+// Default number of attempts before a task is moved to the dead letter queue
+const defaultMaxAttempts = 10
+
+// Task options
+type options struct {
+	maxAttempts int32
+	runAt       time.Time
+}
+
+type Option func(*options)
+
+// Set the number of attempts before the task is moved to the dead letter queue
+func MaxAttempts(n int32) Option {
+	return func(o *options) {
+		o.maxAttempts = n
+	}
+}
+
+// Delay the first run of the task
+func ProcessIn(d time.Duration) Option {
+	return func(o *options) {
+		o.runAt = time.Now().Add(d)
+	}
+}
+
+// TaskDistributor enqueues tasks. Every method takes the Querier to write the task with: pass the Queries of an
+// open transaction (e.g. from a TransferTx hook) and the task is only enqueued if that transaction commits
+type TaskDistributor interface {
+	DistributeTaskNotifyTransfer(ctx context.Context, q db.Querier, payload *PayloadNotifyTransfer, opts ...Option) error
+	DistributeTaskNotifyWithdraw(ctx context.Context, q db.Querier, payload *PayloadNotifyWithdraw, opts ...Option) error
+}
+
+// PostgresTaskDistributor stores tasks in the task table
+type PostgresTaskDistributor struct{}
+
+// Constructor method for PostgresTaskDistributor
+func NewPostgresTaskDistributor() TaskDistributor {
+	return &PostgresTaskDistributor{}
+}
+
+// Helper method: encode the payload and insert the task
+func (distributor *PostgresTaskDistributor) enqueue(ctx context.Context, q db.Querier, taskType string, payload any, opts ...Option) (db.Task, error) {
+	o := options{maxAttempts: defaultMaxAttempts, runAt: time.Now()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.Task{}, err
+	}
+
+	return q.CreateTask(ctx, db.CreateTaskParams{
+		Type:        taskType,
+		Payload:     data,
+		MaxAttempts: o.maxAttempts,
+		RunAt:       o.runAt,
+	})
+}
@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "gobank/db/sqlc"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Retry policy: exponential backoff starting at retryBaseDelay and capped at retryMaxDelay
+const (
+	retryBaseDelay = 5 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// A task handler. Returning an error schedules a retry, until the task runs out of attempts
+type HandlerFunc func(ctx context.Context, task db.Task) error
+
+// TaskProcessor claims due tasks and runs the handler registered for their type
+type TaskProcessor struct {
+	store       db.Store
+	logger      *slog.Logger
+	handlers    map[string]HandlerFunc
+	concurrency int
+	interval    time.Duration
+	lease       time.Duration
+
+	// Time of the last successful poll of the queue, in Unix nanoseconds
+	heartbeat atomic.Int64
+}
+
+// Constructor method for TaskProcessor. Each of the concurrency loops claims one task at a time and holds it for at
+// most lease; a task whose lease expires (e.g. the process crashed) is picked up again by another worker
+func NewTaskProcessor(store db.Store, logger *slog.Logger, concurrency int, interval, lease time.Duration) *TaskProcessor {
+	return &TaskProcessor{
+		store:       store,
+		logger:      logger,
+		handlers:    make(map[string]HandlerFunc),
+		concurrency: concurrency,
+		interval:    interval,
+		lease:       lease,
+	}
+}
+
+// Method to register the handler of a task type
+func (processor *TaskProcessor) Handle(taskType string, handler HandlerFunc) {
+	processor.handlers[taskType] = handler
+}
+
+// Get the delay before the next attempt, given the number of attempts already made
+func Backoff(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+// Method to claim and run a single task. It returns false when no task is due
+func (processor *TaskProcessor) RunOnce(ctx context.Context) (bool, error) {
+	tasks, err := processor.store.ClaimTasks(ctx, db.ClaimTasksParams{
+		LockedUntil: sql.NullTime{Time: time.Now().Add(processor.lease), Valid: true},
+		BatchSize:   1,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	processor.heartbeat.Store(time.Now().UnixNano())
+	if len(tasks) == 0 {
+		return false, nil
+	}
+
+	// Once claimed, the task runs to completion even if the processor is being stopped
+	return true, processor.run(context.WithoutCancel(ctx), tasks[0])
+}
+
+// Get the time the processor last polled the queue successfully, or the zero time if it never did
+func (processor *TaskProcessor) LastHeartbeat() time.Time {
+	nanos := processor.heartbeat.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// Helper method: run a claimed task and record the outcome. The outcome is only recorded while the task is still
+// leased to this processor, otherwise it is left to whoever claimed it since
+func (processor *TaskProcessor) run(ctx context.Context, task db.Task) error {
+	err := processor.execute(ctx, task)
+	if err == nil {
+		rows, err := processor.store.CompleteTask(ctx, db.CompleteTaskParams{
+			TaskID:      task.TaskID,
+			LockedUntil: task.LockedUntil,
+		})
+		return processor.checkLease(task, rows, err)
+	}
+
+	lastError := sql.NullString{String: err.Error(), Valid: true}
+
+	// Out of attempts: move the task to the dead letter queue, where it stays until requeued by an operator
+	if task.Attempts >= task.MaxAttempts {
+		processor.logger.Error("Task moved to dead letter queue",
+			"task_id", task.TaskID, "type", task.Type, "attempts", task.Attempts, "error", err)
+		rows, err := processor.store.KillTask(ctx, db.KillTaskParams{
+			TaskID:      task.TaskID,
+			LastError:   lastError,
+			LockedUntil: task.LockedUntil,
+		})
+		return processor.checkLease(task, rows, err)
+	}
+
+	processor.logger.Warn("Task failed, will retry",
+		"task_id", task.TaskID, "type", task.Type, "attempts", task.Attempts, "error", err)
+	rows, err := processor.store.RetryTask(ctx, db.RetryTaskParams{
+		TaskID:      task.TaskID,
+		RunAt:       time.Now().Add(Backoff(task.Attempts)),
+		LastError:   lastError,
+		LockedUntil: task.LockedUntil,
+	})
+	return processor.checkLease(task, rows, err)
+}
+
+// Helper method: check the outcome of a task was recorded. No row was updated when the lease expired while the task
+// ran, which is logged: the task is claimed again, so its handler runs once more
+func (processor *TaskProcessor) checkLease(task db.Task, rows int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		processor.logger.Warn("Task lease lost before its outcome was recorded",
+			"task_id", task.TaskID, "type", task.Type, "attempts", task.Attempts)
+	}
+	return nil
+}
+
+// Helper method: run the handler of a task, turning panics into errors so one bad task can't stop the worker
+func (processor *TaskProcessor) execute(ctx context.Context, task db.Task) (err error) {
+	handler, ok := processor.handlers[task.Type]
+	if !ok {
+		return fmt.Errorf("no handler registered for task type %s", task.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, task)
+}
+
+// Method to run the processor until the context is cancelled. It returns once every running task has finished
+func (processor *TaskProcessor) Start(ctx context.Context) {
+	processor.logger.Info("Task processor started", "concurrency", processor.concurrency)
+
+	var wg sync.WaitGroup
+	for range processor.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			processor.loop(ctx)
+		}()
+	}
+	wg.Wait()
+
+	processor.logger.Info("Task processor stopped")
+}
+
+// Helper method: claim and run tasks one after the other, sleeping when the queue is empty
+func (processor *TaskProcessor) loop(ctx context.Context) {
+	for {
+		ran, err := processor.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			processor.logger.Error("Failed to process task", "error", err)
+		}
+
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(processor.interval):
+		}
+	}
+}
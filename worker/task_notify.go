@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	db "gobank/db/sqlc"
+	"gobank/notification"
+)
+
+// Task types
+const (
+	TaskNotifyTransfer = "notify:transfer"
+	TaskNotifyWithdraw = "notify:withdraw"
+)
+
+// Payload of the task sending the notifications triggered by a transfer
+type PayloadNotifyTransfer struct {
+	Transfer db.TransferTxResult `json:"transfer"`
+}
+
+// Payload of the task sending the notifications triggered by a withdrawal
+type PayloadNotifyWithdraw struct {
+	Withdrawal db.WithdrawTxResult `json:"withdrawal"`
+}
+
+func (distributor *PostgresTaskDistributor) DistributeTaskNotifyTransfer(
+	ctx context.Context,
+	q db.Querier,
+	payload *PayloadNotifyTransfer,
+	opts ...Option,
+) error {
+	_, err := distributor.enqueue(ctx, q, TaskNotifyTransfer, payload, opts...)
+	return err
+}
+
+func (distributor *PostgresTaskDistributor) DistributeTaskNotifyWithdraw(
+	ctx context.Context,
+	q db.Querier,
+	payload *PayloadNotifyWithdraw,
+	opts ...Option,
+) error {
+	_, err := distributor.enqueue(ctx, q, TaskNotifyWithdraw, payload, opts...)
+	return err
+}
+
+// Method to register the notification task handlers
+func (processor *TaskProcessor) HandleNotifications(service *notification.Service) {
+	processor.Handle(TaskNotifyTransfer, func(ctx context.Context, task db.Task) error {
+		var payload PayloadNotifyTransfer
+		if err := json.Unmarshal(task.Payload, &payload); err != nil {
+			return err
+		}
+		return service.OnTransfer(ctx, payload.Transfer)
+	})
+
+	processor.Handle(TaskNotifyWithdraw, func(ctx context.Context, task db.Task) error {
+		var payload PayloadNotifyWithdraw
+		if err := json.Unmarshal(task.Payload, &payload); err != nil {
+			return err
+		}
+		return service.OnWithdraw(ctx, payload.Withdrawal)
+	})
+}
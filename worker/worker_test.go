@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "gobank/db/sqlc"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, retryBaseDelay, Backoff(1))
+	require.Equal(t, 2*retryBaseDelay, Backoff(2))
+	require.Equal(t, 8*retryBaseDelay, Backoff(4))
+	require.Equal(t, retryMaxDelay, Backoff(100))
+}
+
+func TestExecute(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	processor := NewTaskProcessor(nil, logger, 1, time.Second, time.Minute)
+
+	handlerErr := errors.New("handler failed")
+	processor.Handle("ok", func(ctx context.Context, task db.Task) error { return nil })
+	processor.Handle("fail", func(ctx context.Context, task db.Task) error { return handlerErr })
+	processor.Handle("panic", func(ctx context.Context, task db.Task) error { panic("boom") })
+
+	require.NoError(t, processor.execute(context.Background(), db.Task{Type: "ok"}))
+	require.ErrorIs(t, processor.execute(context.Background(), db.Task{Type: "fail"}), handlerErr)
+	require.ErrorContains(t, processor.execute(context.Background(), db.Task{Type: "panic"}), "boom")
+	require.ErrorContains(t, processor.execute(context.Background(), db.Task{Type: "unknown"}), "no handler")
+}
+
+// Store recording the lease the outcome of a task is recorded with, every other method panics
+type leaseStore struct {
+	db.Store
+	lease sql.NullTime
+	rows  int64
+}
+
+func (store *leaseStore) CompleteTask(ctx context.Context, arg db.CompleteTaskParams) (int64, error) {
+	store.lease = arg.LockedUntil
+	return store.rows, nil
+}
+
+func (store *leaseStore) RetryTask(ctx context.Context, arg db.RetryTaskParams) (int64, error) {
+	store.lease = arg.LockedUntil
+	return store.rows, nil
+}
+
+func TestRunChecksLease(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := &leaseStore{rows: 1}
+	processor := NewTaskProcessor(store, logger, 1, time.Second, time.Minute)
+	processor.Handle("ok", func(ctx context.Context, task db.Task) error { return nil })
+	processor.Handle("fail", func(ctx context.Context, task db.Task) error { return errors.New("handler failed") })
+
+	lease := sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+	task := db.Task{TaskID: 1, Type: "ok", Attempts: 1, MaxAttempts: 3, LockedUntil: lease}
+
+	// The outcome is recorded under the lease the task was claimed with
+	require.NoError(t, processor.run(context.Background(), task))
+	require.Equal(t, lease, store.lease)
+
+	task.Type = "fail"
+	store.lease = sql.NullTime{}
+	require.NoError(t, processor.run(context.Background(), task))
+	require.Equal(t, lease, store.lease)
+
+	// A lost lease isn't an error, the task now belongs to another worker
+	store.rows = 0
+	require.NoError(t, processor.run(context.Background(), task))
+}